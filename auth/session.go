@@ -0,0 +1,144 @@
+package auth
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/yazver/Rocket.Chat.Go.SDK/models"
+)
+
+// Session is the login state of a user. It can be shared by a rest.Client and a
+// realtime.Client, so a token obtained by one of them is used by the other one.
+type Session struct {
+	store Store
+
+	mu          sync.RWMutex
+	credentials models.UserCredentials
+	// tokenLogin is the identity of the login the token belongs to, empty if it's unknown,
+	// e.g. for a token set in the environment by hand.
+	tokenLogin string
+	listeners  []func(models.UserCredentials)
+}
+
+// NewSession creates a session backed by the store, which may be nil. The login credentials,
+// e.g. email and password, are kept in memory to log in again when the token expires. A stored
+// token of another user than the one of the login isn't used.
+func NewSession(store Store, login *models.UserCredentials) (*Session, error) {
+	s := &Session{store: store}
+	if login != nil {
+		s.credentials = *login
+	}
+
+	if store != nil {
+		stored, err := store.Load()
+		switch {
+		case errors.Is(err, ErrNotFound):
+		case err != nil:
+			return nil, err
+		case !sameUser(loginIdentity(*stored), loginIdentity(s.credentials)):
+		default:
+			s.credentials.ID, s.credentials.Token = stored.ID, stored.Token
+			s.tokenLogin = loginIdentity(*stored)
+		}
+	}
+
+	return s, nil
+}
+
+// sameUser reports whether the token of a login may be used for another login, which is the
+// case unless both identities are known and different.
+func sameUser(tokenLogin, login string) bool {
+	return tokenLogin == "" || login == "" || tokenLogin == login
+}
+
+// Credentials returns a copy of the current credentials including the login data.
+func (s *Session) Credentials() models.UserCredentials {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.credentials
+}
+
+// HasToken reports whether the session has a user ID and a token.
+func (s *Session) HasToken() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.credentials.ID != "" && s.credentials.Token != ""
+}
+
+// CanLogin reports whether the session knows how to obtain a new token.
+func (s *Session) CanLogin() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	c := s.credentials
+	return c.Password != "" || c.ServiceName != "" || c.SAMLCredentialToken != ""
+}
+
+// SetLogin replaces the data used to log in. The current token is kept, unless the login is
+// one of another user, e.g. another username or email.
+func (s *Session) SetLogin(login *models.UserCredentials) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id, token := s.credentials.ID, s.credentials.Token
+	s.credentials = *login
+	switch {
+	case s.credentials.Token != "":
+		s.tokenLogin = loginIdentity(s.credentials)
+	case sameUser(s.tokenLogin, loginIdentity(s.credentials)):
+		s.credentials.ID, s.credentials.Token = id, token
+	default:
+		s.tokenLogin = ""
+	}
+}
+
+// SetToken updates the token, stores it and informs the listeners.
+func (s *Session) SetToken(id, token string) error {
+	s.mu.Lock()
+	changed := s.credentials.ID != id || s.credentials.Token != token
+	s.credentials.ID, s.credentials.Token = id, token
+	s.tokenLogin = loginIdentity(s.credentials)
+	credentials, listeners := s.credentials, s.listeners
+	s.mu.Unlock()
+
+	if !changed {
+		return nil
+	}
+
+	if s.store != nil {
+		if err := s.store.Save(&credentials); err != nil {
+			return err
+		}
+	}
+
+	for _, listener := range listeners {
+		listener(credentials)
+	}
+	return nil
+}
+
+// ClearToken forgets the token, e.g. after a logout or when it was rejected.
+// The token is only cleared if it's still the given one, which prevents to drop
+// a token just refreshed by another client.
+func (s *Session) ClearToken(token string) error {
+	s.mu.Lock()
+	if s.credentials.Token != token {
+		s.mu.Unlock()
+		return nil
+	}
+	s.credentials.ID, s.credentials.Token = "", ""
+	s.tokenLogin = ""
+	s.mu.Unlock()
+
+	if s.store != nil {
+		return s.store.Clear()
+	}
+	return nil
+}
+
+// AddTokenListener registers a function called after the token changed.
+func (s *Session) AddTokenListener(listener func(credentials models.UserCredentials)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listeners = append(s.listeners, listener)
+}
@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yazver/Rocket.Chat.Go.SDK/models"
+)
+
+func testStores(t *testing.T) []Store {
+	env := &EnvStore{IDVariable: "TEST_ROCKETCHAT_USER_ID", TokenVariable: "TEST_ROCKETCHAT_AUTH_TOKEN", LoginVariable: "TEST_ROCKETCHAT_LOGIN"}
+	return []Store{NewMemoryStore(), NewFileStore(filepath.Join(t.TempDir(), "rocketchat", "token.json")), env}
+}
+
+func TestStores(t *testing.T) {
+	stores := testStores(t)
+
+	for _, store := range stores {
+		_, err := store.Load()
+		assert.Equal(t, ErrNotFound, err)
+
+		assert.Nil(t, store.Save(&models.UserCredentials{ID: "id", Token: "token", Password: "secret"}))
+		credentials, err := store.Load()
+		assert.Nil(t, err)
+		assert.Equal(t, &models.UserCredentials{ID: "id", Token: "token"}, credentials)
+
+		assert.Nil(t, store.Save(&models.UserCredentials{ID: "id", Token: "token", Email: "alice@localhost.com", Password: "secret"}))
+		credentials, err = store.Load()
+		assert.Nil(t, err)
+		assert.Equal(t, "id", credentials.ID)
+		assert.Equal(t, "token", credentials.Token)
+		assert.Equal(t, "", credentials.Password)
+		assert.Equal(t, "email:alice@localhost.com", loginIdentity(*credentials))
+
+		assert.Nil(t, store.Clear())
+		_, err = store.Load()
+		assert.Equal(t, ErrNotFound, err)
+	}
+}
+
+func TestSession(t *testing.T) {
+	store := NewMemoryStore()
+	assert.Nil(t, store.Save(&models.UserCredentials{ID: "id", Token: "old"}))
+
+	session, err := NewSession(store, &models.UserCredentials{Email: "bot@localhost.com", Password: "pass"})
+	assert.Nil(t, err)
+	assert.True(t, session.HasToken())
+	assert.True(t, session.CanLogin())
+
+	var notified models.UserCredentials
+	session.AddTokenListener(func(credentials models.UserCredentials) { notified = credentials })
+
+	assert.Nil(t, session.SetToken("id", "new"))
+	assert.Equal(t, "new", notified.Token)
+
+	// A stale token doesn't clear the current one.
+	assert.Nil(t, session.ClearToken("old"))
+	assert.True(t, session.HasToken())
+
+	assert.Nil(t, session.ClearToken("new"))
+	assert.False(t, session.HasToken())
+	assert.Equal(t, "pass", session.Credentials().Password)
+
+	_, err = store.Load()
+	assert.Equal(t, ErrNotFound, err)
+}
+
+func TestSession_SwitchUser(t *testing.T) {
+	alice := &models.UserCredentials{Email: "alice@localhost.com", Password: "alice"}
+	bob := &models.UserCredentials{Username: "bob", Password: "bob"}
+
+	for _, store := range testStores(t) {
+		session, err := NewSession(store, alice)
+		assert.Nil(t, err)
+		assert.Nil(t, session.SetToken("alice", "alice-token"))
+
+		// The same user keeps the token.
+		session.SetLogin(&models.UserCredentials{Email: "alice@localhost.com", Password: "alice"})
+		assert.Equal(t, "alice-token", session.Credentials().Token)
+
+		session.SetLogin(bob)
+		assert.False(t, session.HasToken())
+		assert.Equal(t, "bob", session.Credentials().Username)
+
+		// A new session of another user doesn't use the stored token either.
+		session, err = NewSession(store, bob)
+		assert.Nil(t, err)
+		assert.False(t, session.HasToken())
+
+		session, err = NewSession(store, alice)
+		assert.Nil(t, err)
+		assert.Equal(t, "alice-token", session.Credentials().Token)
+
+		// A login with a token replaces the one of the session.
+		session.SetLogin(&models.UserCredentials{ID: "bob", Token: "bob-token"})
+		assert.Equal(t, "bob-token", session.Credentials().Token)
+
+		assert.Nil(t, store.Clear())
+	}
+}
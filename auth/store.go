@@ -0,0 +1,208 @@
+// Package auth persists login tokens and shares them between the rest and realtime clients.
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/yazver/Rocket.Chat.Go.SDK/models"
+)
+
+const (
+	DefaultIDVariable    = "ROCKETCHAT_USER_ID"
+	DefaultTokenVariable = "ROCKETCHAT_AUTH_TOKEN"
+	DefaultLoginVariable = "ROCKETCHAT_LOGIN"
+)
+
+var (
+	ErrNotFound = errors.New("no stored credentials")
+)
+
+// Store persists the user ID and the auth token with the login method and name they were
+// obtained with, so the token of one user isn't used for the login of another one.
+// Passwords are never stored.
+type Store interface {
+	// Load returns the stored credentials or ErrNotFound.
+	Load() (*models.UserCredentials, error)
+	// Save stores the ID, the token and the login identity of the credentials.
+	Save(credentials *models.UserCredentials) error
+	// Clear removes the stored credentials.
+	Clear() error
+}
+
+type storedToken struct {
+	ID    string `json:"id"`
+	Token string `json:"token"`
+	// Login is the identity of the login, see loginIdentity.
+	Login string `json:"login,omitempty"`
+}
+
+func newStoredToken(credentials *models.UserCredentials) *storedToken {
+	return &storedToken{ID: credentials.ID, Token: credentials.Token, Login: loginIdentity(*credentials)}
+}
+
+func (t *storedToken) credentials() *models.UserCredentials {
+	return withIdentity(&models.UserCredentials{ID: t.ID, Token: t.Token}, t.Login)
+}
+
+// loginIdentity identifies the user of a login by its method and name, e.g. "username:bob".
+// It's empty if the login doesn't tell the user, e.g. for tokens or OAuth.
+func loginIdentity(c models.UserCredentials) string {
+	c.ID, c.Token = "", ""
+	if c.Login() == "" {
+		return ""
+	}
+	return string(c.Method()) + ":" + c.Login()
+}
+
+// withIdentity sets the login method and name of a stored identity to the credentials.
+func withIdentity(credentials *models.UserCredentials, identity string) *models.UserCredentials {
+	if i := strings.Index(identity, ":"); i > 0 {
+		credentials.LoginMethod = models.LoginMethod(identity[:i])
+		credentials.Username = identity[i+1:]
+	}
+	return credentials
+}
+
+// MemoryStore keeps the credentials for the lifetime of the process.
+type MemoryStore struct {
+	mu    sync.Mutex
+	token *storedToken
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+func (s *MemoryStore) Load() (*models.UserCredentials, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token == nil {
+		return nil, ErrNotFound
+	}
+	return s.token.credentials(), nil
+}
+
+func (s *MemoryStore) Save(credentials *models.UserCredentials) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.token = newStoredToken(credentials)
+	return nil
+}
+
+func (s *MemoryStore) Clear() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.token = nil
+	return nil
+}
+
+// FileStore keeps the credentials in a JSON file only readable by the current user.
+type FileStore struct {
+	Path string
+}
+
+func NewFileStore(path string) *FileStore {
+	return &FileStore{Path: path}
+}
+
+func (s *FileStore) Load() (*models.UserCredentials, error) {
+	data, err := ioutil.ReadFile(s.Path)
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("reading credentials: %w", err)
+	}
+
+	token := new(storedToken)
+	if err := json.Unmarshal(data, token); err != nil {
+		return nil, fmt.Errorf("parsing credentials: %w", err)
+	}
+	if token.ID == "" || token.Token == "" {
+		return nil, ErrNotFound
+	}
+
+	return token.credentials(), nil
+}
+
+func (s *FileStore) Save(credentials *models.UserCredentials) error {
+	data, err := json.Marshal(newStoredToken(credentials))
+	if err != nil {
+		return fmt.Errorf("marshaling credentials: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0700); err != nil {
+		return fmt.Errorf("creating credentials directory: %w", err)
+	}
+	if err := ioutil.WriteFile(s.Path, data, 0600); err != nil {
+		return fmt.Errorf("writing credentials: %w", err)
+	}
+	return nil
+}
+
+func (s *FileStore) Clear() error {
+	if err := os.Remove(s.Path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("removing credentials: %w", err)
+	}
+	return nil
+}
+
+// EnvStore reads the credentials from environment variables. Saving only changes the
+// environment of the current process, which is inherited by started child processes.
+// LoginVariable, which may be empty, keeps the login identity of the token.
+type EnvStore struct {
+	IDVariable    string
+	TokenVariable string
+	LoginVariable string
+}
+
+// NewEnvStore creates a store using ROCKETCHAT_USER_ID, ROCKETCHAT_AUTH_TOKEN and
+// ROCKETCHAT_LOGIN.
+func NewEnvStore() *EnvStore {
+	return &EnvStore{IDVariable: DefaultIDVariable, TokenVariable: DefaultTokenVariable, LoginVariable: DefaultLoginVariable}
+}
+
+func (s *EnvStore) Load() (*models.UserCredentials, error) {
+	id, token := os.Getenv(s.IDVariable), os.Getenv(s.TokenVariable)
+	if id == "" || token == "" {
+		return nil, ErrNotFound
+	}
+	credentials := &models.UserCredentials{ID: id, Token: token}
+	if s.LoginVariable != "" {
+		withIdentity(credentials, os.Getenv(s.LoginVariable))
+	}
+	return credentials, nil
+}
+
+func (s *EnvStore) Save(credentials *models.UserCredentials) error {
+	if err := os.Setenv(s.IDVariable, credentials.ID); err != nil {
+		return err
+	}
+	if s.LoginVariable != "" {
+		if err := os.Setenv(s.LoginVariable, loginIdentity(*credentials)); err != nil {
+			return err
+		}
+	}
+	return os.Setenv(s.TokenVariable, credentials.Token)
+}
+
+func (s *EnvStore) Clear() error {
+	if err := os.Unsetenv(s.IDVariable); err != nil {
+		return err
+	}
+	if s.LoginVariable != "" {
+		if err := os.Unsetenv(s.LoginVariable); err != nil {
+			return err
+		}
+	}
+	return os.Unsetenv(s.TokenVariable)
+}
@@ -0,0 +1,93 @@
+// Command rocketchat-sync brings the settings, roles, permissions and default channels of a server
+// to the state described by a YAML or JSON file.
+//
+// Usage:
+//
+//	rocketchat-sync -url https://chat.example.com -file server.yaml [-dry-run]
+//
+// The token is taken from ROCKETCHAT_USER_ID and ROCKETCHAT_AUTH_TOKEN, otherwise the
+// user logs in with ROCKETCHAT_USER and ROCKETCHAT_PASSWORD.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/yazver/Rocket.Chat.Go.SDK/auth"
+	"github.com/yazver/Rocket.Chat.Go.SDK/configsync"
+	"github.com/yazver/Rocket.Chat.Go.SDK/models"
+	"github.com/yazver/Rocket.Chat.Go.SDK/rest"
+)
+
+func main() {
+	serverURL := flag.String("url", os.Getenv("ROCKETCHAT_URL"), "URL of the server")
+	file := flag.String("file", "rocketchat.yaml", "desired state file, YAML or JSON")
+	dryRun := flag.Bool("dry-run", false, "only print the plan")
+	debug := flag.Bool("debug", false, "log the network communication")
+	flag.Parse()
+
+	if err := run(*serverURL, *file, *dryRun, *debug); err != nil {
+		fmt.Fprintln(os.Stderr, "rocketchat-sync:", err)
+		os.Exit(1)
+	}
+}
+
+func run(serverURL, file string, dryRun, debug bool) error {
+	config, err := configsync.Load(file)
+	if err != nil {
+		return err
+	}
+
+	u, err := url.Parse(serverURL)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("invalid server url %q", serverURL)
+	}
+
+	client := rest.NewClient(u, debug)
+	if err := login(client); err != nil {
+		return err
+	}
+
+	plan, err := configsync.Diff(client, config)
+	if err != nil {
+		return fmt.Errorf("planning: %w", err)
+	}
+	if err := plan.Print(os.Stdout); err != nil {
+		return err
+	}
+
+	if dryRun || plan.Empty() {
+		return nil
+	}
+	if err := plan.Apply(client); err != nil {
+		return err
+	}
+	fmt.Println("Applied.")
+	return nil
+}
+
+func login(client *rest.Client) error {
+	credentials := &models.UserCredentials{Password: os.Getenv("ROCKETCHAT_PASSWORD")}
+	if user := os.Getenv("ROCKETCHAT_USER"); strings.Contains(user, "@") {
+		credentials.Email = user
+	} else {
+		credentials.Username = user
+	}
+
+	session, err := auth.NewSession(auth.NewEnvStore(), credentials)
+	if err != nil {
+		return err
+	}
+	if !session.HasToken() && !session.CanLogin() {
+		return fmt.Errorf("set ROCKETCHAT_USER_ID and ROCKETCHAT_AUTH_TOKEN or ROCKETCHAT_USER and ROCKETCHAT_PASSWORD")
+	}
+
+	client.Session = session
+	if _, err := client.Login(credentials); err != nil {
+		return fmt.Errorf("login: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"golang.org/x/term"
+
+	"github.com/yazver/Rocket.Chat.Go.SDK/auth"
+	"github.com/yazver/Rocket.Chat.Go.SDK/models"
+	"github.com/yazver/Rocket.Chat.Go.SDK/rest"
+)
+
+type app struct {
+	serverURL string
+	json      bool
+	debug     bool
+	out       io.Writer
+
+	client *rest.Client
+}
+
+// configDir is where the server of the last login and the tokens are kept.
+func configDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "rocketchat"), nil
+}
+
+// server returns the URL of the server, remembered from the last login if it's not given.
+func (a *app) server() (*url.URL, error) {
+	serverURL := a.serverURL
+	if serverURL == "" {
+		dir, err := configDir()
+		if err != nil {
+			return nil, err
+		}
+		data, err := ioutil.ReadFile(filepath.Join(dir, "server"))
+		if err != nil {
+			return nil, errors.New("no server, use -url or ROCKETCHAT_URL")
+		}
+		serverURL = strings.TrimSpace(string(data))
+	}
+
+	u, err := url.Parse(serverURL)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid server url %q", serverURL)
+	}
+	return u, nil
+}
+
+func (a *app) rememberServer(u *url.URL) error {
+	dir, err := configDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, "server"), []byte(u.String()+"\n"), 0600)
+}
+
+// tokenStore keeps the token of a server, ROCKETCHAT_USER_ID and ROCKETCHAT_AUTH_TOKEN win over the file.
+func tokenStore(u *url.URL) (auth.Store, error) {
+	if os.Getenv(auth.DefaultTokenVariable) != "" {
+		return auth.NewEnvStore(), nil
+	}
+	return fileStore(u)
+}
+
+func fileStore(u *url.URL) (*auth.FileStore, error) {
+	dir, err := configDir()
+	if err != nil {
+		return nil, err
+	}
+	return auth.NewFileStore(filepath.Join(dir, "token-"+u.Host+".json")), nil
+}
+
+// connect creates a rest client logged in with the kept token.
+func (a *app) connect() (*rest.Client, error) {
+	if a.client != nil {
+		return a.client, nil
+	}
+
+	u, err := a.server()
+	if err != nil {
+		return nil, err
+	}
+
+	store, err := tokenStore(u)
+	if err != nil {
+		return nil, err
+	}
+	session, err := auth.NewSession(store, nil)
+	if err != nil {
+		return nil, err
+	}
+	if !session.HasToken() {
+		return nil, errors.New("not logged in, run rocketchat login first")
+	}
+
+	client := rest.NewClient(u, a.debug)
+	client.Session = session
+	client.TwoFactorCode = askTwoFactorCode
+	if _, err := client.Login(&models.UserCredentials{}); err != nil {
+		return nil, fmt.Errorf("login: %w", err)
+	}
+
+	a.client = client
+	return client, nil
+}
+
+func askTwoFactorCode(method models.TwoFactorMethod) (string, error) {
+	return prompt(fmt.Sprintf("Two-factor code (%s): ", method))
+}
+
+// promptPassword asks for a password without echoing it if stdin is a terminal.
+func promptPassword(text string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return prompt(text)
+	}
+
+	fmt.Fprint(os.Stderr, text)
+	password, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(password)), nil
+}
+
+func prompt(text string) (string, error) {
+	fmt.Fprint(os.Stderr, text)
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// print writes v as JSON or the rows as a table.
+func (a *app) print(v interface{}, headers []string, rows [][]string) error {
+	if a.json {
+		encoder := json.NewEncoder(a.out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(v)
+	}
+
+	w := tabwriter.NewWriter(a.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(headers, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	return w.Flush()
+}
+
+// done tells the user that a command without results succeeded.
+func (a *app) done(format string, args ...interface{}) error {
+	if a.json {
+		return a.print(map[string]bool{"success": true}, nil, nil)
+	}
+	_, err := fmt.Fprintf(a.out, format+"\n", args...)
+	return err
+}
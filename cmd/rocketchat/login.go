@@ -0,0 +1,80 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/yazver/Rocket.Chat.Go.SDK/auth"
+	"github.com/yazver/Rocket.Chat.Go.SDK/models"
+	"github.com/yazver/Rocket.Chat.Go.SDK/rest"
+)
+
+func runLogin(a *app, args []string) error {
+	flags := flag.NewFlagSet("login", flag.ContinueOnError)
+	user := flags.String("user", os.Getenv("ROCKETCHAT_USER"), "username or email")
+	password := flags.String("password", os.Getenv("ROCKETCHAT_PASSWORD"), "password, asked for if not set")
+	if err := flags.Parse(args); err != nil || *user == "" {
+		return errUsage
+	}
+
+	u, err := a.server()
+	if err != nil {
+		return err
+	}
+
+	if *password == "" {
+		if *password, err = promptPassword("Password: "); err != nil {
+			return err
+		}
+	}
+
+	credentials := &models.UserCredentials{Password: *password}
+	if strings.Contains(*user, "@") {
+		credentials.Email = *user
+	} else {
+		credentials.Username = *user
+	}
+
+	store, err := fileStore(u)
+	if err != nil {
+		return err
+	}
+	session, err := auth.NewSession(store, credentials)
+	if err != nil {
+		return err
+	}
+	// A new login replaces the kept token.
+	if err := session.ClearToken(session.Credentials().Token); err != nil {
+		return err
+	}
+
+	client := rest.NewClient(u, a.debug)
+	client.Session = session
+	client.TwoFactorCode = askTwoFactorCode
+	me, err := client.Login(credentials)
+	if err != nil {
+		return fmt.Errorf("login: %w", err)
+	}
+
+	if err := a.rememberServer(u); err != nil {
+		return err
+	}
+	if a.json {
+		return a.print(me, nil, nil)
+	}
+	return a.done("Logged in to %s as %s.", u.Host, me.UserName)
+}
+
+func runLogout(a *app, args []string) error {
+	client, err := a.connect()
+	if err != nil {
+		return err
+	}
+
+	if _, err := client.Logout(); err != nil {
+		return err
+	}
+	return a.done("Logged out.")
+}
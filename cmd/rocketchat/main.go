@@ -0,0 +1,92 @@
+// Command rocketchat is a command line client for Rocket.Chat servers.
+//
+// Usage:
+//
+//	rocketchat [-url URL] [-o table|json] [-debug] <command> [arguments]
+//
+// Commands:
+//
+//	login -user USER [-password PASSWORD]   log in and keep the token
+//	logout                                  log out and forget the token
+//	send -room ROOM [-file FILE] [TEXT...]  send a message or upload a file
+//	rooms                                   list the joined rooms
+//	members -room ROOM                      list the members of a room
+//	create [-private] [-readonly] [-members a,b] NAME
+//	archive -room ROOM                      archive a room
+//	tail -room ROOM                         print new messages of a room as they come
+//	export -room ROOM [-format jsonl|csv|html] [-attachments DIR] FILE
+//	users list|info|create|delete|activate|deactivate
+//
+// The server is taken from -url, ROCKETCHAT_URL or the last login. The token of a login is kept
+// in the user's config directory, ROCKETCHAT_USER_ID and ROCKETCHAT_AUTH_TOKEN may be used instead.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+)
+
+var errUsage = errors.New("usage")
+
+type command struct {
+	run   func(app *app, args []string) error
+	usage string
+}
+
+var commands = map[string]command{
+	"login":   {runLogin, "login -user USER [-password PASSWORD]"},
+	"logout":  {runLogout, "logout"},
+	"send":    {runSend, "send -room ROOM [-file FILE] [TEXT...]"},
+	"rooms":   {runRooms, "rooms"},
+	"members": {runMembers, "members -room ROOM"},
+	"create":  {runCreate, "create [-private] [-readonly] [-members a,b] NAME"},
+	"archive": {runArchive, "archive -room ROOM"},
+	"tail":    {runTail, "tail -room ROOM"},
+	"export":  {runExport, "export -room ROOM [-format jsonl|csv|html] [-attachments DIR] FILE"},
+	"users":   {runUsers, "users list|info USER|create -username USER -email EMAIL -password PASSWORD|delete USER|activate USER|deactivate USER"},
+}
+
+func main() {
+	flags := flag.NewFlagSet("rocketchat", flag.ExitOnError)
+	serverURL := flags.String("url", os.Getenv("ROCKETCHAT_URL"), "URL of the server")
+	output := flags.String("o", "table", "output format, table or json")
+	debug := flags.Bool("debug", false, "log the network communication")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: rocketchat [flags] <command> [arguments]\n\nFlags:")
+		flags.PrintDefaults()
+		fmt.Fprintln(flags.Output(), "\nCommands:")
+		for _, name := range []string{"login", "logout", "send", "rooms", "members", "create", "archive", "tail", "export", "users"} {
+			fmt.Fprintln(flags.Output(), "  "+commands[name].usage)
+		}
+	}
+	_ = flags.Parse(os.Args[1:])
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	cmd, ok := commands[flags.Arg(0)]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "rocketchat: unknown command %q\n", flags.Arg(0))
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	if *output != "table" && *output != "json" {
+		fmt.Fprintf(os.Stderr, "rocketchat: unknown output format %q\n", *output)
+		os.Exit(2)
+	}
+
+	app := &app{serverURL: *serverURL, json: *output == "json", debug: *debug, out: os.Stdout}
+	if err := cmd.run(app, flags.Args()[1:]); err != nil {
+		if errors.Is(err, errUsage) {
+			fmt.Fprintln(os.Stderr, "Usage: rocketchat "+cmd.usage)
+			os.Exit(2)
+		}
+		fmt.Fprintln(os.Stderr, "rocketchat:", err)
+		os.Exit(1)
+	}
+}
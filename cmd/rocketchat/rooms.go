@@ -0,0 +1,313 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yazver/Rocket.Chat.Go.SDK/export"
+	"github.com/yazver/Rocket.Chat.Go.SDK/models"
+	"github.com/yazver/Rocket.Chat.Go.SDK/realtime"
+	"github.com/yazver/Rocket.Chat.Go.SDK/rest"
+)
+
+// room looks a room up by its name, as channel first and as private group then.
+func room(client *rest.Client, name string) (*models.Channel, error) {
+	name = strings.TrimPrefix(name, "#")
+
+	channel, err := client.GetChannelInfo(&models.Channel{Name: name})
+	if err == nil {
+		return channel, nil
+	}
+
+	group, groupErr := client.GetGroupInfo(&models.Group{Name: name})
+	if groupErr != nil {
+		return nil, fmt.Errorf("room %s: %w", name, err)
+	}
+	return group, nil
+}
+
+func roomFlag(flags *flag.FlagSet) *string {
+	return flags.String("room", "", "name of the room")
+}
+
+func runSend(a *app, args []string) error {
+	flags := flag.NewFlagSet("send", flag.ContinueOnError)
+	roomName := roomFlag(flags)
+	file := flags.String("file", "", "file to upload")
+	if err := flags.Parse(args); err != nil || *roomName == "" {
+		return errUsage
+	}
+	text := strings.Join(flags.Args(), " ")
+	if text == "" && *file == "" {
+		return errUsage
+	}
+
+	client, err := a.connect()
+	if err != nil {
+		return err
+	}
+	target, err := room(client, *roomName)
+	if err != nil {
+		return err
+	}
+
+	var message *models.Message
+	if *file != "" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		if message, err = client.UploadFile(target.ID, filepath.Base(*file), f, text); err != nil {
+			return err
+		}
+	} else {
+		response, err := client.PostMessage(&models.PostMessage{RoomID: target.ID, Text: text})
+		if err != nil {
+			return err
+		}
+		message = &response.Message
+	}
+
+	if a.json {
+		return a.print(message, nil, nil)
+	}
+	return a.done("Sent to %s.", target.Name)
+}
+
+func runRooms(a *app, args []string) error {
+	client, err := a.connect()
+	if err != nil {
+		return err
+	}
+
+	channels, err := client.GetJoinedChannels(nil)
+	if err != nil {
+		return err
+	}
+	groups, err := client.ListGroup()
+	if err != nil {
+		return err
+	}
+
+	rooms := append(append([]models.Channel{}, channels.Channels...), groups...)
+	rows := make([][]string, 0, len(rooms))
+	for _, r := range rooms {
+		rows = append(rows, []string{r.ID, r.Name, roomType(r.Type), strconv.Itoa(r.Msgs)})
+	}
+	return a.print(rooms, []string{"ID", "NAME", "TYPE", "MESSAGES"}, rows)
+}
+
+func roomType(t string) string {
+	switch t {
+	case "c":
+		return "channel"
+	case "p":
+		return "private"
+	case "d":
+		return "direct"
+	}
+	return t
+}
+
+func runMembers(a *app, args []string) error {
+	flags := flag.NewFlagSet("members", flag.ContinueOnError)
+	roomName := roomFlag(flags)
+	if err := flags.Parse(args); err != nil || *roomName == "" {
+		return errUsage
+	}
+
+	client, err := a.connect()
+	if err != nil {
+		return err
+	}
+	target, err := room(client, *roomName)
+	if err != nil {
+		return err
+	}
+
+	var members []models.User
+	if target.Type == "p" {
+		members, err = client.MembersGroup(target)
+	} else {
+		var response *rest.ChannelMembersResponse
+		if response, err = client.GetChannelMembers(target.ID, nil); err == nil {
+			members = response.Members
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, 0, len(members))
+	for _, member := range members {
+		rows = append(rows, []string{member.ID, member.UserName, member.Name, member.Status})
+	}
+	return a.print(members, []string{"ID", "USERNAME", "NAME", "STATUS"}, rows)
+}
+
+func runCreate(a *app, args []string) error {
+	flags := flag.NewFlagSet("create", flag.ContinueOnError)
+	private := flags.Bool("private", false, "create a private group")
+	readOnly := flags.Bool("readonly", false, "only some users may write")
+	members := flags.String("members", "", "comma separated usernames")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 1 {
+		return errUsage
+	}
+
+	req := &models.CreateChannelRequest{Name: flags.Arg(0), ReadOnly: *readOnly, Members: []string{}}
+	if *members != "" {
+		req.Members = strings.Split(*members, ",")
+	}
+
+	client, err := a.connect()
+	if err != nil {
+		return err
+	}
+
+	var created *models.Channel
+	if *private {
+		created, err = client.CreateGroup(req)
+	} else {
+		created, err = client.CreateChannel(req)
+	}
+	if err != nil {
+		return err
+	}
+
+	if a.json {
+		return a.print(created, nil, nil)
+	}
+	return a.done("Created %s (%s).", created.Name, created.ID)
+}
+
+func runArchive(a *app, args []string) error {
+	flags := flag.NewFlagSet("archive", flag.ContinueOnError)
+	roomName := roomFlag(flags)
+	if err := flags.Parse(args); err != nil || *roomName == "" {
+		return errUsage
+	}
+
+	client, err := a.connect()
+	if err != nil {
+		return err
+	}
+	target, err := room(client, *roomName)
+	if err != nil {
+		return err
+	}
+
+	if target.Type == "p" {
+		err = client.ArchiveGroup(target.ID)
+	} else {
+		err = client.ArchiveChannel(target.ID)
+	}
+	if err != nil {
+		return err
+	}
+	return a.done("Archived %s.", target.Name)
+}
+
+func runTail(a *app, args []string) error {
+	flags := flag.NewFlagSet("tail", flag.ContinueOnError)
+	roomName := roomFlag(flags)
+	if err := flags.Parse(args); err != nil || *roomName == "" {
+		return errUsage
+	}
+
+	client, err := a.connect()
+	if err != nil {
+		return err
+	}
+	target, err := room(client, *roomName)
+	if err != nil {
+		return err
+	}
+
+	u, err := a.server()
+	if err != nil {
+		return err
+	}
+	live, err := realtime.NewClient(u, a.debug)
+	if err != nil {
+		return err
+	}
+	defer live.Close()
+
+	live.Session = client.Session
+	live.TwoFactorCode = askTwoFactorCode
+	if _, err := live.Login(&models.UserCredentials{}); err != nil {
+		return fmt.Errorf("login: %w", err)
+	}
+
+	messages := make(chan models.Message, 100)
+	if err := live.SubscribeToMessageStream(target, messages); err != nil {
+		return err
+	}
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+
+	for {
+		select {
+		case message := <-messages:
+			if err := a.printMessage(&message); err != nil {
+				return err
+			}
+		case <-interrupt:
+			return nil
+		}
+	}
+}
+
+func (a *app) printMessage(message *models.Message) error {
+	if a.json {
+		return json.NewEncoder(a.out).Encode(message)
+	}
+
+	ts := time.Now()
+	if message.Timestamp != nil {
+		ts = *message.Timestamp
+	}
+	username := ""
+	if message.User != nil {
+		username = message.User.UserName
+	}
+	_, err := fmt.Fprintf(a.out, "%s %s: %s\n", ts.Local().Format("15:04:05"), username, message.Msg)
+	return err
+}
+
+func runExport(a *app, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	roomName := roomFlag(flags)
+	format := flags.String("format", "jsonl", "file format, jsonl, csv or html")
+	attachments := flags.String("attachments", "", "directory to download the attachments to")
+	if err := flags.Parse(args); err != nil || *roomName == "" || flags.NArg() != 1 {
+		return errUsage
+	}
+
+	client, err := a.connect()
+	if err != nil {
+		return err
+	}
+	target, err := room(client, *roomName)
+	if err != nil {
+		return err
+	}
+
+	exporter := export.NewExporter(client, export.Format(*format))
+	exporter.AttachmentsDir = *attachments
+	count, err := exporter.ExportFile(flags.Arg(0), target)
+	if err != nil {
+		return err
+	}
+	return a.done("Exported %d messages of %s.", count, target.Name)
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"flag"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/yazver/Rocket.Chat.Go.SDK/models"
+	"github.com/yazver/Rocket.Chat.Go.SDK/rest"
+)
+
+func runUsers(a *app, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	client, err := a.connect()
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "list":
+		return listUsers(a, client, args[1:])
+	case "info":
+		if len(args) != 2 {
+			return errUsage
+		}
+		user, err := client.GetUserInfo(&models.User{UserName: args[1]})
+		if err != nil {
+			return err
+		}
+		return a.print(user, userHeaders, [][]string{userRow(user)})
+	case "create":
+		return createUser(a, client, args[1:])
+	case "delete", "activate", "deactivate":
+		if len(args) != 2 {
+			return errUsage
+		}
+		user, err := client.GetUserInfo(&models.User{UserName: args[1]})
+		if err != nil {
+			return err
+		}
+
+		switch args[0] {
+		case "delete":
+			err = client.DeleteUser(user, false)
+		default:
+			_, err = client.SetUserActiveStatus(user.ID, args[0] == "activate", false)
+		}
+		if err != nil {
+			return err
+		}
+		return a.done("%s %sd.", user.UserName, strings.TrimSuffix(args[0], "e"))
+	}
+	return errUsage
+}
+
+var userHeaders = []string{"ID", "USERNAME", "NAME", "EMAIL", "ROLES", "ACTIVE"}
+
+func userRow(user *models.User) []string {
+	return []string{user.ID, user.UserName, user.Name, user.Email(), strings.Join(user.Roles, ","), strconv.FormatBool(user.Active)}
+}
+
+func listUsers(a *app, client *rest.Client, args []string) error {
+	flags := flag.NewFlagSet("users list", flag.ContinueOnError)
+	count := flags.Int("count", 50, "number of users")
+	offset := flags.Int("offset", 0, "number of users to skip")
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+
+	response, err := client.ListUsers(url.Values{
+		"count":  []string{strconv.Itoa(*count)},
+		"offset": []string{strconv.Itoa(*offset)},
+	})
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, 0, len(response.Users))
+	for i := range response.Users {
+		rows = append(rows, userRow(&response.Users[i]))
+	}
+	return a.print(response.Users, userHeaders, rows)
+}
+
+func createUser(a *app, client *rest.Client, args []string) error {
+	flags := flag.NewFlagSet("users create", flag.ContinueOnError)
+	req := new(models.CreateUserRequest)
+	flags.StringVar(&req.Username, "username", "", "username")
+	flags.StringVar(&req.Email, "email", "", "email address")
+	flags.StringVar(&req.Name, "name", "", "display name, defaults to the username")
+	flags.StringVar(&req.Password, "password", "", "password")
+	roles := flags.String("roles", "", "comma separated roles")
+	if err := flags.Parse(args); err != nil || req.Username == "" || req.Email == "" || req.Password == "" {
+		return errUsage
+	}
+	if req.Name == "" {
+		req.Name = req.Username
+	}
+	if *roles != "" {
+		req.Roles = strings.Split(*roles, ",")
+	}
+
+	response, err := client.CreateUser(req)
+	if err != nil {
+		return err
+	}
+	return a.print(&response.User, userHeaders, [][]string{userRow(&response.User)})
+}
@@ -0,0 +1,95 @@
+// Package configsync keeps the configuration of a server in a desired state described by a YAML or
+// JSON file: settings, roles, permissions and default channels.
+package configsync
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/yazver/Rocket.Chat.Go.SDK/models"
+)
+
+// Config is the desired state of a server. Only what's listed is changed,
+// settings, roles, permissions and channels not mentioned are left alone.
+type Config struct {
+	// Settings maps setting IDs to their values.
+	Settings map[string]interface{} `json:"settings,omitempty" yaml:"settings,omitempty"`
+	// Roles are created or updated by their names.
+	Roles []Role `json:"roles,omitempty" yaml:"roles,omitempty"`
+	// Permissions maps permission IDs to the complete list of roles having them.
+	Permissions map[string][]string `json:"permissions,omitempty" yaml:"permissions,omitempty"`
+	// DefaultChannels are created if needed and marked as default, so new users join them.
+	DefaultChannels []string `json:"defaultChannels,omitempty" yaml:"defaultChannels,omitempty"`
+}
+
+type Role struct {
+	Name         string           `json:"name" yaml:"name"`
+	Description  string           `json:"description,omitempty" yaml:"description,omitempty"`
+	Scope        models.RoleScope `json:"scope,omitempty" yaml:"scope,omitempty"`
+	Mandatory2FA bool             `json:"mandatory2fa,omitempty" yaml:"mandatory2fa,omitempty"`
+}
+
+// Format is the encoding of a config file.
+type Format string
+
+const (
+	FormatYAML Format = "yaml"
+	FormatJSON Format = "json"
+)
+
+// Load reads a config file. Files ending with .json are read as JSON, all others as YAML.
+func Load(path string) (*Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading config: %w", err)
+	}
+
+	format := FormatYAML
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		format = FormatJSON
+	}
+
+	config, err := Parse(data, format)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return config, nil
+}
+
+// Parse decodes a config. Unknown fields are rejected to catch typos.
+func Parse(data []byte, format Format) (*Config, error) {
+	config := new(Config)
+
+	switch format {
+	case FormatJSON:
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(config); err != nil {
+			return nil, fmt.Errorf("decoding json config: %w", err)
+		}
+	case FormatYAML:
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(config); err != nil {
+			return nil, fmt.Errorf("decoding yaml config: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unknown config format %q", format)
+	}
+
+	for i, role := range config.Roles {
+		if role.Name == "" {
+			return nil, fmt.Errorf("role %d has no name", i+1)
+		}
+		if role.Scope == "" {
+			config.Roles[i].Scope = models.RoleScopeUsers
+		}
+	}
+	return config, nil
+}
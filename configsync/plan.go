@@ -0,0 +1,309 @@
+package configsync
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yazver/Rocket.Chat.Go.SDK/models"
+	"github.com/yazver/Rocket.Chat.Go.SDK/rest"
+)
+
+// Server is the part of rest.Client the sync needs.
+type Server interface {
+	GetSetting(id string) (*models.Setting, error)
+	UpdateSetting(id string, req *models.UpdateSettingRequest) error
+	ListRoles() ([]models.Role, error)
+	CreateRole(req *models.CreateRoleRequest) (*models.Role, error)
+	UpdateRole(req *models.UpdateRoleRequest) (*models.Role, error)
+	ListAllPermissions(updatedSince *time.Time) (*rest.PermissionsListAllResponse, error)
+	UpdatePermissions(req *rest.UpdatePermissionsRequest) (*rest.UpdatePermissionsResponse, error)
+	GetChannelInfo(channel *models.Channel) (*models.Channel, error)
+	CreateChannel(req *models.CreateChannelRequest) (*models.Channel, error)
+	SaveRoomSettings(roomID string, settings map[string]interface{}) error
+}
+
+var _ Server = (*rest.Client)(nil)
+
+// Action tells what a change does.
+type Action string
+
+const (
+	ActionCreate Action = "create"
+	ActionUpdate Action = "update"
+)
+
+// Change is a single step to bring the server to the desired state.
+type Change struct {
+	Kind   string
+	Action Action
+	Name   string
+	// Old and New describe the values for humans, Old is empty for created objects.
+	Old string
+	New string
+
+	apply func(Server) error
+}
+
+func (c Change) String() string {
+	if c.Action == ActionCreate {
+		return fmt.Sprintf("+ %s %s: %s", c.Kind, c.Name, c.New)
+	}
+	return fmt.Sprintf("~ %s %s: %s -> %s", c.Kind, c.Name, c.Old, c.New)
+}
+
+// Plan are the changes needed, in the order they are applied.
+type Plan struct {
+	Changes []Change
+}
+
+// Empty tells whether the server is in the desired state already.
+func (p *Plan) Empty() bool {
+	return len(p.Changes) == 0
+}
+
+// Print writes the plan in a human readable form.
+func (p *Plan) Print(w io.Writer) error {
+	if p.Empty() {
+		_, err := fmt.Fprintln(w, "No changes, the server is up to date.")
+		return err
+	}
+
+	var creates, updates int
+	for _, change := range p.Changes {
+		if change.Action == ActionCreate {
+			creates++
+		} else {
+			updates++
+		}
+		if _, err := fmt.Fprintln(w, change); err != nil {
+			return err
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "\nPlan: %d to create, %d to update.\n", creates, updates)
+	return err
+}
+
+// Apply makes the changes, stopping at the first failing one. As the plan only contains
+// differences, diffing and applying again after a failure continues where it stopped.
+func (p *Plan) Apply(server Server) error {
+	for _, change := range p.Changes {
+		if err := change.apply(server); err != nil {
+			return fmt.Errorf("applying %s %s: %w", change.Kind, change.Name, err)
+		}
+	}
+	return nil
+}
+
+// Diff compares the config with the server and plans the changes. Roles are changed first,
+// as permissions may refer to them.
+func Diff(server Server, config *Config) (*Plan, error) {
+	plan := new(Plan)
+
+	for _, diff := range []func(Server, *Config) ([]Change, error){diffRoles, diffSettings, diffPermissions, diffChannels} {
+		changes, err := diff(server, config)
+		if err != nil {
+			return nil, err
+		}
+		plan.Changes = append(plan.Changes, changes...)
+	}
+	return plan, nil
+}
+
+func diffRoles(server Server, config *Config) ([]Change, error) {
+	if len(config.Roles) == 0 {
+		return nil, nil
+	}
+
+	roles, err := server.ListRoles()
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[string]models.Role, len(roles))
+	for _, role := range roles {
+		existing[role.Name] = role
+	}
+
+	var changes []Change
+	for _, desired := range config.Roles {
+		desired := desired
+		role, ok := existing[desired.Name]
+		switch {
+		case !ok:
+			changes = append(changes, Change{
+				Kind: "role", Action: ActionCreate, Name: desired.Name, New: describeRole(desired),
+				apply: func(server Server) error {
+					_, err := server.CreateRole(&models.CreateRoleRequest{
+						Name:         desired.Name,
+						Scope:        desired.Scope,
+						Description:  desired.Description,
+						Mandatory2FA: desired.Mandatory2FA,
+					})
+					return err
+				},
+			})
+		case role.Description != desired.Description || role.Scope != desired.Scope || role.Mandatory2FA != desired.Mandatory2FA:
+			current := Role{Name: role.Name, Description: role.Description, Scope: role.Scope, Mandatory2FA: role.Mandatory2FA}
+			changes = append(changes, Change{
+				Kind: "role", Action: ActionUpdate, Name: desired.Name, Old: describeRole(current), New: describeRole(desired),
+				apply: func(server Server) error {
+					_, err := server.UpdateRole(&models.UpdateRoleRequest{
+						RoleID:       role.ID,
+						Name:         desired.Name,
+						Scope:        desired.Scope,
+						Description:  desired.Description,
+						Mandatory2FA: desired.Mandatory2FA,
+					})
+					return err
+				},
+			})
+		}
+	}
+	return changes, nil
+}
+
+func describeRole(role Role) string {
+	return fmt.Sprintf("scope=%s description=%q mandatory2fa=%t", role.Scope, role.Description, role.Mandatory2FA)
+}
+
+func diffSettings(server Server, config *Config) ([]Change, error) {
+	var changes []Change
+	for _, id := range sortedKeys(config.Settings) {
+		setting, err := server.GetSetting(id)
+		if err != nil {
+			return nil, err
+		}
+		if setting.Type == models.SettingTypeAsset {
+			return nil, fmt.Errorf("setting %s: assets can't be synced", id)
+		}
+
+		raw, err := json.Marshal(config.Settings[id])
+		if err != nil {
+			return nil, fmt.Errorf("setting %s: %w", id, err)
+		}
+		desired, err := models.DecodeSettingValue(setting.Type, raw)
+		if err != nil {
+			return nil, fmt.Errorf("setting %s: %w", id, err)
+		}
+		if sameSettingValue(setting.Value, desired) {
+			continue
+		}
+
+		id := id
+		changes = append(changes, Change{
+			Kind: "setting", Action: ActionUpdate, Name: id, Old: string(setting.Value.Raw), New: string(raw),
+			apply: func(server Server) error {
+				return server.UpdateSetting(id, &models.UpdateSettingRequest{Value: &desired})
+			},
+		})
+	}
+	return changes, nil
+}
+
+func sameSettingValue(current, desired models.SettingValue) bool {
+	var a, b interface{}
+	if json.Unmarshal(current.Raw, &a) != nil || json.Unmarshal(desired.Raw, &b) != nil {
+		return false
+	}
+	// Numbers decode as float64 on both sides, so 5000 and 5000.0 are the same.
+	return reflect.DeepEqual(a, b)
+}
+
+func diffPermissions(server Server, config *Config) ([]Change, error) {
+	if len(config.Permissions) == 0 {
+		return nil, nil
+	}
+
+	response, err := server.ListAllPermissions(nil)
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[string]models.Permission, len(response.Update))
+	for _, permission := range response.Update {
+		existing[permission.ID] = permission
+	}
+
+	var changes []Change
+	for _, id := range sortedKeys(config.Permissions) {
+		permission, ok := existing[id]
+		if !ok {
+			return nil, fmt.Errorf("permission %s doesn't exist", id)
+		}
+
+		current, desired := sortedCopy(permission.Roles), sortedCopy(config.Permissions[id])
+		if reflect.DeepEqual(current, desired) {
+			continue
+		}
+
+		id := id
+		changes = append(changes, Change{
+			Kind: "permission", Action: ActionUpdate, Name: id,
+			Old: "[" + strings.Join(current, ", ") + "]", New: "[" + strings.Join(desired, ", ") + "]",
+			apply: func(server Server) error {
+				_, err := server.UpdatePermissions(&rest.UpdatePermissionsRequest{
+					Permissions: []models.Permission{{ID: id, Roles: desired}},
+				})
+				return err
+			},
+		})
+	}
+	return changes, nil
+}
+
+func diffChannels(server Server, config *Config) ([]Change, error) {
+	var changes []Change
+	for _, name := range config.DefaultChannels {
+		name := name
+		channel, err := server.GetChannelInfo(&models.Channel{Name: name})
+		switch {
+		case isRoomNotFound(err):
+			changes = append(changes, Change{
+				Kind: "channel", Action: ActionCreate, Name: name, New: "default",
+				apply: func(server Server) error {
+					channel, err := server.CreateChannel(&models.CreateChannelRequest{Name: name, Members: []string{}})
+					if err != nil {
+						return err
+					}
+					return server.SaveRoomSettings(channel.ID, map[string]interface{}{"default": true})
+				},
+			})
+		case err != nil:
+			return nil, err
+		case !channel.Default:
+			roomID := channel.ID
+			changes = append(changes, Change{
+				Kind: "channel", Action: ActionUpdate, Name: name, Old: "not default", New: "default",
+				apply: func(server Server) error {
+					return server.SaveRoomSettings(roomID, map[string]interface{}{"default": true})
+				},
+			})
+		}
+	}
+	return changes, nil
+}
+
+func isRoomNotFound(err error) bool {
+	return err != nil && !errors.Is(err, rest.ErrUnauthorized) && strings.Contains(err.Error(), "error-room-not-found")
+}
+
+func sortedKeys(m interface{}) []string {
+	value := reflect.ValueOf(m)
+	keys := make([]string, 0, value.Len())
+	for _, key := range value.MapKeys() {
+		keys = append(keys, key.String())
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedCopy(values []string) []string {
+	sorted := append([]string{}, values...)
+	sort.Strings(sorted)
+	return sorted
+}
@@ -0,0 +1,190 @@
+package configsync
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yazver/Rocket.Chat.Go.SDK/models"
+	"github.com/yazver/Rocket.Chat.Go.SDK/rest"
+)
+
+type fakeServer struct {
+	settings    map[string]json.RawMessage
+	roles       []models.Role
+	permissions []models.Permission
+	channels    map[string]*models.Channel
+}
+
+func (s *fakeServer) GetSetting(id string) (*models.Setting, error) {
+	raw, ok := s.settings[id]
+	if !ok {
+		return nil, errors.New("setting not found")
+	}
+	value, err := models.DecodeSettingValue("", raw)
+	return &models.Setting{ID: id, Type: value.Type, Value: value}, err
+}
+
+func (s *fakeServer) UpdateSetting(id string, req *models.UpdateSettingRequest) error {
+	raw, err := json.Marshal(req.Value)
+	s.settings[id] = raw
+	return err
+}
+
+func (s *fakeServer) ListRoles() ([]models.Role, error) {
+	return s.roles, nil
+}
+
+func (s *fakeServer) CreateRole(req *models.CreateRoleRequest) (*models.Role, error) {
+	role := models.Role{ID: req.Name, Name: req.Name, Description: req.Description, Scope: req.Scope, Mandatory2FA: req.Mandatory2FA}
+	s.roles = append(s.roles, role)
+	return &role, nil
+}
+
+func (s *fakeServer) UpdateRole(req *models.UpdateRoleRequest) (*models.Role, error) {
+	// The request is sent as JSON, so an omitted description is kept like by the server.
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	var sent struct {
+		Description *string `json:"description"`
+	}
+	if err := json.Unmarshal(data, &sent); err != nil {
+		return nil, err
+	}
+	for i, role := range s.roles {
+		if role.ID == req.RoleID {
+			if sent.Description != nil {
+				role.Description = *sent.Description
+			}
+			s.roles[i] = models.Role{ID: role.ID, Name: req.Name, Description: role.Description, Scope: req.Scope, Mandatory2FA: req.Mandatory2FA}
+			return &s.roles[i], nil
+		}
+	}
+	return nil, errors.New("role not found")
+}
+
+func (s *fakeServer) ListAllPermissions(updatedSince *time.Time) (*rest.PermissionsListAllResponse, error) {
+	return &rest.PermissionsListAllResponse{Update: s.permissions}, nil
+}
+
+func (s *fakeServer) UpdatePermissions(req *rest.UpdatePermissionsRequest) (*rest.UpdatePermissionsResponse, error) {
+	for _, update := range req.Permissions {
+		for i := range s.permissions {
+			if s.permissions[i].ID == update.ID {
+				s.permissions[i].Roles = update.Roles
+			}
+		}
+	}
+	return &rest.UpdatePermissionsResponse{}, nil
+}
+
+func (s *fakeServer) GetChannelInfo(channel *models.Channel) (*models.Channel, error) {
+	if existing, ok := s.channels[channel.Name]; ok {
+		return existing, nil
+	}
+	return nil, errors.New("The required \"roomName\" param provided does not match any channel [error-room-not-found]")
+}
+
+func (s *fakeServer) CreateChannel(req *models.CreateChannelRequest) (*models.Channel, error) {
+	channel := &models.Channel{ID: "id-" + req.Name, Name: req.Name}
+	s.channels[req.Name] = channel
+	return channel, nil
+}
+
+func (s *fakeServer) SaveRoomSettings(roomID string, settings map[string]interface{}) error {
+	for _, channel := range s.channels {
+		if channel.ID == roomID {
+			channel.Default = settings["default"] == true
+		}
+	}
+	return nil
+}
+
+const testConfig = `
+settings:
+  Site_Name: My Chat
+  Message_MaxAllowedSize: 5000
+  Accounts_AllowRegistration: false
+roles:
+  - name: support
+    description: Support team
+permissions:
+  view-statistics: [support, admin]
+defaultChannels:
+  - general
+  - support
+`
+
+func TestSync(t *testing.T) {
+	config, err := Parse([]byte(testConfig), FormatYAML)
+	require.NoError(t, err)
+	assert.Equal(t, models.RoleScopeUsers, config.Roles[0].Scope)
+
+	server := &fakeServer{
+		settings: map[string]json.RawMessage{
+			"Site_Name":                  json.RawMessage(`"Rocket.Chat"`),
+			"Message_MaxAllowedSize":     json.RawMessage(`5000`),
+			"Accounts_AllowRegistration": json.RawMessage(`true`),
+		},
+		roles:       []models.Role{{ID: "admin", Name: "admin", Scope: models.RoleScopeUsers}},
+		permissions: []models.Permission{{ID: "view-statistics", Roles: []string{"admin"}}},
+		channels:    map[string]*models.Channel{"general": {ID: "GENERAL", Name: "general"}},
+	}
+
+	plan, err := Diff(server, config)
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	require.NoError(t, plan.Print(&out))
+	assert.Equal(t, `+ role support: scope=Users description="Support team" mandatory2fa=false
+~ setting Accounts_AllowRegistration: true -> false
+~ setting Site_Name: "Rocket.Chat" -> "My Chat"
+~ permission view-statistics: [admin] -> [admin, support]
+~ channel general: not default -> default
++ channel support: default
+
+Plan: 2 to create, 4 to update.
+`, out.String())
+
+	require.NoError(t, plan.Apply(server))
+	assert.JSONEq(t, `"My Chat"`, string(server.settings["Site_Name"]))
+	assert.True(t, server.channels["support"].Default)
+
+	plan, err = Diff(server, config)
+	require.NoError(t, err)
+	assert.True(t, plan.Empty(), "applying again has to change nothing: %v", plan.Changes)
+}
+
+func TestParse(t *testing.T) {
+	_, err := Parse([]byte(`{"setting": {}}`), FormatJSON)
+	assert.Error(t, err, "unknown fields have to be rejected")
+
+	config, err := Parse([]byte(`{"permissions": {"create-c": ["user"]}}`), FormatJSON)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"user"}, config.Permissions["create-c"])
+}
+
+func TestSync_ClearRoleDescription(t *testing.T) {
+	config, err := Parse([]byte("roles:\n  - name: support\n"), FormatYAML)
+	require.NoError(t, err)
+	server := &fakeServer{roles: []models.Role{{ID: "support", Name: "support", Description: "Support team", Scope: models.RoleScopeUsers}}}
+
+	plan, err := Diff(server, config)
+	require.NoError(t, err)
+	require.Len(t, plan.Changes, 1)
+	assert.Equal(t, ActionUpdate, plan.Changes[0].Action)
+
+	require.NoError(t, plan.Apply(server))
+	assert.Equal(t, "", server.roles[0].Description)
+
+	plan, err = Diff(server, config)
+	require.NoError(t, err)
+	assert.True(t, plan.Empty(), "applying again has to change nothing: %v", plan.Changes)
+}
@@ -0,0 +1,291 @@
+// Package export archives the history of rooms as JSON Lines, CSV or a standalone HTML
+// transcript. The history is loaded with the REST history endpoints, the authors are resolved
+// to their usernames and names, and attachments may be downloaded along. Exports to a file
+// can be continued later with the messages sent since the last exported one.
+//
+//	exporter := export.NewExporter(client, export.FormatCSV)
+//	exporter.AttachmentsDir = "attachments"
+//	count, err := exporter.ExportFile("general.csv", room)
+package export
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yazver/Rocket.Chat.Go.SDK/models"
+	"github.com/yazver/Rocket.Chat.Go.SDK/rest"
+)
+
+// Format is the file format of an export.
+type Format string
+
+const (
+	FormatJSONLines Format = "jsonl"
+	FormatCSV       Format = "csv"
+	FormatHTML      Format = "html"
+)
+
+// DefaultPageSize is the number of messages loaded at once.
+const DefaultPageSize = 100
+
+// Record is an exported message.
+type Record struct {
+	ID        string     `json:"id"`
+	RoomID    string     `json:"roomId"`
+	Timestamp time.Time  `json:"ts"`
+	EditedAt  *time.Time `json:"editedAt,omitempty"`
+	Type      string     `json:"type,omitempty"`
+	UserID    string     `json:"userId"`
+	Username  string     `json:"username"`
+	// Name is the display name of the author.
+	Name        string       `json:"name,omitempty"`
+	Text        string       `json:"text"`
+	Attachments []Attachment `json:"attachments,omitempty"`
+}
+
+// Attachment is an exported attachment, File is the path of the downloaded file.
+type Attachment struct {
+	Title string `json:"title,omitempty"`
+	URL   string `json:"url,omitempty"`
+	File  string `json:"file,omitempty"`
+}
+
+// Exporter exports the history of rooms.
+type Exporter struct {
+	// PageSize is the number of messages loaded at once, DefaultPageSize if not set.
+	PageSize int
+	// AttachmentsDir is the directory the files of attachments are downloaded to, they are
+	// only linked if it's empty.
+	AttachmentsDir string
+
+	client *rest.Client
+	format Format
+	users  map[string]*models.User
+}
+
+// NewExporter creates an exporter writing the format.
+func NewExporter(client *rest.Client, format Format) *Exporter {
+	return &Exporter{client: client, format: format, users: map[string]*models.User{}}
+}
+
+// Export writes the messages of the room sent after since, oldest first. The zero time exports
+// the whole history. It returns the number of messages exported.
+func (e *Exporter) Export(w io.Writer, room *models.Channel, since time.Time) (int, error) {
+	return e.export(w, room, since, true)
+}
+
+// ExportFile exports the room into a file. If the file exists, the messages sent after its
+// last message are appended to it, so an export can be continued, otherwise the file is
+// created with the whole history.
+func (e *Exporter) ExportFile(name string, room *models.Channel) (int, error) {
+	var since time.Time
+	info, err := os.Stat(name)
+	continued := err == nil && info.Size() > 0
+	if continued {
+		if since, err = LastTimestamp(name, e.format); err != nil {
+			return 0, err
+		}
+	}
+
+	file, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return 0, fmt.Errorf("opening export: %w", err)
+	}
+	defer file.Close()
+
+	count, err := e.export(file, room, since, !continued)
+	if err != nil {
+		return count, err
+	}
+	if err := file.Close(); err != nil {
+		return count, fmt.Errorf("writing export: %w", err)
+	}
+	return count, nil
+}
+
+func (e *Exporter) export(w io.Writer, room *models.Channel, since time.Time, header bool) (int, error) {
+	writer, err := newWriter(e.format, w)
+	if err != nil {
+		return 0, err
+	}
+
+	messages, err := e.history(room, since)
+	if err != nil {
+		return 0, err
+	}
+
+	if header {
+		if err := writer.Header(room); err != nil {
+			return 0, fmt.Errorf("writing export: %w", err)
+		}
+	}
+	for i, message := range messages {
+		record, err := e.record(message)
+		if err != nil {
+			return i, err
+		}
+		if err := writer.Write(record); err != nil {
+			return i, fmt.Errorf("writing export: %w", err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return len(messages), fmt.Errorf("writing export: %w", err)
+	}
+	return len(messages), nil
+}
+
+// history loads the messages sent after since page by page, from the newest back, and returns
+// them oldest first.
+func (e *Exporter) history(room *models.Channel, since time.Time) ([]models.Message, error) {
+	pageSize := e.PageSize
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+
+	var messages []models.Message
+	seen := map[string]bool{}
+	var latest time.Time
+	for offset := 0; ; offset += pageSize {
+		params := url.Values{
+			"count":  []string{strconv.Itoa(pageSize)},
+			"offset": []string{strconv.Itoa(offset)},
+		}
+		if !since.IsZero() {
+			params.Set("oldest", since.UTC().Format(time.RFC3339Nano))
+		}
+		if !latest.IsZero() {
+			params.Set("latest", latest.UTC().Format(time.RFC3339Nano))
+		}
+
+		response, err := e.client.RoomHistory(room, params)
+		if err != nil {
+			return nil, fmt.Errorf("loading history of room %s: %w", room.ID, err)
+		}
+
+		page := response.Messages
+		for _, message := range page {
+			if !seen[message.ID] && message.Timestamp != nil {
+				seen[message.ID] = true
+				messages = append(messages, message)
+			}
+		}
+		if len(page) < pageSize {
+			break
+		}
+		// Messages sent during the export would move the pages, so the next ones are limited
+		// to the messages before the newest one of the first page.
+		if latest.IsZero() && page[0].Timestamp != nil {
+			latest = page[0].Timestamp.Add(time.Millisecond)
+		}
+	}
+
+	// The pages are the newest first, messages sent at the same time keep their order.
+	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+		messages[i], messages[j] = messages[j], messages[i]
+	}
+	sort.SliceStable(messages, func(i, j int) bool { return messages[i].Timestamp.Before(*messages[j].Timestamp) })
+	return messages, nil
+}
+
+// record converts a message, resolving its author and downloading its attachments.
+func (e *Exporter) record(message models.Message) (Record, error) {
+	record := Record{
+		ID:        message.ID,
+		RoomID:    message.RoomID,
+		Timestamp: message.Timestamp.UTC(),
+		EditedAt:  message.EditedAt,
+		Type:      message.Type,
+		Text:      message.Msg,
+	}
+	if message.User != nil {
+		author := e.user(message.User)
+		record.UserID, record.Username, record.Name = author.ID, author.UserName, author.Name
+	}
+
+	for i, attachment := range message.Attachments {
+		exported := Attachment{Title: attachment.Title, URL: attachment.TitleLink}
+		if attachment.TitleLinkDownload && attachment.TitleLink != "" && e.AttachmentsDir != "" {
+			file, err := e.download(message, i, attachment)
+			if err != nil {
+				return record, err
+			}
+			exported.File = file
+		}
+		record.Attachments = append(record.Attachments, exported)
+	}
+	return record, nil
+}
+
+// user completes the author of a message, which may lack the username or the name, with the
+// user info. Users which can't be looked up, e.g. deleted ones, are taken as they are.
+func (e *Exporter) user(author *models.User) *models.User {
+	if author.UserName != "" && author.Name != "" {
+		return author
+	}
+	if user, ok := e.users[author.ID]; ok {
+		return user
+	}
+
+	user, err := e.client.GetUserInfo(&models.User{ID: author.ID})
+	if err != nil || user == nil {
+		user = author
+	}
+	e.users[author.ID] = user
+	return user
+}
+
+// download saves the file of an attachment as <message ID>-<index>-<name> and returns its path.
+// Existing files aren't overwritten but taken as the download of an earlier export.
+func (e *Exporter) download(message models.Message, index int, attachment models.Attachment) (string, error) {
+	name := path.Base(attachment.TitleLink)
+	if unescaped, err := url.PathUnescape(name); err == nil {
+		name = unescaped
+	}
+	name = safeFileName(fmt.Sprintf("%s-%d-%s", message.ID, index, strings.TrimLeft(filepath.Base(name), ".")))
+
+	if err := os.MkdirAll(e.AttachmentsDir, 0o755); err != nil {
+		return "", fmt.Errorf("creating attachments directory: %w", err)
+	}
+	target := filepath.Join(e.AttachmentsDir, name)
+	if rel, err := filepath.Rel(e.AttachmentsDir, target); err != nil || rel != name {
+		return "", fmt.Errorf("saving attachment: %q is outside of the attachments directory", name)
+	}
+	file, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if errors.Is(err, os.ErrExist) {
+		// Exports of overlapping periods download the same attachments, failed downloads
+		// are removed.
+		return target, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("saving attachment: %w", err)
+	}
+	defer file.Close()
+
+	if err := e.client.DownloadFile(attachment.TitleLink, file); err != nil {
+		os.Remove(target)
+		return "", fmt.Errorf("downloading attachment of message %s: %w", message.ID, err)
+	}
+	if err := file.Close(); err != nil {
+		return "", fmt.Errorf("saving attachment: %w", err)
+	}
+	return target, nil
+}
+
+// safeFileName replaces the path separators and the parent directory references of a name,
+// which come from the server, so it's a single file name.
+func safeFileName(name string) string {
+	name = strings.NewReplacer("/", "_", "\\", "_", "\x00", "_", "..", "_").Replace(name)
+	if name == "" || name == "." {
+		return "_"
+	}
+	return name
+}
@@ -0,0 +1,180 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yazver/Rocket.Chat.Go.SDK/fakeserver"
+	"github.com/yazver/Rocket.Chat.Go.SDK/models"
+	"github.com/yazver/Rocket.Chat.Go.SDK/rest"
+)
+
+type testServer struct {
+	*fakeserver.Server
+	now    time.Time
+	client *rest.Client
+	room   *models.Channel
+}
+
+// newTestServer starts a server with the room news, every two messages are sent at the same time.
+func newTestServer(t *testing.T) *testServer {
+	s := &testServer{Server: fakeserver.New(), now: time.Date(2021, 5, 1, 12, 0, 0, 0, time.UTC)}
+	t.Cleanup(s.Close)
+	s.Now = func() time.Time { return s.now }
+	s.AddUser("bot", "pass", "bot")
+	s.AddUser("alice", "pass")
+	s.room = s.AddRoom("news", models.RoomTypeChannel, "bot", "alice")
+
+	s.client = rest.NewClient(s.URL(), false)
+	_, err := s.client.Login(&models.UserCredentials{Username: "bot", Password: "pass"})
+	require.NoError(t, err)
+	return s
+}
+
+func (s *testServer) send(username string, texts ...string) {
+	for i, text := range texts {
+		if i%2 == 0 {
+			s.now = s.now.Add(time.Minute)
+		}
+		s.AddMessage(s.room.ID, username, text)
+	}
+}
+
+func readRecords(t *testing.T, data []byte) []Record {
+	var records []Record
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		var record Record
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestExporter_Export(t *testing.T) {
+	s := newTestServer(t)
+	s.send("alice", "one", "two", "three", "four", "five")
+	s.now = s.now.Add(time.Minute)
+	_, err := s.client.UploadFile(s.room.ID, "report.txt", strings.NewReader("content"), "the report")
+	require.NoError(t, err)
+
+	exporter := NewExporter(s.client, FormatJSONLines)
+	exporter.PageSize = 2
+	exporter.AttachmentsDir = filepath.Join(t.TempDir(), "attachments")
+	var buffer bytes.Buffer
+	count, err := exporter.Export(&buffer, s.room, time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, 6, count)
+
+	records := readRecords(t, buffer.Bytes())
+	require.Len(t, records, 6)
+	var texts []string
+	for _, record := range records {
+		texts = append(texts, record.Text)
+	}
+	assert.Equal(t, []string{"one", "two", "three", "four", "five", "the report"}, texts)
+	assert.Equal(t, "alice", records[0].Username)
+	assert.Equal(t, s.now, records[5].Timestamp)
+
+	report := records[5]
+	assert.Equal(t, "bot", report.Username)
+	require.Len(t, report.Attachments, 1)
+	assert.Equal(t, "report.txt", report.Attachments[0].Title)
+	assert.Equal(t, filepath.Join(exporter.AttachmentsDir, report.ID+"-0-report.txt"), report.Attachments[0].File)
+	content, err := os.ReadFile(report.Attachments[0].File)
+	require.NoError(t, err)
+	assert.Equal(t, "content", string(content))
+
+	buffer.Reset()
+	count, err = exporter.Export(&buffer, s.room, records[3].Timestamp)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count, "only messages after since")
+}
+
+func TestExporter_ExportFile(t *testing.T) {
+	for _, format := range []Format{FormatJSONLines, FormatCSV, FormatHTML} {
+		t.Run(string(format), func(t *testing.T) {
+			s := newTestServer(t)
+			s.send("alice", "one", "two <b>", "three")
+
+			name := filepath.Join(t.TempDir(), "news."+string(format))
+			exporter := NewExporter(s.client, format)
+			count, err := exporter.ExportFile(name, s.room)
+			require.NoError(t, err)
+			assert.Equal(t, 3, count)
+
+			last, err := LastTimestamp(name, format)
+			require.NoError(t, err)
+			assert.Equal(t, s.now, last)
+
+			count, err = exporter.ExportFile(name, s.room)
+			require.NoError(t, err)
+			assert.Equal(t, 0, count, "nothing new")
+
+			s.send("bot", "four")
+			count, err = exporter.ExportFile(name, s.room)
+			require.NoError(t, err)
+			assert.Equal(t, 1, count)
+
+			last, err = LastTimestamp(name, format)
+			require.NoError(t, err)
+			assert.Equal(t, s.now, last)
+
+			data, err := os.ReadFile(name)
+			require.NoError(t, err)
+			switch format {
+			case FormatJSONLines:
+				assert.Len(t, readRecords(t, data), 4)
+			case FormatCSV:
+				rows, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+				require.NoError(t, err)
+				require.Len(t, rows, 5)
+				assert.Equal(t, csvColumns, rows[0])
+				assert.Equal(t, []string{"alice", "two <b>"}, []string{rows[2][3], rows[2][5]})
+				assert.Equal(t, "four", rows[4][5])
+			case FormatHTML:
+				html := string(data)
+				assert.Equal(t, 1, strings.Count(html, "<h1>news</h1>"), "the header is written once")
+				assert.Equal(t, 4, strings.Count(html, `class="message"`))
+				assert.Contains(t, html, "two &lt;b&gt;")
+			}
+		})
+	}
+}
+
+func TestLastTimestamp(t *testing.T) {
+	dir := t.TempDir()
+	_, err := LastTimestamp(filepath.Join(dir, "missing.jsonl"), FormatJSONLines)
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+
+	empty := filepath.Join(dir, "empty.csv")
+	require.NoError(t, os.WriteFile(empty, []byte(strings.Join(csvColumns, ",")+"\n"), 0o644))
+	last, err := LastTimestamp(empty, FormatCSV)
+	require.NoError(t, err)
+	assert.True(t, last.IsZero())
+}
+
+func TestSafeFileName(t *testing.T) {
+	for name, expected := range map[string]string{
+		"msg1-0-report.txt":        "msg1-0-report.txt",
+		"../../etc-0-passwd":       "____etc-0-passwd",
+		`..\..\msg-0-report.txt`:   "____msg-0-report.txt",
+		"msg/../../x-0-report.txt": "msg_____x-0-report.txt",
+		"..":                       "_",
+		"":                         "_",
+	} {
+		assert.Equal(t, expected, safeFileName(name), name)
+		assert.Equal(t, safeFileName(name), filepath.Base(safeFileName(name)), name)
+	}
+}
@@ -0,0 +1,206 @@
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/yazver/Rocket.Chat.Go.SDK/models"
+)
+
+// writer writes records in a format. The header is only written to new files.
+type writer interface {
+	Header(room *models.Channel) error
+	Write(record Record) error
+	Flush() error
+}
+
+func newWriter(format Format, w io.Writer) (writer, error) {
+	switch format {
+	case FormatJSONLines:
+		return jsonLinesWriter{json.NewEncoder(w)}, nil
+	case FormatCSV:
+		return csvWriter{csv.NewWriter(w)}, nil
+	case FormatHTML:
+		return htmlWriter{w}, nil
+	}
+	return nil, fmt.Errorf("unknown export format %q", format)
+}
+
+type jsonLinesWriter struct {
+	encoder *json.Encoder
+}
+
+func (w jsonLinesWriter) Header(*models.Channel) error {
+	return nil
+}
+
+func (w jsonLinesWriter) Write(record Record) error {
+	return w.encoder.Encode(record)
+}
+
+func (w jsonLinesWriter) Flush() error {
+	return nil
+}
+
+// csvColumns are the columns of CSV exports, the time is the second one.
+var csvColumns = []string{"id", "ts", "user_id", "username", "name", "text", "edited_at", "type", "attachments"}
+
+type csvWriter struct {
+	writer *csv.Writer
+}
+
+func (w csvWriter) Header(*models.Channel) error {
+	return w.writer.Write(csvColumns)
+}
+
+// Write writes a row, the attachments are the downloaded files or the links separated by spaces.
+func (w csvWriter) Write(record Record) error {
+	var editedAt string
+	if record.EditedAt != nil {
+		editedAt = record.EditedAt.UTC().Format(time.RFC3339Nano)
+	}
+	attachments := make([]string, 0, len(record.Attachments))
+	for _, attachment := range record.Attachments {
+		if attachment.File != "" {
+			attachments = append(attachments, attachment.File)
+		} else if attachment.URL != "" {
+			attachments = append(attachments, attachment.URL)
+		}
+	}
+
+	return w.writer.Write([]string{
+		record.ID,
+		record.Timestamp.Format(time.RFC3339Nano),
+		record.UserID,
+		record.Username,
+		record.Name,
+		record.Text,
+		editedAt,
+		record.Type,
+		strings.Join(attachments, " "),
+	})
+}
+
+func (w csvWriter) Flush() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+// The HTML transcript leaves the body and html elements open, which HTML allows, so messages
+// can be appended to it later.
+var (
+	htmlHeader = template.Must(template.New("header").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Name}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+.message { margin: 0.5em 0; }
+.meta { color: #666; font-size: 0.85em; }
+.author { font-weight: bold; }
+.text { white-space: pre-wrap; }
+</style>
+</head>
+<body>
+<h1>{{.Name}}</h1>
+`))
+
+	htmlMessage = template.Must(template.New("message").Parse(`<div class="message" id="{{.ID}}" data-ts="{{.Timestamp.Format "2006-01-02T15:04:05.999999999Z07:00"}}">
+<div class="meta"><span class="author">{{if .Name}}{{.Name}} {{end}}@{{.Username}}</span> <time datetime="{{.Timestamp.Format "2006-01-02T15:04:05.999999999Z07:00"}}">{{.Timestamp.Format "2006-01-02 15:04:05"}}</time>{{if .EditedAt}} (edited){{end}}</div>
+<div class="text">{{.Text}}</div>
+{{range .Attachments}}<div class="attachment">{{if .File}}<a href="{{.File}}">{{.Title}}</a>{{else}}{{.Title}}{{end}}</div>
+{{end}}</div>
+`))
+)
+
+type htmlWriter struct {
+	writer io.Writer
+}
+
+func (w htmlWriter) Header(room *models.Channel) error {
+	return htmlHeader.Execute(w.writer, room)
+}
+
+func (w htmlWriter) Write(record Record) error {
+	return htmlMessage.Execute(w.writer, record)
+}
+
+func (w htmlWriter) Flush() error {
+	return nil
+}
+
+var htmlTimestamp = regexp.MustCompile(`data-ts="([^"]+)"`)
+
+// LastTimestamp reads the time of the last message of an export file, the zero time if it has
+// no messages.
+func LastTimestamp(name string, format Format) (time.Time, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("reading export: %w", err)
+	}
+	defer file.Close()
+
+	var last string
+	switch format {
+	case FormatJSONLines:
+		scanner := bufio.NewScanner(file)
+		scanner.Buffer(nil, 16*1024*1024)
+		for scanner.Scan() {
+			if line := strings.TrimSpace(scanner.Text()); line != "" {
+				var record Record
+				if err := json.Unmarshal([]byte(line), &record); err != nil {
+					return time.Time{}, fmt.Errorf("reading export %s: %w", name, err)
+				}
+				last = record.Timestamp.Format(time.RFC3339Nano)
+			}
+		}
+		err = scanner.Err()
+	case FormatCSV:
+		reader := csv.NewReader(file)
+		for {
+			row, readErr := reader.Read()
+			if readErr == io.EOF {
+				break
+			}
+			if readErr != nil {
+				err = readErr
+				break
+			}
+			if len(row) > 1 && row[1] != csvColumns[1] {
+				last = row[1]
+			}
+		}
+	case FormatHTML:
+		scanner := bufio.NewScanner(file)
+		scanner.Buffer(nil, 16*1024*1024)
+		for scanner.Scan() {
+			if match := htmlTimestamp.FindStringSubmatch(scanner.Text()); match != nil {
+				last = match[1]
+			}
+		}
+		err = scanner.Err()
+	default:
+		return time.Time{}, fmt.Errorf("unknown export format %q", format)
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("reading export %s: %w", name, err)
+	}
+
+	if last == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, last)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("reading export %s: %w", name, err)
+	}
+	return t, nil
+}
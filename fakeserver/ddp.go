@@ -0,0 +1,693 @@
+package fakeserver
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/net/websocket"
+
+	"github.com/yazver/Rocket.Chat.Go.SDK/models"
+)
+
+// ddpConn is a websocket connection of a DDP client.
+type ddpConn struct {
+	ws *websocket.Conn
+
+	writeMu sync.Mutex
+
+	// The fields below are guarded by the lock of the server.
+	userID  string
+	streams map[string]map[string]bool
+}
+
+// MeteorError is sent as error of a method call. A MethodFunc may return it to choose the error code.
+type MeteorError struct {
+	Code    interface{}            `json:"error"`
+	Reason  string                 `json:"reason"`
+	Message string                 `json:"message"`
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+func meteorError(code interface{}, reason string) *MeteorError {
+	return &MeteorError{Code: code, Reason: reason, Message: fmt.Sprintf("%s [%v]", reason, code)}
+}
+
+func (e *MeteorError) Error() string {
+	return e.Message
+}
+
+func (c *ddpConn) send(msg interface{}) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	_ = websocket.JSON.Send(c.ws, msg)
+}
+
+func (s *Server) serveDDP(ws *websocket.Conn) {
+	conn := &ddpConn{ws: ws, streams: map[string]map[string]bool{}}
+
+	s.mu.Lock()
+	s.conns[conn] = true
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		ws.Close()
+	}()
+
+	for {
+		var msg map[string]interface{}
+		if err := websocket.JSON.Receive(ws, &msg); err != nil {
+			return
+		}
+
+		switch msg["msg"] {
+		case "connect":
+			s.mu.Lock()
+			session := s.newID()
+			s.mu.Unlock()
+			conn.send(map[string]interface{}{"msg": "connected", "session": session})
+		case "ping":
+			pong := map[string]interface{}{"msg": "pong"}
+			if id, ok := msg["id"]; ok {
+				pong["id"] = id
+			}
+			conn.send(pong)
+		case "method":
+			s.serveMethod(conn, msg)
+		case "sub":
+			s.serveSub(conn, msg)
+		case "unsub":
+			conn.send(map[string]interface{}{"msg": "nosub", "id": msg["id"]})
+		}
+	}
+}
+
+func (s *Server) serveMethod(conn *ddpConn, msg map[string]interface{}) {
+	id, _ := msg["id"].(string)
+	name, _ := msg["method"].(string)
+	params, _ := msg["params"].([]interface{})
+
+	result, err := s.callMethod(conn, name, params)
+	reply := map[string]interface{}{"msg": "result", "id": id}
+	if err != nil {
+		reply["error"] = err
+	} else {
+		reply["result"] = toDDP(result)
+	}
+
+	conn.send(reply)
+	conn.send(map[string]interface{}{"msg": "updated", "methods": []string{id}})
+	s.notifyChanges()
+}
+
+func (s *Server) callMethod(conn *ddpConn, name string, params []interface{}) (interface{}, *MeteorError) {
+	s.mu.Lock()
+	custom, ok := s.methods[name]
+	userID := conn.userID
+	s.mu.Unlock()
+
+	if ok {
+		result, err := custom(userID, params)
+		if err != nil {
+			if meteorErr, ok := err.(*MeteorError); ok {
+				return nil, meteorErr
+			}
+			return nil, meteorError(500, err.Error())
+		}
+		return result, nil
+	}
+
+	switch name {
+	case "login":
+		return s.ddpLogin(conn, params)
+	case "registerUser":
+		return s.ddpRegisterUser(params)
+	}
+
+	s.mu.Lock()
+	u, loggedIn := s.users[userID]
+	s.mu.Unlock()
+	if !loggedIn {
+		return nil, meteorError("error-invalid-user", "Invalid user")
+	}
+
+	switch name {
+	case "setUsername":
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		username := stringAt(params, 0)
+		if other := s.userByName(username); other != nil && other != u {
+			return nil, meteorError("error-field-unavailable", username+" is already in use :(")
+		}
+		s.renameMember(u.UserName, username)
+		u.UserName = username
+		return username, nil
+	case "logout":
+		s.mu.Lock()
+		conn.userID = ""
+		s.mu.Unlock()
+		return nil, nil
+	case "sendMessage":
+		return s.ddpSendMessage(u, params)
+	case "updateMessage":
+		return s.ddpUpdateMessage(u, params)
+	case "deleteMessage":
+		return s.ddpDeleteMessage(u, params)
+	case "loadHistory":
+		return s.ddpLoadHistory(u, params)
+	case "loadMissedMessages":
+		return s.ddpLoadMissedMessages(u, params)
+	case "messages/get":
+		options, _ := paramAt(params, 1).(map[string]interface{})
+		return s.ddpMessageChanges(stringAt(params, 0), dateAt([]interface{}{options["lastUpdate"]}, 0))
+	case "rooms/get":
+		return s.ddpRooms(u, dateAt(params, 0))
+	case "subscriptions/get":
+		return s.ddpSubscriptions(u, dateAt(params, 0))
+	case "getRoomIdByNameOrId":
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if room := s.room(stringAt(params, 0)); room != nil {
+			return room.ID, nil
+		}
+		return nil, meteorError("error-not-allowed", "Not allowed")
+	case "getRoomRoles":
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		room, ok := s.rooms[stringAt(params, 0)]
+		if !ok {
+			return nil, meteorError("error-invalid-room", "Invalid room")
+		}
+		return s.roomRoles(room), nil
+	case "createChannel", "createPrivateGroup":
+		return s.ddpCreateRoom(u, name == "createPrivateGroup", params)
+	case "joinRoom", "leaveRoom":
+		return s.ddpJoinRoom(u, name == "joinRoom", stringAt(params, 0))
+	case "permissions/get", "public-settings/get", "getUserRoles":
+		return []interface{}{}, nil
+	case "UserPresence:away", "UserPresence:online", "UserPresence:setDefaultStatus", "stream-notify-room":
+		return nil, nil
+	}
+
+	return nil, meteorError(404, fmt.Sprintf("Method '%s' not found", name))
+}
+
+func (s *Server) ddpLogin(conn *ddpConn, params []interface{}) (interface{}, *MeteorError) {
+	request, _ := paramAt(params, 0).(map[string]interface{})
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var u *user
+	token, _ := request["resume"].(string)
+	if token != "" {
+		u = s.userByToken(token)
+	} else {
+		login, _ := request["user"].(map[string]interface{})
+		password, _ := request["password"].(map[string]interface{})
+		if username, ok := login["username"].(string); ok {
+			u = s.userByName(username)
+		} else if email, ok := login["email"].(string); ok {
+			u = s.userByEmail(email)
+		}
+
+		if u != nil {
+			digest := sha256.Sum256([]byte(u.password))
+			if password["digest"] != hex.EncodeToString(digest[:]) {
+				u = nil
+			}
+		}
+	}
+
+	if u == nil {
+		return nil, meteorError(403, "User not found")
+	}
+	if token == "" {
+		token = s.newToken(u)
+	}
+	conn.userID = u.ID
+
+	return map[string]interface{}{
+		"id":           u.ID,
+		"token":        token,
+		"tokenExpires": map[string]interface{}{"$date": s.now().Add(90*24*time.Hour).UnixNano() / 1e6},
+		"type":         "password",
+	}, nil
+}
+
+// ddpRegisterUser creates a user without username, it's set by setUsername after the login.
+func (s *Server) ddpRegisterUser(params []interface{}) (interface{}, *MeteorError) {
+	request, _ := paramAt(params, 0).(map[string]interface{})
+	email, _ := request["email"].(string)
+	password, _ := request["pass"].(string)
+	name, _ := request["name"].(string)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if email == "" || s.userByEmail(email) != nil {
+		return nil, meteorError(403, "Email already exists.")
+	}
+
+	created := s.addUser("", password, nil)
+	u := s.users[created.ID]
+	u.Name = name
+	u.Emails = []models.UserEmail{{Address: email}}
+	return map[string]interface{}{"_id": u.ID}, nil
+}
+
+// renameMember changes a username in the member lists, it has to be called with the lock held.
+func (s *Server) renameMember(from, to string) {
+	for _, r := range s.rooms {
+		for i, member := range r.members {
+			if member == from {
+				r.members[i] = to
+			}
+		}
+	}
+}
+
+func (s *Server) ddpSendMessage(u *user, params []interface{}) (interface{}, *MeteorError) {
+	var message models.Message
+	if err := remarshal(paramAt(params, 0), &message); err != nil {
+		return nil, meteorError(400, "Match failed")
+	}
+
+	s.mu.Lock()
+	room, ok := s.rooms[message.RoomID]
+	if !ok || (room.Type != "c" && !containsString(room.members, u.UserName)) {
+		s.mu.Unlock()
+		return nil, meteorError("error-not-allowed", "Not allowed")
+	}
+	stored := s.addMessage(&message, u)
+	s.mu.Unlock()
+
+	s.notifyMessage(stored)
+	return stored, nil
+}
+
+// ddpUpdateMessage changes the text of a message, subscribers of the room get the edited message.
+func (s *Server) ddpUpdateMessage(u *user, params []interface{}) (interface{}, *MeteorError) {
+	var message models.Message
+	if err := remarshal(paramAt(params, 0), &message); err != nil {
+		return nil, meteorError(400, "Match failed")
+	}
+
+	s.mu.Lock()
+	stored := s.message(message.RoomID, message.ID)
+	if stored == nil {
+		s.mu.Unlock()
+		return nil, meteorError("error-action-not-allowed", "Not allowed")
+	}
+	now := s.now()
+	stored.Msg = message.Msg
+	stored.EditedAt = &now
+	stored.UpdatedAt = &now
+	stored.EditedBy = u.UserName
+	edited := *stored
+	s.mu.Unlock()
+
+	s.notifyMessage(&edited)
+	return nil, nil
+}
+
+// ddpDeleteMessage deletes a message, subscribers of the deleteMessage event of the room are
+// told its ID.
+func (s *Server) ddpDeleteMessage(u *user, params []interface{}) (interface{}, *MeteorError) {
+	id := ""
+	if request, ok := paramAt(params, 0).(map[string]interface{}); ok {
+		id, _ = request["_id"].(string)
+	}
+
+	s.mu.Lock()
+	var roomID string
+	for rid, messages := range s.messages {
+		for i, message := range messages {
+			if message.ID == id {
+				roomID = rid
+				s.messages[rid] = append(messages[:i:i], messages[i+1:]...)
+				now := s.now()
+				s.deleted[rid] = append(s.deleted[rid], models.DeletedMessage{ID: id, DeletedAt: &now})
+				break
+			}
+		}
+	}
+	if room, ok := s.rooms[roomID]; ok {
+		room.Msgs--
+		s.roomChanged(room)
+	}
+	s.mu.Unlock()
+
+	if roomID == "" {
+		return nil, meteorError("error-action-not-allowed", "Not allowed")
+	}
+	s.Emit("stream-notify-room", roomID+"/deleteMessage", map[string]interface{}{"_id": id})
+	return nil, nil
+}
+
+// message finds a message of a room, it has to be called with the lock held.
+func (s *Server) message(roomID, id string) *models.Message {
+	for _, message := range s.messages[roomID] {
+		if message.ID == id {
+			return message
+		}
+	}
+	return nil
+}
+
+// ddpLoadHistory supports the parameters roomID, end, limit and the last seen date.
+func (s *Server) ddpLoadHistory(u *user, params []interface{}) (interface{}, *MeteorError) {
+	roomID := stringAt(params, 0)
+	end := dateAt(params, 1)
+	limit := 20
+	if l, ok := paramAt(params, 2).(float64); ok {
+		limit = int(l)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.rooms[roomID]; !ok {
+		return nil, meteorError("error-invalid-room", "Invalid room")
+	}
+
+	messages := []models.Message{}
+	stored := s.messages[roomID]
+	for i := len(stored) - 1; i >= 0 && len(messages) < limit; i-- {
+		if !end.IsZero() && !stored[i].Timestamp.Before(end) {
+			continue
+		}
+		messages = append(messages, *stored[i])
+	}
+	return map[string]interface{}{"messages": messages}, nil
+}
+
+// ddpLoadMissedMessages returns the messages of the room sent after the time, oldest first.
+func (s *Server) ddpLoadMissedMessages(u *user, params []interface{}) (interface{}, *MeteorError) {
+	roomID := stringAt(params, 0)
+	start := dateAt(params, 1)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.rooms[roomID]; !ok {
+		return nil, meteorError("error-invalid-room", "Invalid room")
+	}
+
+	messages := []models.Message{}
+	for _, message := range s.messages[roomID] {
+		if message.Timestamp.After(start) {
+			messages = append(messages, *message)
+		}
+	}
+	return messages, nil
+}
+
+// ddpMessageChanges returns the messages of the room updated and deleted after the time.
+func (s *Server) ddpMessageChanges(roomID string, since time.Time) (interface{}, *MeteorError) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.rooms[roomID]; !ok {
+		return nil, meteorError("error-invalid-room", "Invalid room")
+	}
+	return s.messageChanges(roomID, since), nil
+}
+
+// messageChanges returns the messages of the room updated and deleted after the time, it has
+// to be called with the lock held.
+func (s *Server) messageChanges(roomID string, since time.Time) models.MessageChanges {
+	changes := models.MessageChanges{Updated: []models.Message{}, Deleted: []models.DeletedMessage{}}
+	for _, message := range s.messages[roomID] {
+		if message.UpdatedAt.After(since) {
+			changes.Updated = append(changes.Updated, *message)
+		}
+	}
+	for _, deleted := range s.deleted[roomID] {
+		if deleted.DeletedAt.After(since) {
+			changes.Deleted = append(changes.Deleted, deleted)
+		}
+	}
+	return changes
+}
+
+// ddpRooms returns the rooms of the user updated after since.
+func (s *Server) ddpRooms(u *user, since time.Time) (interface{}, *MeteorError) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	update, remove := s.roomsUpdatedSince(u, since)
+	return map[string]interface{}{"update": update, "remove": remove}, nil
+}
+
+// ddpSubscriptions returns the subscriptions of the user updated or removed after since.
+func (s *Server) ddpSubscriptions(u *user, since time.Time) (interface{}, *MeteorError) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	update, remove := s.subscriptionsUpdatedSince(u, since)
+	return map[string]interface{}{"update": update, "remove": remove}, nil
+}
+
+// roomsUpdatedSince returns the rooms of the user updated after since, removed rooms aren't
+// tracked. It has to be called with the lock held.
+func (s *Server) roomsUpdatedSince(u *user, since time.Time) ([]models.Channel, []models.Channel) {
+	rooms := s.sortedRooms(func(r *room) bool {
+		return containsString(r.members, u.UserName) && r.UpdatedAt.After(since)
+	})
+	update := make([]models.Channel, 0, len(rooms))
+	for _, r := range rooms {
+		update = append(update, r.Channel)
+	}
+	return update, []models.Channel{}
+}
+
+// subscriptionsUpdatedSince returns the subscriptions of the user updated after since and the
+// ones of the rooms the user left since then. It has to be called with the lock held.
+func (s *Server) subscriptionsUpdatedSince(u *user, since time.Time) ([]map[string]interface{}, []map[string]interface{}) {
+	update := []map[string]interface{}{}
+	remove := []map[string]interface{}{}
+	for _, r := range s.sortedRooms(func(*room) bool { return true }) {
+		if containsString(r.members, u.UserName) && r.UpdatedAt.After(since) {
+			update = append(update, s.subscription(r, u))
+		}
+		if left, ok := r.left[u.ID]; ok && left.After(since) {
+			remove = append(remove, map[string]interface{}{"_id": r.ID + u.ID, "_deletedAt": left})
+		}
+	}
+	return update, remove
+}
+
+// subscription builds the subscription document of a member, it has to be called with the lock held.
+func (s *Server) subscription(r *room, u *user) map[string]interface{} {
+	return map[string]interface{}{
+		"_id":           r.ID + u.ID,
+		"rid":           r.ID,
+		"name":          r.Name,
+		"fname":         r.Fname,
+		"t":             r.Type,
+		"u":             map[string]interface{}{"_id": u.ID, "username": u.UserName},
+		"roles":         r.roles[u.ID],
+		"open":          true,
+		"alert":         false,
+		"f":             r.favorites[u.ID],
+		"unread":        0,
+		"userMentions":  0,
+		"groupMentions": 0,
+		"archived":      r.Archived,
+		"ts":            r.Timestamp,
+		"ls":            r.Timestamp,
+		"_updatedAt":    r.UpdatedAt,
+	}
+}
+
+func (s *Server) ddpCreateRoom(u *user, private bool, params []interface{}) (interface{}, *MeteorError) {
+	name := stringAt(params, 0)
+	var members []string
+	_ = remarshal(paramAt(params, 1), &members)
+
+	roomType := "c"
+	if private {
+		roomType = "p"
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if name == "" || s.room(name) != nil {
+		return nil, meteorError("error-duplicate-channel-name", "A channel with name '"+name+"' exists")
+	}
+	r := s.addRoom(name, roomType, u.UserName, members)
+	return map[string]interface{}{"rid": r.ID, "name": r.Name}, nil
+}
+
+func (s *Server) ddpJoinRoom(u *user, join bool, roomID string) (interface{}, *MeteorError) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.rooms[roomID]
+	if !ok || (join && r.Type != "c") {
+		return nil, meteorError("error-not-allowed", "Not allowed")
+	}
+
+	members := []string{}
+	for _, member := range r.members {
+		if member != u.UserName {
+			members = append(members, member)
+		}
+	}
+	if join {
+		members = append(members, u.UserName)
+	}
+	s.setMembers(r, members)
+	return true, nil
+}
+
+func (s *Server) serveSub(conn *ddpConn, msg map[string]interface{}) {
+	id, _ := msg["id"].(string)
+	name, _ := msg["name"].(string)
+	params, _ := msg["params"].([]interface{})
+
+	event := stringAt(params, 0)
+	useCollection, _ := paramAt(params, 1).(bool)
+	if options, ok := paramAt(params, 1).(map[string]interface{}); ok {
+		useCollection, _ = options["useCollection"].(bool)
+	}
+
+	s.mu.Lock()
+	// Like the server, the messages of rooms which don't exist can't be subscribed to.
+	if name == "stream-room-messages" && event != "__my_messages__" && s.room(event) == nil {
+		s.mu.Unlock()
+		conn.send(map[string]interface{}{"msg": "nosub", "id": id, "error": meteorError("error-not-allowed", "Not allowed")})
+		return
+	}
+	if conn.streams[name] == nil {
+		conn.streams[name] = map[string]bool{}
+	}
+	conn.streams[name][event] = true
+	s.mu.Unlock()
+
+	// Streams with useCollection announce a document which later events change.
+	if useCollection {
+		conn.send(map[string]interface{}{
+			"msg": "added", "collection": name, "id": "id",
+			"fields": map[string]interface{}{"eventName": event},
+		})
+	}
+	conn.send(map[string]interface{}{"msg": "ready", "subs": []string{id}})
+}
+
+// Emit sends an event to the clients subscribed to the event of the stream, e.g.
+// Emit("stream-notify-logged", "permissions-changed", "updated", permission).
+func (s *Server) Emit(stream, event string, args ...interface{}) {
+	s.mu.Lock()
+	var targets []*ddpConn
+	for conn := range s.conns {
+		if conn.streams[stream][event] {
+			targets = append(targets, conn)
+		}
+	}
+	s.mu.Unlock()
+
+	if args == nil {
+		args = []interface{}{}
+	}
+	msg := map[string]interface{}{
+		"msg": "changed", "collection": stream, "id": "id",
+		"fields": map[string]interface{}{"eventName": event, "args": toDDP(args)},
+	}
+	for _, conn := range targets {
+		conn.send(msg)
+	}
+}
+
+func (s *Server) notifyMessage(message *models.Message) {
+	s.Emit("stream-room-messages", message.RoomID, message)
+	s.Emit("stream-room-messages", "__my_messages__", message)
+	s.notifyChanges()
+}
+
+// dateKeys are the fields DDP sends as {"$date": milliseconds} instead of a string.
+var dateKeys = map[string]bool{
+	"ts": true, "_updatedAt": true, "editedAt": true, "createdAt": true,
+	"lastLogin": true, "lm": true, "ls": true, "tokenExpires": true, "_deletedAt": true,
+}
+
+// toDDP converts a value to its JSON form with the dates of DDP, i.e. EJSON.
+func toDDP(value interface{}) interface{} {
+	var decoded interface{}
+	if remarshal(value, &decoded) != nil {
+		return value
+	}
+	return convertDates("", decoded)
+}
+
+func convertDates(key string, value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			v[k] = convertDates(k, child)
+		}
+		return v
+	case []interface{}:
+		for i, child := range v {
+			v[i] = convertDates("", child)
+		}
+		return v
+	case string:
+		if dateKeys[key] {
+			if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+				return map[string]interface{}{"$date": t.UnixNano() / 1e6}
+			}
+		}
+	}
+	return value
+}
+
+func remarshal(from, to interface{}) error {
+	data, err := json.Marshal(from)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, to)
+}
+
+func paramAt(params []interface{}, i int) interface{} {
+	if i < len(params) {
+		return params[i]
+	}
+	return nil
+}
+
+func stringAt(params []interface{}, i int) string {
+	value, _ := paramAt(params, i).(string)
+	return value
+}
+
+// dateAt reads an EJSON date parameter.
+func dateAt(params []interface{}, i int) time.Time {
+	date, ok := paramAt(params, i).(map[string]interface{})
+	if !ok {
+		return time.Time{}
+	}
+	ms, _ := date["$date"].(float64)
+	return time.Unix(0, int64(ms)*int64(time.Millisecond)).UTC()
+}
+
+func parseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339Nano, value)
+}
+
+func sortUsers(users []models.User) {
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+}
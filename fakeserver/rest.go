@@ -0,0 +1,1222 @@
+package fakeserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/yazver/Rocket.Chat.Go.SDK/models"
+)
+
+// restHandler handles an endpoint for the user logged in, which is nil for public endpoints.
+type restHandler func(r *http.Request, u *user) (int, interface{})
+
+type restEndpoint struct {
+	handler restHandler
+	public  bool
+}
+
+func (s *Server) endpoints() map[string]restEndpoint {
+	return map[string]restEndpoint{
+		"GET info":                    {s.info, true},
+		"POST login":                  {s.login, true},
+		"POST method.call":            {s.methodCall, false},
+		"POST method.callAnon":        {s.methodCall, true},
+		"GET logout":                  {s.logout, false},
+		"POST logout":                 {s.logout, false},
+		"GET me":                      {s.me, false},
+		"GET users.info":              {s.usersInfo, false},
+		"GET users.list":              {s.usersList, false},
+		"POST users.create":           {s.usersCreate, false},
+		"GET channels.list":           {s.roomsList("c", false), false},
+		"GET channels.list.joined":    {s.roomsList("c", true), false},
+		"GET channels.info":           {s.roomsInfo("c", "channel"), false},
+		"POST channels.create":        {s.roomsCreate("c", "channel"), false},
+		"GET channels.members":        {s.roomsMembers("c"), false},
+		"GET channels.history":        {s.roomsHistory("c"), false},
+		"GET channels.roles":          {s.roomsRoles("c"), false},
+		"POST channels.archive":       {s.roomsArchive("c"), false},
+		"POST channels.leave":         {s.roomsLeave("c"), false},
+		"POST channels.invite":        {s.roomsInvite("c", true), false},
+		"POST channels.kick":          {s.roomsInvite("c", false), false},
+		"POST channels.setTopic":      {s.roomsSetTopic("c"), false},
+		"GET groups.list":             {s.roomsList("p", true), false},
+		"GET groups.info":             {s.roomsInfo("p", "group"), false},
+		"POST groups.create":          {s.roomsCreate("p", "group"), false},
+		"GET groups.members":          {s.roomsMembers("p"), false},
+		"GET groups.history":          {s.roomsHistory("p"), false},
+		"GET groups.roles":            {s.roomsRoles("p"), false},
+		"POST groups.archive":         {s.roomsArchive("p"), false},
+		"POST groups.leave":           {s.roomsLeave("p"), false},
+		"POST groups.invite":          {s.roomsInvite("p", true), false},
+		"POST groups.kick":            {s.roomsInvite("p", false), false},
+		"POST groups.setTopic":        {s.roomsSetTopic("p"), false},
+		"GET im.members":              {s.roomsMembers("d"), false},
+		"GET im.history":              {s.roomsHistory("d"), false},
+		"POST im.setTopic":            {s.roomsSetTopic("d"), false},
+		"POST im.close":               {s.imClose, false},
+		"POST chat.postMessage":       {s.postMessage, false},
+		"GET chat.syncMessages":       {s.syncMessages, false},
+		"GET permissions.listAll":     {s.permissionsListAll, false},
+		"GET settings.public":         {s.settingsPublic, true},
+		"GET subscriptions.get":       {s.subscriptionsGet, false},
+		"GET subscriptions.getOne":    {s.subscriptionsGetOne, false},
+		"GET rooms.info":              {s.roomsInfo("", "room"), false},
+		"GET rooms.get":               {s.roomsGet, false},
+		"POST rooms.leave":            {s.roomsLeave(""), false},
+		"POST rooms.favorite":         {s.roomsFavorite, false},
+		"POST rooms.cleanHistory":     {s.roomsCleanHistory, false},
+		"POST rooms.saveRoomSettings": {s.saveRoomSettings, false},
+		"POST rooms.upload":           {s.roomsUpload, false},
+
+		"GET directory":                            {s.directory, false},
+		"GET spotlight":                            {s.spotlight, false},
+		"GET statistics":                           {s.statistics, false},
+		"GET statistics.list":                      {s.statisticsList, false},
+		"GET roles.list":                           {s.rolesList, false},
+		"POST permissions.update":                  {s.permissionsUpdate, false},
+		"GET users.getPresence":                    {s.usersGetPresence, false},
+		"POST users.setStatus":                     {s.usersSetStatus, false},
+		"GET users.getPreferences":                 {s.usersGetPreferences, false},
+		"GET users.getAvatar":                      {s.usersGetAvatar, true},
+		"POST users.generatePersonalAccessToken":   {s.accessTokensGenerate(false), false},
+		"POST users.regeneratePersonalAccessToken": {s.accessTokensGenerate(true), false},
+		"GET users.getPersonalAccessTokens":        {s.accessTokensList, false},
+		"POST users.removePersonalAccessToken":     {s.accessTokensRemove, false},
+	}
+}
+
+func (s *Server) serveREST(w http.ResponseWriter, r *http.Request) {
+	endpoint := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/api/v1/"), "/api/")
+	// The method name is part of the path of method.call/<method>, the room the one of
+	// rooms.upload/<room ID>.
+	if i := strings.Index(endpoint, "/"); i > 0 && (strings.HasPrefix(endpoint, "method.call") || endpoint[:i] == "rooms.upload") {
+		endpoint = endpoint[:i]
+	}
+
+	s.mu.Lock()
+	custom, ok := s.rest[r.Method+" "+endpoint]
+	s.mu.Unlock()
+	if ok {
+		custom(w, r)
+		return
+	}
+
+	handler, ok := s.endpoints()[r.Method+" "+endpoint]
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]interface{}{
+			"success": false,
+			"error":   fmt.Sprintf("API endpoint %q not found", r.URL.Path),
+		})
+		return
+	}
+
+	s.mu.Lock()
+	u := s.userByToken(r.Header.Get("X-Auth-Token"))
+	if u != nil && u.ID != r.Header.Get("X-User-Id") {
+		u = nil
+	}
+	s.mu.Unlock()
+
+	if u == nil && !handler.public {
+		writeJSON(w, http.StatusUnauthorized, map[string]interface{}{
+			"status":  "error",
+			"message": "You must be logged in to do this.",
+		})
+		return
+	}
+
+	status, response := handler.handler(r, u)
+	if location, ok := response.(redirect); ok {
+		http.Redirect(w, r, string(location), status)
+	} else {
+		writeJSON(w, status, response)
+	}
+	s.notifyChanges()
+}
+
+// redirect is returned by handlers to redirect to its URL instead of writing a JSON response.
+type redirect string
+
+func writeJSON(w http.ResponseWriter, status int, response interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+func success(fields map[string]interface{}) (int, interface{}) {
+	if fields == nil {
+		fields = map[string]interface{}{}
+	}
+	fields["success"] = true
+	return http.StatusOK, fields
+}
+
+func failure(format string, args ...interface{}) (int, interface{}) {
+	return http.StatusBadRequest, map[string]interface{}{"success": false, "error": fmt.Sprintf(format, args...)}
+}
+
+// params reads the query of GET requests and the JSON or form body of POST requests.
+func params(r *http.Request) map[string]interface{} {
+	result := map[string]interface{}{}
+	if r.Method == http.MethodGet {
+		for key := range r.URL.Query() {
+			result[key] = r.URL.Query().Get(key)
+		}
+		return result
+	}
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		_ = json.NewDecoder(r.Body).Decode(&result)
+		return result
+	}
+	if r.ParseForm() == nil {
+		for key := range r.PostForm {
+			result[key] = r.PostForm.Get(key)
+		}
+	}
+	return result
+}
+
+func stringParam(params map[string]interface{}, key string) string {
+	value, _ := params[key].(string)
+	return value
+}
+
+func intParam(params map[string]interface{}, key string, fallback int) int {
+	switch value := params[key].(type) {
+	case string:
+		if i, err := strconv.Atoi(value); err == nil {
+			return i
+		}
+	case float64:
+		return int(value)
+	}
+	return fallback
+}
+
+func (s *Server) info(r *http.Request, u *user) (int, interface{}) {
+	return success(map[string]interface{}{"version": Version, "info": map[string]interface{}{"version": Version}})
+}
+
+// methodCall calls a DDP method like the websocket does, the messages are JSON strings.
+func (s *Server) methodCall(r *http.Request, u *user) (int, interface{}) {
+	endpoint := strings.TrimPrefix(r.URL.Path, "/api/v1/")
+	name := endpoint[strings.Index(endpoint, "/")+1:]
+
+	var request struct {
+		Message string `json:"message"`
+	}
+	var msg struct {
+		ID     string        `json:"id"`
+		Params []interface{} `json:"params"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return failure("invalid body: %v", err)
+	}
+	if err := json.Unmarshal([]byte(request.Message), &msg); err != nil {
+		return failure("invalid message: %v", err)
+	}
+
+	conn := &ddpConn{streams: map[string]map[string]bool{}}
+	if u != nil {
+		conn.userID = u.ID
+	}
+
+	result, meteorErr := s.callMethod(conn, name, msg.Params)
+	reply := map[string]interface{}{"msg": "result", "id": msg.ID}
+	if meteorErr != nil {
+		reply["error"] = meteorErr
+	} else {
+		reply["result"] = toDDP(result)
+	}
+	message, err := json.Marshal(reply)
+	if err != nil {
+		return failure("encoding result: %v", err)
+	}
+
+	if meteorErr != nil {
+		return http.StatusBadRequest, map[string]interface{}{"success": false, "message": string(message)}
+	}
+	return success(map[string]interface{}{"message": string(message)})
+}
+
+func (s *Server) login(r *http.Request, _ *user) (int, interface{}) {
+	p := params(r)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var u *user
+	switch {
+	case stringParam(p, "resume") != "":
+		u = s.userByToken(stringParam(p, "resume"))
+	case stringParam(p, "username") != "":
+		u = s.userByName(stringParam(p, "username"))
+	default:
+		login := stringParam(p, "user")
+		if u = s.userByName(login); u == nil {
+			u = s.userByEmail(login)
+		}
+	}
+
+	if u == nil || (stringParam(p, "resume") == "" && u.password != stringParam(p, "password")) {
+		return http.StatusUnauthorized, map[string]interface{}{"status": "error", "error": "Unauthorized", "message": "Unauthorized"}
+	}
+
+	token := stringParam(p, "resume")
+	if token == "" {
+		token = s.newToken(u)
+	}
+	return http.StatusOK, map[string]interface{}{
+		"status": "success",
+		"data":   map[string]interface{}{"authToken": token, "userId": u.ID, "me": u.User},
+	}
+}
+
+func (s *Server) userByEmail(email string) *user {
+	for _, u := range s.users {
+		if u.Email() == email {
+			return u
+		}
+	}
+	return nil
+}
+
+func (s *Server) logout(r *http.Request, u *user) (int, interface{}) {
+	s.mu.Lock()
+	delete(u.tokens, r.Header.Get("X-Auth-Token"))
+	s.mu.Unlock()
+
+	return http.StatusOK, map[string]interface{}{
+		"status": "success",
+		"data":   map[string]interface{}{"message": "You've been logged out!"},
+	}
+}
+
+func (s *Server) me(r *http.Request, u *user) (int, interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, _ := json.Marshal(u.User)
+	response := map[string]interface{}{}
+	_ = json.Unmarshal(data, &response)
+	return success(response)
+}
+
+func (s *Server) usersInfo(r *http.Request, _ *user) (int, interface{}) {
+	p := params(r)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u := s.users[stringParam(p, "userId")]
+	if username := stringParam(p, "username"); username != "" {
+		u = s.userByName(username)
+	}
+	if u == nil {
+		return failure("User not found.")
+	}
+	return success(map[string]interface{}{"user": u.User})
+}
+
+func (s *Server) usersList(r *http.Request, _ *user) (int, interface{}) {
+	p := params(r)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	users := make([]models.User, 0, len(s.users))
+	for _, u := range s.users {
+		users = append(users, u.User)
+	}
+	sortUsers(users)
+
+	offset, count := intParam(p, "offset", 0), intParam(p, "count", 50)
+	total := len(users)
+	start, end := pageBounds(total, offset, count)
+	users = users[start:end]
+	return success(map[string]interface{}{"users": users, "offset": offset, "count": len(users), "total": total})
+}
+
+func (s *Server) usersCreate(r *http.Request, creator *user) (int, interface{}) {
+	var req models.CreateUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return failure("Invalid request: %v", err)
+	}
+
+	s.mu.Lock()
+	exists := s.userByName(req.Username) != nil
+	s.mu.Unlock()
+	if exists {
+		return failure("%s is already in use :( [error-field-unavailable]", req.Username)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	created := s.addUser(req.Username, req.Password, req.Roles)
+	u := s.users[created.ID]
+	u.Name = req.Name
+	u.Emails = []models.UserEmail{{Address: req.Email}}
+	if len(req.CustomFields) > 0 {
+		u.CustomFields = make(map[string]interface{}, len(req.CustomFields))
+		for key, value := range req.CustomFields {
+			u.CustomFields[key] = value
+		}
+	}
+	return success(map[string]interface{}{"user": u.User})
+}
+
+func (s *Server) roomsList(roomType string, joined bool) restHandler {
+	return func(r *http.Request, u *user) (int, interface{}) {
+		p := params(r)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		rooms := s.sortedRooms(func(room *room) bool {
+			return room.Type == roomType && (!joined || containsString(room.members, u.UserName))
+		})
+		channels := make([]models.Channel, 0, len(rooms))
+		for _, room := range rooms {
+			channels = append(channels, room.Channel)
+		}
+
+		key := "channels"
+		if roomType == "p" {
+			key = "groups"
+		}
+		offset, count := intParam(p, "offset", 0), intParam(p, "count", 50)
+		total := len(channels)
+		start, end := pageBounds(total, offset, count)
+		channels = channels[start:end]
+		return success(map[string]interface{}{key: channels, "offset": offset, "count": len(channels), "total": total})
+	}
+}
+
+// findRoom looks the room of the request up by roomId or roomName.
+func (s *Server) findRoom(p map[string]interface{}, roomType string, u *user) (*room, error) {
+	var r *room
+	if id := stringParam(p, "roomId"); id != "" {
+		r = s.rooms[id]
+	} else if name := stringParam(p, "roomName"); name != "" {
+		r = s.room(name)
+	}
+
+	if r == nil || (roomType != "" && r.Type != roomType) {
+		return nil, fmt.Errorf("The required \"roomId\" or \"roomName\" param provided does not match any room [error-room-not-found]")
+	}
+	if r.Type != "c" && !containsString(r.members, u.UserName) {
+		return nil, fmt.Errorf("The required \"roomId\" or \"roomName\" param provided does not match any room [error-room-not-found]")
+	}
+	return r, nil
+}
+
+func (s *Server) roomsInfo(roomType, key string) restHandler {
+	return func(r *http.Request, u *user) (int, interface{}) {
+		p := params(r)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		room, err := s.findRoom(p, roomType, u)
+		if err != nil {
+			return failure(err.Error())
+		}
+		return success(map[string]interface{}{key: room.Channel})
+	}
+}
+
+func (s *Server) roomsCreate(roomType, key string) restHandler {
+	return func(r *http.Request, u *user) (int, interface{}) {
+		var req models.CreateChannelRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return failure("Invalid request: %v", err)
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if req.Name == "" || s.room(req.Name) != nil {
+			return failure("A channel with name '%s' exists [error-duplicate-channel-name]", req.Name)
+		}
+		room := s.addRoom(req.Name, roomType, u.UserName, req.Members)
+		room.ReadOnly = req.ReadOnly
+		return success(map[string]interface{}{key: room.Channel})
+	}
+}
+
+func (s *Server) roomsMembers(roomType string) restHandler {
+	return func(r *http.Request, u *user) (int, interface{}) {
+		p := params(r)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		room, err := s.findRoom(p, roomType, u)
+		if err != nil {
+			return failure(err.Error())
+		}
+
+		members := make([]models.User, 0, len(room.members))
+		for _, username := range room.members {
+			if member := s.userByName(username); member != nil {
+				members = append(members, models.User{ID: member.ID, UserName: member.UserName, Name: member.Name, Status: member.Status})
+			}
+		}
+
+		offset, count := intParam(p, "offset", 0), intParam(p, "count", 50)
+		total := len(members)
+		start, end := pageBounds(total, offset, count)
+		members = members[start:end]
+		return success(map[string]interface{}{"members": members, "offset": offset, "count": len(members), "total": total})
+	}
+}
+
+// roomsHistory returns the messages newest first. It supports count, offset, latest and oldest.
+func (s *Server) roomsHistory(roomType string) restHandler {
+	return func(r *http.Request, u *user) (int, interface{}) {
+		p := params(r)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		room, err := s.findRoom(p, roomType, u)
+		if err != nil {
+			return failure(err.Error())
+		}
+
+		latest, _ := parseTime(stringParam(p, "latest"))
+		oldest, _ := parseTime(stringParam(p, "oldest"))
+
+		messages := []models.Message{}
+		stored := s.messages[room.ID]
+		for i := len(stored) - 1; i >= 0; i-- {
+			ts := *stored[i].Timestamp
+			if (!latest.IsZero() && !ts.Before(latest)) || (!oldest.IsZero() && !ts.After(oldest)) {
+				continue
+			}
+			messages = append(messages, *stored[i])
+		}
+
+		start, end := pageBounds(len(messages), intParam(p, "offset", 0), intParam(p, "count", 20))
+		messages = messages[start:end]
+		return success(map[string]interface{}{"messages": messages})
+	}
+}
+
+func (s *Server) roomsRoles(roomType string) restHandler {
+	return func(r *http.Request, u *user) (int, interface{}) {
+		p := params(r)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		room, err := s.findRoom(p, roomType, u)
+		if err != nil {
+			return failure(err.Error())
+		}
+		return success(map[string]interface{}{"roles": s.roomRoles(room)})
+	}
+}
+
+func (s *Server) roomRoles(room *room) []models.RoomRoles {
+	roles := make([]models.RoomRoles, 0, len(room.roles))
+	for userID, userRoles := range room.roles {
+		if u, ok := s.users[userID]; ok {
+			roles = append(roles, models.RoomRoles{
+				ID:     room.ID + userID,
+				RoomID: room.ID,
+				User:   models.User{ID: u.ID, UserName: u.UserName, Name: u.Name},
+				Roles:  userRoles,
+			})
+		}
+	}
+	return roles
+}
+
+func (s *Server) roomsArchive(roomType string) restHandler {
+	return func(r *http.Request, u *user) (int, interface{}) {
+		p := params(r)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		room, err := s.findRoom(p, roomType, u)
+		if err != nil {
+			return failure(err.Error())
+		}
+		room.Archived = true
+		room.ReadOnly = true
+		s.roomChanged(room)
+		return success(nil)
+	}
+}
+
+func (s *Server) roomsLeave(roomType string) restHandler {
+	return func(r *http.Request, u *user) (int, interface{}) {
+		p := params(r)
+
+		s.mu.Lock()
+		room, err := s.findRoom(p, roomType, u)
+		s.mu.Unlock()
+		if err != nil {
+			return failure(err.Error())
+		}
+
+		if _, meteorErr := s.ddpJoinRoom(u, false, room.ID); meteorErr != nil {
+			return failure(meteorErr.Error())
+		}
+		return success(nil)
+	}
+}
+
+// roomsInvite adds the user of the request to the room, or removes the user if invite is false.
+func (s *Server) roomsInvite(roomType string, invite bool) restHandler {
+	return func(r *http.Request, u *user) (int, interface{}) {
+		p := params(r)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		room, err := s.findRoom(p, roomType, u)
+		if err != nil {
+			return failure(err.Error())
+		}
+		member, ok := s.users[stringParam(p, "userId")]
+		if !ok {
+			return failure("The required \"userId\" param provided does not match any users [error-invalid-user]")
+		}
+
+		members := []string{}
+		for _, username := range room.members {
+			if username != member.UserName {
+				members = append(members, username)
+			}
+		}
+		if invite {
+			members = append(members, member.UserName)
+		}
+		s.setMembers(room, members)
+
+		key := "channel"
+		if roomType == "p" {
+			key = "group"
+		}
+		return success(map[string]interface{}{key: room.Channel})
+	}
+}
+
+func (s *Server) roomsSetTopic(roomType string) restHandler {
+	return func(r *http.Request, u *user) (int, interface{}) {
+		p := params(r)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		room, err := s.findRoom(p, roomType, u)
+		if err != nil {
+			return failure(err.Error())
+		}
+		room.Topic = stringParam(p, "topic")
+		s.roomChanged(room)
+		return success(map[string]interface{}{"topic": room.Topic})
+	}
+}
+
+// imClose hides direct messages, the user stays a member.
+func (s *Server) imClose(r *http.Request, u *user) (int, interface{}) {
+	p := params(r)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.findRoom(p, "d", u); err != nil {
+		return failure(err.Error())
+	}
+	return success(nil)
+}
+
+// roomsGet returns the rooms of the user, with updatedSince only the rooms updated since then.
+func (s *Server) roomsGet(r *http.Request, u *user) (int, interface{}) {
+	since, err := parseTime(stringParam(params(r), "updatedSince"))
+	if err != nil {
+		return failure("Invalid updatedSince: %v", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	update, remove := s.roomsUpdatedSince(u, since)
+	return success(map[string]interface{}{"update": update, "remove": remove})
+}
+
+// subscriptionsGet returns the subscriptions of the user, with updatedSince only the ones
+// updated or removed since then.
+func (s *Server) subscriptionsGet(r *http.Request, u *user) (int, interface{}) {
+	since, err := parseTime(stringParam(params(r), "updatedSince"))
+	if err != nil {
+		return failure("Invalid updatedSince: %v", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	update, remove := s.subscriptionsUpdatedSince(u, since)
+	return success(map[string]interface{}{"update": update, "remove": remove})
+}
+
+func (s *Server) roomsFavorite(r *http.Request, u *user) (int, interface{}) {
+	p := params(r)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	room, err := s.findRoom(p, "", u)
+	if err != nil {
+		return failure(err.Error())
+	}
+	favorite, _ := p["favorite"].(bool)
+	if room.favorites == nil {
+		room.favorites = map[string]bool{}
+	}
+	room.favorites[u.ID] = favorite
+	s.roomChanged(room)
+	return success(nil)
+}
+
+// roomsCleanHistory deletes the messages between oldest and latest, users limits it to
+// messages of the usernames.
+func (s *Server) roomsCleanHistory(r *http.Request, u *user) (int, interface{}) {
+	p := params(r)
+	latest, err := parseTime(stringParam(p, "latest"))
+	if err != nil || latest.IsZero() {
+		return failure("Body parameter \"latest\" is required.")
+	}
+	oldest, err := parseTime(stringParam(p, "oldest"))
+	if err != nil || oldest.IsZero() {
+		return failure("Body parameter \"oldest\" is required.")
+	}
+	inclusive, _ := p["inclusive"].(bool)
+	var users []string
+	_ = remarshal(p["users"], &users)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	room, err := s.findRoom(p, "", u)
+	if err != nil {
+		return failure(err.Error())
+	}
+
+	kept := []*models.Message{}
+	for _, message := range s.messages[room.ID] {
+		ts := *message.Timestamp
+		inRange := ts.After(oldest) && ts.Before(latest)
+		if inclusive {
+			inRange = !ts.Before(oldest) && !ts.After(latest)
+		}
+		if inRange && (len(users) == 0 || containsString(users, message.User.UserName)) {
+			continue
+		}
+		kept = append(kept, message)
+	}
+	count := len(s.messages[room.ID]) - len(kept)
+	s.messages[room.ID] = kept
+	room.Msgs -= count
+	return success(map[string]interface{}{"count": count})
+}
+
+// roomsUpload posts a file as attachment of a message, the file is served by serveFile.
+func (s *Server) roomsUpload(r *http.Request, u *user) (int, interface{}) {
+	roomID := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+	upload, header, err := r.FormFile("file")
+	if err != nil {
+		return failure("No file uploaded: %v", err)
+	}
+	defer upload.Close()
+	data, err := ioutil.ReadAll(upload)
+	if err != nil {
+		return failure("Reading file: %v", err)
+	}
+
+	s.mu.Lock()
+	room, ok := s.rooms[roomID]
+	if !ok || !containsString(room.members, u.UserName) {
+		s.mu.Unlock()
+		return failure("Not allowed [error-not-allowed]")
+	}
+	id := s.newID()
+	s.files[id] = file{contentType: header.Header.Get("Content-Type"), data: data}
+	attachment := models.Attachment{
+		Title:             header.Filename,
+		TitleLink:         "/file-upload/" + id + "/" + url.PathEscape(header.Filename),
+		TitleLinkDownload: true,
+	}
+	message := s.addMessage(&models.Message{RoomID: room.ID, Msg: r.FormValue("msg"), PostMessage: models.PostMessage{Attachments: []models.Attachment{attachment}}}, u)
+	s.mu.Unlock()
+
+	s.notifyMessage(message)
+	return success(map[string]interface{}{"message": message})
+}
+
+// serveFile serves uploaded files to users logged in.
+func (s *Server) serveFile(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/file-upload/"), "/")
+
+	s.mu.Lock()
+	u := s.userByToken(r.Header.Get("X-Auth-Token"))
+	f, ok := s.files[parts[0]]
+	s.mu.Unlock()
+
+	switch {
+	case u == nil || u.ID != r.Header.Get("X-User-Id"):
+		http.Error(w, "Forbidden", http.StatusForbidden)
+	case !ok:
+		http.NotFound(w, r)
+	default:
+		w.Header().Set("Content-Type", f.contentType)
+		_, _ = w.Write(f.data)
+	}
+}
+
+func (s *Server) postMessage(r *http.Request, u *user) (int, interface{}) {
+	var req models.PostMessage
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return failure("Invalid request: %v", err)
+	}
+
+	s.mu.Lock()
+	target := req.RoomID
+	if target == "" {
+		target = req.Channel
+	}
+	room := s.room(target)
+	if room == nil {
+		s.mu.Unlock()
+		return failure("The channel %q does not exist [invalid-channel]", target)
+	}
+	if room.Archived {
+		s.mu.Unlock()
+		return failure("Room is archived [error-room-archived]")
+	}
+
+	message := s.addMessage(&models.Message{RoomID: room.ID, Msg: req.Text, PostMessage: req}, u)
+	s.mu.Unlock()
+
+	s.notifyMessage(message)
+	return success(map[string]interface{}{"message": message, "ts": message.Timestamp.UnixNano() / 1e6, "channel": target})
+}
+
+// syncMessages returns the messages of a room updated and deleted after lastUpdate.
+func (s *Server) syncMessages(r *http.Request, u *user) (int, interface{}) {
+	query := params(r)
+	roomID, lastUpdate := stringParam(query, "roomId"), stringParam(query, "lastUpdate")
+	if roomID == "" {
+		return failure("The required \"roomId\" query param is missing.")
+	}
+	if lastUpdate == "" {
+		return failure("The required \"lastUpdate\" query param is missing.")
+	}
+	since, err := parseTime(lastUpdate)
+	if err != nil {
+		return failure("The \"lastUpdate\" query parameter must be a valid date.")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.rooms[roomID]; !ok {
+		return failure("Not allowed [error-not-allowed]")
+	}
+	return success(map[string]interface{}{"result": s.messageChanges(roomID, since)})
+}
+
+// permissionsListAll returns the permissions changed after updatedSince, all without it.
+func (s *Server) permissionsListAll(r *http.Request, u *user) (int, interface{}) {
+	since, err := parseTime(stringParam(params(r), "updatedSince"))
+	if err != nil {
+		return failure("Invalid date for `updatedSince`")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	permissions := []models.Permission{}
+	for _, permission := range s.sortedPermissions() {
+		if since.IsZero() || permission.UpdatedAt.After(since) {
+			permissions = append(permissions, permission)
+		}
+	}
+	return success(map[string]interface{}{"update": permissions, "remove": []models.Permission{}})
+}
+
+// sortedPermissions returns copies of the permissions by ID, it has to be called with the
+// lock held.
+func (s *Server) sortedPermissions() []models.Permission {
+	permissions := make([]models.Permission, 0, len(s.permissions))
+	for _, permission := range s.permissions {
+		permissions = append(permissions, *permission)
+	}
+	sort.Slice(permissions, func(i, j int) bool { return permissions[i].ID < permissions[j].ID })
+	return permissions
+}
+
+// permissionsUpdate sets the roles of permissions, the changes are sent to the logged in users.
+func (s *Server) permissionsUpdate(r *http.Request, u *user) (int, interface{}) {
+	var req struct {
+		Permissions []models.Permission `json:"permissions"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Permissions) == 0 {
+		return failure("Invalid body params [error-invalid-body-params]")
+	}
+
+	s.mu.Lock()
+	now := s.now()
+	var changed []models.Permission
+	for _, update := range req.Permissions {
+		permission, ok := s.permissions[update.ID]
+		if !ok {
+			s.mu.Unlock()
+			return failure("Invalid permission [error-invalid-permission]")
+		}
+		permission.Roles = append([]string{}, update.Roles...)
+		permission.UpdatedAt = &now
+		changed = append(changed, *permission)
+	}
+	permissions := s.sortedPermissions()
+	s.mu.Unlock()
+
+	for _, permission := range changed {
+		s.Emit("stream-notify-logged", "permissions-changed", "updated", permission)
+	}
+	return success(map[string]interface{}{"permissions": permissions})
+}
+
+func (s *Server) rolesList(r *http.Request, u *user) (int, interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return success(map[string]interface{}{"roles": append([]models.Role{}, s.roles...)})
+}
+
+// directory searches the channels or the users by name, query is {"text": ..., "type": ...}.
+func (s *Server) directory(r *http.Request, u *user) (int, interface{}) {
+	p := params(r)
+	var query struct {
+		Text string `json:"text"`
+		Type string `json:"type"`
+	}
+	if raw := stringParam(p, "query"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &query); err != nil {
+			return failure("Invalid query")
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := []map[string]interface{}{}
+	if query.Type == "users" {
+		users := make([]models.User, 0, len(s.users))
+		for _, candidate := range s.users {
+			if strings.Contains(candidate.UserName, query.Text) || strings.Contains(candidate.Name, query.Text) {
+				users = append(users, candidate.User)
+			}
+		}
+		sortUsers(users)
+		for _, found := range users {
+			result = append(result, map[string]interface{}{
+				"_id": found.ID, "username": found.UserName, "name": found.Name, "emails": found.Emails, "createdAt": found.CreatedAt,
+			})
+		}
+	} else {
+		rooms := s.sortedRooms(func(r *room) bool { return r.Type == "c" && strings.Contains(r.Name, query.Text) })
+		for _, found := range rooms {
+			result = append(result, map[string]interface{}{
+				"_id": found.ID, "name": found.Name, "fname": found.Fname, "t": found.Type, "usersCount": len(found.members), "createdAt": found.Timestamp,
+			})
+		}
+	}
+
+	offset, count := intParam(p, "offset", 0), intParam(p, "count", 50)
+	total := len(result)
+	start, end := pageBounds(total, offset, count)
+	result = result[start:end]
+	return success(map[string]interface{}{"result": result, "offset": offset, "count": len(result), "total": total})
+}
+
+// spotlight searches users by "@name", public rooms the user didn't join by "#name", and both
+// without a prefix.
+func (s *Server) spotlight(r *http.Request, u *user) (int, interface{}) {
+	query := stringParam(params(r), "query")
+	searchUsers, searchRooms := !strings.HasPrefix(query, "#"), !strings.HasPrefix(query, "@")
+	text := strings.TrimLeft(query, "@#")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	users := []models.User{}
+	if searchUsers {
+		for _, candidate := range s.users {
+			if strings.Contains(candidate.UserName, text) || strings.Contains(candidate.Name, text) {
+				users = append(users, models.User{ID: candidate.ID, UserName: candidate.UserName, Name: candidate.Name, Status: candidate.Status})
+			}
+		}
+		sortUsers(users)
+	}
+	rooms := []models.Channel{}
+	if searchRooms {
+		for _, found := range s.sortedRooms(func(r *room) bool {
+			return r.Type == "c" && strings.Contains(r.Name, text) && !containsString(r.members, u.UserName)
+		}) {
+			rooms = append(rooms, models.Channel{ID: found.ID, Name: found.Name, Fname: found.Fname, Type: found.Type})
+		}
+	}
+	return success(map[string]interface{}{"users": users, "rooms": rooms})
+}
+
+// stats counts the users, rooms and messages, it has to be called with the lock held.
+func (s *Server) stats() models.Statistics {
+	now := s.now()
+	stats := models.Statistics{ID: "statistics", UniqueID: "fakeserver", Version: Version, CreatedAt: now, UpdatedAt: now}
+	for _, u := range s.users {
+		stats.TotalUsers++
+		if u.Active {
+			stats.ActiveUsers++
+		} else {
+			stats.NonActiveUsers++
+		}
+		switch u.Status {
+		case "online":
+			stats.OnlineUsers++
+		case "away":
+			stats.AwayUsers++
+		default:
+			stats.OfflineUsers++
+		}
+	}
+	for _, r := range s.rooms {
+		stats.TotalRooms++
+		count := len(s.messages[r.ID])
+		stats.TotalMessages += count
+		switch r.Type {
+		case "c":
+			stats.TotalChannels++
+			stats.TotalChannelMessages += count
+		case "p":
+			stats.TotalPrivateGroups++
+			stats.TotalPrivateGroupMessages += count
+		case "d":
+			stats.TotalDirect++
+			stats.TotalDirectMessages += count
+		}
+	}
+	return stats
+}
+
+func (s *Server) statistics(r *http.Request, u *user) (int, interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.hasPermission(u, "view-statistics") {
+		return http.StatusForbidden, map[string]interface{}{"success": false, "error": "unauthorized"}
+	}
+	return success(map[string]interface{}{"statistics": s.stats()})
+}
+
+func (s *Server) statisticsList(r *http.Request, u *user) (int, interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.hasPermission(u, "view-statistics") {
+		return http.StatusForbidden, map[string]interface{}{"success": false, "error": "unauthorized"}
+	}
+	return success(map[string]interface{}{"statistics": []models.Statistics{s.stats()}, "offset": 0, "count": 1, "total": 1})
+}
+
+// hasPermission reports whether a role of the user has the permission, it has to be called
+// with the lock held.
+func (s *Server) hasPermission(u *user, id string) bool {
+	permission, ok := s.permissions[id]
+	if !ok {
+		return false
+	}
+	for _, role := range u.Roles {
+		if containsString(permission.Roles, role) {
+			return true
+		}
+	}
+	return false
+}
+
+// userParam returns the user given by userId or username, the logged in user without them.
+// It has to be called with the lock held.
+func (s *Server) userParam(p map[string]interface{}, u *user) *user {
+	if id := stringParam(p, "userId"); id != "" {
+		return s.users[id]
+	}
+	if username := stringParam(p, "username"); username != "" {
+		return s.userByName(username)
+	}
+	return u
+}
+
+func (s *Server) usersGetPresence(r *http.Request, u *user) (int, interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	target := s.userParam(params(r), u)
+	if target == nil {
+		return failure("User not found.")
+	}
+	return success(map[string]interface{}{"presence": target.Status, "connectionStatus": target.Status})
+}
+
+func (s *Server) usersSetStatus(r *http.Request, u *user) (int, interface{}) {
+	p := params(r)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	target := s.userParam(p, u)
+	if target == nil {
+		return failure("User not found.")
+	}
+	if status := stringParam(p, "status"); status != "" {
+		target.Status = status
+	}
+	if message, ok := p["message"].(string); ok {
+		target.StatusText = message
+	}
+	return success(nil)
+}
+
+func (s *Server) usersGetPreferences(r *http.Request, u *user) (int, interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return success(map[string]interface{}{"preferences": u.preferences})
+}
+
+// usersGetAvatar redirects to the avatar of the user, like the server does.
+func (s *Server) usersGetAvatar(r *http.Request, _ *user) (int, interface{}) {
+	s.mu.Lock()
+	target := s.userParam(params(r), nil)
+	s.mu.Unlock()
+	if target == nil {
+		return failure("User not found.")
+	}
+	return http.StatusFound, redirect(s.server.URL + "/avatar/" + url.PathEscape(target.UserName))
+}
+
+// accessTokensGenerate creates a personal access token, regenerate replaces an existing one.
+func (s *Server) accessTokensGenerate(regenerate bool) restHandler {
+	return func(r *http.Request, u *user) (int, interface{}) {
+		p := params(r)
+		name := stringParam(p, "tokenName")
+		if name == "" {
+			return failure("Param \"tokenName\" is required [error-param-required]")
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		existing, exists := u.accessTokens[name]
+		switch {
+		case regenerate && !exists:
+			return failure("Invalid token name [not-authorized]")
+		case !regenerate && exists:
+			return failure("A token with this name already exists [error-token-already-exists]")
+		}
+
+		bypass, _ := p["bypassTwoFactor"].(bool)
+		if regenerate {
+			delete(u.tokens, existing.token)
+			bypass = existing.bypass2FA
+		}
+		token := s.newToken(u)
+		u.accessTokens[name] = accessToken{token: token, createdAt: s.now(), bypass2FA: bypass}
+		return success(map[string]interface{}{"token": token})
+	}
+}
+
+func (s *Server) accessTokensList(r *http.Request, u *user) (int, interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tokens := make([]models.PersonalAccessToken, 0, len(u.accessTokens))
+	for name, token := range u.accessTokens {
+		tokens = append(tokens, models.PersonalAccessToken{
+			Name: name, CreatedAt: token.createdAt, LastTokenPart: token.token[len(token.token)-6:], BypassTwoFactor: token.bypass2FA,
+		})
+	}
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i].Name < tokens[j].Name })
+	return success(map[string]interface{}{"tokens": tokens})
+}
+
+func (s *Server) accessTokensRemove(r *http.Request, u *user) (int, interface{}) {
+	name := stringParam(params(r), "tokenName")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	token, ok := u.accessTokens[name]
+	if !ok {
+		return failure("Invalid token name [not-authorized]")
+	}
+	delete(u.tokens, token.token)
+	delete(u.accessTokens, name)
+	return success(nil)
+}
+
+func (s *Server) settingsPublic(r *http.Request, u *user) (int, interface{}) {
+	return success(map[string]interface{}{"settings": []models.Setting{}, "offset": 0, "count": 0, "total": 0})
+}
+
+func (s *Server) subscriptionsGetOne(r *http.Request, u *user) (int, interface{}) {
+	p := params(r)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	room, ok := s.rooms[stringParam(p, "roomId")]
+	if !ok || !containsString(room.members, u.UserName) {
+		return success(map[string]interface{}{"subscription": nil})
+	}
+	return success(map[string]interface{}{"subscription": s.subscription(room, u)})
+}
+
+func (s *Server) saveRoomSettings(r *http.Request, u *user) (int, interface{}) {
+	p := params(r)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	room, ok := s.rooms[stringParam(p, "rid")]
+	if !ok {
+		return failure("Invalid room [error-invalid-room]")
+	}
+	if value, ok := p["default"].(bool); ok {
+		room.Default = value
+	}
+	if value, ok := p["readOnly"].(bool); ok {
+		room.ReadOnly = value
+	}
+	return success(map[string]interface{}{"rid": room.ID})
+}
+
+// pageBounds returns the part of a list of the length to return for the offset and count.
+func pageBounds(length, offset, count int) (int, int) {
+	if offset > length {
+		offset = length
+	}
+	end := length
+	if count > 0 && offset+count < length {
+		end = offset + count
+	}
+	return offset, end
+}
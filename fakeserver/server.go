@@ -0,0 +1,488 @@
+// Package fakeserver provides an in-process Rocket.Chat server for tests. It serves the common
+// REST endpoints and a DDP websocket with in-memory users, rooms and messages, so bots built
+// on the rest and realtime clients can be tested offline.
+//
+//	server := fakeserver.New()
+//	defer server.Close()
+//	server.AddUser("bot", "pass", "bot")
+//	general := server.Room(fakeserver.GeneralRoomID)
+//
+//	client := rest.NewClient(server.URL(), false)
+package fakeserver
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/websocket"
+
+	"github.com/yazver/Rocket.Chat.Go.SDK/models"
+)
+
+const (
+	// Version is the server version reported by /api/info.
+	Version = "3.18.0"
+	// GeneralRoomID is the ID of the default channel every user joins.
+	GeneralRoomID = "GENERAL"
+)
+
+// MethodFunc handles a DDP method call of the user with the ID. The result is sent back,
+// an error is sent as Meteor error.
+type MethodFunc func(userID string, params []interface{}) (interface{}, error)
+
+// Server is a fake Rocket.Chat server. All methods are safe for concurrent use.
+type Server struct {
+	// Now gives the time of created messages and rooms, it may be replaced for stable results.
+	Now func() time.Time
+
+	server *httptest.Server
+
+	mu       sync.Mutex
+	nextID   int
+	users    map[string]*user
+	rooms    map[string]*room
+	messages map[string][]*models.Message
+	deleted  map[string][]models.DeletedMessage
+	conns    map[*ddpConn]bool
+	rest     map[string]http.HandlerFunc
+	methods  map[string]MethodFunc
+	files    map[string]file
+	// permissions are the permissions with the roles granted them, roles the roles.
+	permissions map[string]*models.Permission
+	roles       []models.Role
+
+	// changes are the rooms and memberships changed by the current request, their users are
+	// notified when it's done.
+	changes []roomChange
+}
+
+type roomChange struct {
+	roomID string
+	// userID is set if the user left the room.
+	userID string
+}
+
+// file is an uploaded file, served at /file-upload/<ID>/<name>.
+type file struct {
+	contentType string
+	data        []byte
+}
+
+type user struct {
+	models.User
+	password string
+	tokens   map[string]bool
+	// accessTokens are the personal access tokens by their names.
+	accessTokens map[string]accessToken
+	preferences  models.UserPreferences
+}
+
+// accessToken is a personal access token, it's a login token too.
+type accessToken struct {
+	token     string
+	createdAt time.Time
+	bypass2FA bool
+}
+
+type room struct {
+	models.Channel
+	owner   string
+	members []string
+	roles   map[string][]string
+	// favorites are the IDs of the users who marked the room as favorite.
+	favorites map[string]bool
+	// left are the IDs of the users who left the room with the time they left.
+	left map[string]time.Time
+}
+
+// New starts a fake server. It has to be closed after use.
+func New() *Server {
+	s := &Server{
+		Now:      time.Now,
+		users:    map[string]*user{},
+		rooms:    map[string]*room{},
+		messages: map[string][]*models.Message{},
+		deleted:  map[string][]models.DeletedMessage{},
+		conns:    map[*ddpConn]bool{},
+		rest:     map[string]http.HandlerFunc{},
+		methods:  map[string]MethodFunc{},
+		files:    map[string]file{},
+
+		permissions: map[string]*models.Permission{},
+	}
+
+	// Some of the default roles and permissions of a new installation.
+	for _, role := range []string{"admin", "moderator", "owner", "leader", "user", "bot", "guest", "anonymous"} {
+		scope := models.RoleScopeUsers
+		if role == "moderator" || role == "owner" || role == "leader" {
+			scope = models.RoleScopeSubscriptions
+		}
+		s.roles = append(s.roles, models.Role{ID: role, Name: role, Scope: scope, Protected: true})
+	}
+	for id, roles := range map[string][]string{
+		"access-permissions":     {"admin"},
+		"add-user-to-any-p-room": {"admin"},
+		"create-c":               {"admin", "user", "bot"},
+		"create-p":               {"admin", "user", "bot"},
+		"view-history":           {"admin", "user", "bot", "anonymous"},
+		"view-statistics":        {"admin"},
+	} {
+		now := s.now()
+		s.permissions[id] = &models.Permission{ID: id, Roles: roles, UpdatedAt: &now}
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/websocket", websocket.Handler(s.serveDDP))
+	mux.HandleFunc("/api/", s.serveREST)
+	mux.HandleFunc("/file-upload/", s.serveFile)
+	s.server = httptest.NewServer(mux)
+
+	// Like a new installation, there is the default channel general.
+	s.mu.Lock()
+	general := s.addRoom("general", "c", "", nil)
+	delete(s.rooms, general.ID)
+	general.ID = GeneralRoomID
+	general.Default = true
+	s.rooms[general.ID] = general
+	s.mu.Unlock()
+
+	return s
+}
+
+// URL is the address of the server, to be used with rest.NewClient and realtime.NewClient.
+func (s *Server) URL() *url.URL {
+	u, _ := url.Parse(s.server.URL)
+	return u
+}
+
+// Close closes the websocket connections and stops the server.
+func (s *Server) Close() {
+	s.mu.Lock()
+	conns := make([]*ddpConn, 0, len(s.conns))
+	for conn := range s.conns {
+		conns = append(conns, conn)
+	}
+	s.mu.Unlock()
+
+	for _, conn := range conns {
+		conn.ws.Close()
+	}
+	s.server.Close()
+}
+
+// HandleREST adds or replaces a REST endpoint, e.g. HandleREST(http.MethodGet, "statistics", ...)
+// for /api/v1/statistics.
+func (s *Server) HandleREST(method, endpoint string, handler http.HandlerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rest[method+" "+endpoint] = handler
+}
+
+// HandleMethod adds or replaces a DDP method.
+func (s *Server) HandleMethod(name string, handler MethodFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.methods[name] = handler
+}
+
+// newID returns a new unique ID, it has to be called with the lock held.
+func (s *Server) newID() string {
+	s.nextID++
+	return fmt.Sprintf("fake%013d", s.nextID)
+}
+
+func (s *Server) now() time.Time {
+	return s.Now().UTC().Truncate(time.Millisecond)
+}
+
+// AddUser creates a user which can log in with the password and joins the default rooms.
+// Without roles the user gets the role "user".
+func (s *Server) AddUser(username, password string, roles ...string) *models.User {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.addUser(username, password, roles)
+}
+
+func (s *Server) addUser(username, password string, roles []string) *models.User {
+	if len(roles) == 0 {
+		roles = []string{"user"}
+	}
+
+	now := s.now()
+	u := &user{
+		User: models.User{
+			ID:        s.newID(),
+			Name:      username,
+			UserName:  username,
+			Status:    "online",
+			Emails:    []models.UserEmail{{Address: username + "@example.com", Verified: true}},
+			Roles:     roles,
+			Active:    true,
+			Type:      "user",
+			CreatedAt: &now,
+			UpdatedAt: &now,
+		},
+		password:     password,
+		tokens:       map[string]bool{},
+		accessTokens: map[string]accessToken{},
+	}
+	s.users[u.ID] = u
+
+	for _, r := range s.rooms {
+		if r.Default {
+			r.members = append(r.members, username)
+			r.Users = r.members
+		}
+	}
+
+	result := u.User
+	return &result
+}
+
+// Token creates a login token for a user, like a personal access token.
+func (s *Server) Token(userID string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[userID]
+	if !ok {
+		return ""
+	}
+	return s.newToken(u)
+}
+
+func (s *Server) newToken(u *user) string {
+	token := "token-" + s.newID()
+	u.tokens[token] = true
+	return token
+}
+
+// AddRoom creates a room of the type "c" (channel), "p" (private group) or "d" (direct messages)
+// with the members given by their usernames.
+func (s *Server) AddRoom(name, roomType string, members ...string) *models.Channel {
+	s.mu.Lock()
+	r := s.addRoom(name, roomType, "", members)
+	result := r.Channel
+	s.mu.Unlock()
+
+	s.notifyChanges()
+	return &result
+}
+
+func (s *Server) addRoom(name, roomType, owner string, members []string) *room {
+	now := s.now()
+	r := &room{
+		Channel: models.Channel{
+			ID:        s.newID(),
+			Name:      name,
+			Fname:     name,
+			Type:      roomType,
+			Timestamp: &now,
+			UpdatedAt: &now,
+		},
+		owner:   owner,
+		members: append([]string{}, members...),
+		roles:   map[string][]string{},
+		left:    map[string]time.Time{},
+	}
+	if owner != "" {
+		if u := s.userByName(owner); u != nil {
+			r.User = &models.User{ID: u.ID, UserName: u.UserName}
+			r.roles[u.ID] = []string{"owner"}
+		}
+		if !containsString(r.members, owner) {
+			r.members = append(r.members, owner)
+		}
+	}
+	r.Users = r.members
+	s.rooms[r.ID] = r
+	s.changes = append(s.changes, roomChange{roomID: r.ID})
+	return r
+}
+
+// setMembers changes the members of a room, it has to be called with the lock held.
+func (s *Server) setMembers(r *room, members []string) {
+	now := s.now()
+	for _, username := range r.members {
+		if u := s.userByName(username); u != nil && !containsString(members, username) {
+			r.left[u.ID] = now
+			s.changes = append(s.changes, roomChange{roomID: r.ID, userID: u.ID})
+		}
+	}
+	for _, username := range members {
+		if u := s.userByName(username); u != nil {
+			delete(r.left, u.ID)
+		}
+	}
+
+	r.members = members
+	r.Users = members
+	s.roomChanged(r)
+}
+
+// roomChanged updates the room, its members are notified when the request is done. It has to
+// be called with the lock held.
+func (s *Server) roomChanged(r *room) {
+	now := s.now()
+	r.UpdatedAt = &now
+	s.changes = append(s.changes, roomChange{roomID: r.ID})
+}
+
+// notifyChanges sends the changed rooms and subscriptions to the user streams of the members,
+// users who left get their subscription removed.
+func (s *Server) notifyChanges() {
+	type event struct {
+		name string
+		args []interface{}
+	}
+
+	s.mu.Lock()
+	var events []event
+	for _, change := range s.changes {
+		r, ok := s.rooms[change.roomID]
+		if !ok {
+			continue
+		}
+		if change.userID != "" {
+			removed := map[string]interface{}{"_id": r.ID + change.userID, "rid": r.ID, "_deletedAt": r.left[change.userID]}
+			events = append(events, event{change.userID + "/subscriptions-changed", []interface{}{"removed", removed}})
+			continue
+		}
+		for _, username := range r.members {
+			if u := s.userByName(username); u != nil {
+				events = append(events,
+					event{u.ID + "/rooms-changed", []interface{}{"updated", r.Channel}},
+					event{u.ID + "/subscriptions-changed", []interface{}{"updated", s.subscription(r, u)}})
+			}
+		}
+	}
+	s.changes = nil
+	s.mu.Unlock()
+
+	for _, e := range events {
+		s.Emit("stream-notify-user", e.name, e.args...)
+	}
+}
+
+// AddMessage posts a message of the user to the room, subscribers of the room get it.
+func (s *Server) AddMessage(roomID, username, text string) *models.Message {
+	s.mu.Lock()
+	u := s.userByName(username)
+	if u == nil {
+		s.mu.Unlock()
+		return nil
+	}
+	message := s.addMessage(&models.Message{RoomID: roomID, Msg: text}, u)
+	s.mu.Unlock()
+
+	s.notifyMessage(message)
+	return message
+}
+
+// addMessage stores a message, it has to be called with the lock held.
+func (s *Server) addMessage(message *models.Message, u *user) *models.Message {
+	now := s.now()
+	stored := *message
+	if stored.ID == "" {
+		stored.ID = s.newID()
+	}
+	stored.Timestamp = &now
+	stored.UpdatedAt = &now
+	stored.User = &models.User{ID: u.ID, UserName: u.UserName, Name: u.Name}
+	stored.PostMessage = models.PostMessage{Attachments: message.Attachments, Blocks: message.Blocks, Alias: message.Alias, Emoji: message.Emoji, Avatar: message.Avatar}
+
+	s.messages[stored.RoomID] = append(s.messages[stored.RoomID], &stored)
+	if r, ok := s.rooms[stored.RoomID]; ok {
+		r.Msgs++
+		s.roomChanged(r)
+		last := stored
+		r.LastMessage = &last
+	}
+
+	result := stored
+	return &result
+}
+
+// Messages returns the messages of a room, oldest first.
+func (s *Server) Messages(roomID string) []models.Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	messages := make([]models.Message, 0, len(s.messages[roomID]))
+	for _, message := range s.messages[roomID] {
+		messages = append(messages, *message)
+	}
+	return messages
+}
+
+// Room returns a room by its ID or name.
+func (s *Server) Room(idOrName string) *models.Channel {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r := s.room(idOrName); r != nil {
+		result := r.Channel
+		return &result
+	}
+	return nil
+}
+
+func (s *Server) room(idOrName string) *room {
+	if r, ok := s.rooms[idOrName]; ok {
+		return r
+	}
+	name := strings.TrimPrefix(idOrName, "#")
+	for _, r := range s.rooms {
+		if r.Name == name {
+			return r
+		}
+	}
+	return nil
+}
+
+func (s *Server) userByName(username string) *user {
+	username = strings.TrimPrefix(username, "@")
+	for _, u := range s.users {
+		if u.UserName == username {
+			return u
+		}
+	}
+	return nil
+}
+
+func (s *Server) userByToken(token string) *user {
+	for _, u := range s.users {
+		if u.tokens[token] {
+			return u
+		}
+	}
+	return nil
+}
+
+// sortedRooms returns the rooms in creation order, it has to be called with the lock held.
+func (s *Server) sortedRooms(filter func(*room) bool) []*room {
+	rooms := make([]*room, 0, len(s.rooms))
+	for _, r := range s.rooms {
+		if filter(r) {
+			rooms = append(rooms, r)
+		}
+	}
+	sort.Slice(rooms, func(i, j int) bool { return rooms[i].ID < rooms[j].ID })
+	return rooms
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,149 @@
+package fakeserver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yazver/Rocket.Chat.Go.SDK/models"
+	"github.com/yazver/Rocket.Chat.Go.SDK/realtime"
+	"github.com/yazver/Rocket.Chat.Go.SDK/rest"
+)
+
+func TestServer_REST(t *testing.T) {
+	server := New()
+	defer server.Close()
+
+	server.AddUser("alice", "secret")
+	general := server.AddRoom("news", "c", "alice")
+
+	client := rest.NewClient(server.URL(), false)
+	me, err := client.Login(&models.UserCredentials{Username: "alice", Password: "secret"})
+	require.NoError(t, err)
+	assert.Equal(t, "alice", me.UserName)
+
+	channel, err := client.GetChannelInfo(&models.Channel{Name: "news"})
+	require.NoError(t, err)
+	assert.Equal(t, general.ID, channel.ID)
+
+	_, err = client.PostMessage(&models.PostMessage{Channel: "#news", Text: "hello"})
+	require.NoError(t, err)
+	server.AddMessage(general.ID, "alice", "world")
+
+	messages, err := client.GetMessages(channel, &models.Pagination{Count: 10})
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+	assert.Equal(t, "world", messages[0].Msg, "history is newest first")
+	assert.Equal(t, "hello", messages[1].Msg)
+	assert.Equal(t, "alice", messages[1].User.UserName)
+
+	_, err = client.GetChannelInfo(&models.Channel{Name: "unknown"})
+	assert.Error(t, err)
+
+	client = rest.NewClient(server.URL(), false)
+	_, err = client.Login(&models.UserCredentials{Username: "alice", Password: "wrong"})
+	assert.Error(t, err)
+}
+
+func TestServer_Realtime(t *testing.T) {
+	server := New()
+	defer server.Close()
+
+	server.AddUser("bot", "pass", "bot")
+	server.AddUser("alice", "secret")
+	general := server.Room(GeneralRoomID)
+
+	client, err := realtime.NewClient(server.URL(), false)
+	require.NoError(t, err)
+	defer client.Close()
+
+	user, err := client.Login(&models.UserCredentials{Email: "bot@example.com", Password: "pass"})
+	require.NoError(t, err)
+	assert.NotEmpty(t, user.Token)
+
+	version, err := client.ServerVersion()
+	require.NoError(t, err)
+	assert.Equal(t, Version, version.String())
+	assert.True(t, client.Supports(models.CapabilityTeams))
+	assert.False(t, client.Supports(models.CapabilityRoleIDs))
+
+	rooms, err := client.GetChannelsIn()
+	require.NoError(t, err)
+	require.Len(t, rooms, 1)
+	assert.Equal(t, "general", rooms[0].Name)
+
+	subscriptions, err := client.GetChannelSubscriptions()
+	require.NoError(t, err)
+	require.Len(t, subscriptions, 1)
+	assert.Equal(t, general.ID, subscriptions[0].RoomID)
+	assert.Equal(t, "c", subscriptions[0].Type)
+	require.NotNil(t, subscriptions[0].LastSeen)
+	assert.True(t, subscriptions[0].LastSeen.Equal(*general.Timestamp))
+
+	messages := make(chan models.Message, 10)
+	require.NoError(t, client.SubscribeToMessageStream(general, messages))
+
+	server.AddMessage(general.ID, "alice", "hi bot")
+	select {
+	case message := <-messages:
+		assert.Equal(t, "hi bot", message.Msg)
+		assert.Equal(t, "alice", message.User.UserName)
+		require.NotNil(t, message.Timestamp)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message from the stream")
+	}
+
+	sent, err := client.SendMessage(client.NewMessage(general, "hello alice"))
+	require.NoError(t, err)
+	assert.Equal(t, "hello alice", sent.Msg)
+	assert.Len(t, server.Messages(general.ID), 2)
+
+	history, err := client.LoadHistory(general.ID)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, "hello alice", history[0].Msg)
+}
+
+func TestServer_RegisterUser(t *testing.T) {
+	server := New()
+	defer server.Close()
+
+	client, err := realtime.NewClient(server.URL(), false)
+	require.NoError(t, err)
+	defer client.Close()
+
+	_, err = client.RegisterUser(&models.UserCredentials{Email: "new@example.com", Name: "newuser", Password: "pass"})
+	require.NoError(t, err)
+
+	general := server.Room(GeneralRoomID)
+	require.NotNil(t, general)
+	assert.Equal(t, []string{"newuser"}, general.Users)
+
+	restClient := rest.NewClient(server.URL(), false)
+	me, err := restClient.Login(&models.UserCredentials{Username: "newuser", Password: "pass"})
+	require.NoError(t, err)
+	assert.Equal(t, "newuser", me.UserName)
+}
+
+func TestServer_CustomMethod(t *testing.T) {
+	server := New()
+	defer server.Close()
+
+	server.AddUser("bot", "pass")
+	server.HandleMethod("getRoomIdByNameOrId", func(userID string, params []interface{}) (interface{}, error) {
+		return "custom-" + params[0].(string), nil
+	})
+
+	client, err := realtime.NewClient(server.URL(), false)
+	require.NoError(t, err)
+	defer client.Close()
+
+	_, err = client.Login(&models.UserCredentials{Username: "bot", Password: "pass"})
+	require.NoError(t, err)
+
+	id, err := client.GetChannelID("general")
+	require.NoError(t, err)
+	assert.Equal(t, "custom-general", id)
+}
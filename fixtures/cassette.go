@@ -0,0 +1,159 @@
+// Package fixtures records the traffic between the clients and a real server into golden files
+// and replays it in tests, so tests run against real responses of several Rocket.Chat versions
+// without a server. Tokens, passwords and IDs are scrubbed before the files are written.
+//
+// Record once against a server:
+//
+//	recorder := fixtures.NewRecorder(serverURL)
+//	client := rest.NewClient(recorder.URL(), false)
+//	... use the client ...
+//	recorder.Close()
+//	err := recorder.Save("testdata/channels-3.18.json")
+//
+// Replay in the test:
+//
+//	replayer, err := fixtures.LoadReplayer("testdata/channels-3.18.json")
+//	defer replayer.Close()
+//	client := rest.NewClient(replayer.URL(), false)
+package fixtures
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/url"
+	"unicode/utf8"
+)
+
+// Cassette is the recorded traffic, the content of a golden file.
+type Cassette struct {
+	// Server is the version of the recorded server, if known.
+	Server string `json:"server,omitempty"`
+	// HTTP are the REST requests in the order they were made.
+	HTTP []Interaction `json:"http,omitempty"`
+	// DDP are the websocket connections in the order they were opened.
+	DDP []Session `json:"ddp,omitempty"`
+}
+
+// Interaction is a REST request with its response. Bodies are kept as JSON if they are JSON,
+// otherwise as a JSON string. Bodies which aren't UTF-8, e.g. images, are base64 strings.
+type Interaction struct {
+	Method      string          `json:"method"`
+	Path        string          `json:"path"`
+	Query       string          `json:"query,omitempty"`
+	ContentType string          `json:"contentType,omitempty"`
+	Body        json.RawMessage `json:"body,omitempty"`
+	BodyBase64  bool            `json:"bodyBase64,omitempty"`
+
+	Status         int             `json:"status"`
+	ResponseType   string          `json:"responseType,omitempty"`
+	Response       json.RawMessage `json:"response,omitempty"`
+	ResponseBase64 bool            `json:"responseBase64,omitempty"`
+}
+
+// Session is a DDP websocket connection.
+type Session struct {
+	Frames []Frame `json:"frames"`
+}
+
+// Frame is a DDP message, either sent by the client or received from the server.
+type Frame struct {
+	Send json.RawMessage `json:"send,omitempty"`
+	Recv json.RawMessage `json:"recv,omitempty"`
+}
+
+// Load reads a cassette from a golden file.
+func Load(path string) (*Cassette, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading cassette: %w", err)
+	}
+
+	cassette := new(Cassette)
+	if err := json.Unmarshal(data, cassette); err != nil {
+		return nil, fmt.Errorf("parsing cassette %s: %w", path, err)
+	}
+	return cassette, nil
+}
+
+// Save writes the cassette as indented JSON.
+func (c *Cassette) Save(path string) error {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(c); err != nil {
+		return fmt.Errorf("encoding cassette: %w", err)
+	}
+
+	if err := ioutil.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("writing cassette: %w", err)
+	}
+	return nil
+}
+
+// encodeBody keeps JSON bodies as they are and stores others as string. Bodies which aren't
+// UTF-8 would be changed by a JSON string, they are stored base64 encoded, which is reported.
+func encodeBody(body []byte) (json.RawMessage, bool) {
+	if len(body) == 0 {
+		return nil, false
+	}
+	if !utf8.Valid(body) {
+		encoded, _ := json.Marshal(base64.StdEncoding.EncodeToString(body))
+		return encoded, true
+	}
+	if json.Valid(body) {
+		return append(json.RawMessage{}, body...), false
+	}
+	encoded, _ := json.Marshal(string(body))
+	return encoded, false
+}
+
+// encodeText stores a text body, which is always UTF-8, e.g. a DDP message.
+func encodeText(body string) json.RawMessage {
+	encoded, _ := encodeBody([]byte(body))
+	return encoded
+}
+
+// decodeBody returns the body as it was sent.
+func decodeBody(body json.RawMessage, isBase64 bool) []byte {
+	var text string
+	if len(body) > 0 && body[0] == '"' && json.Unmarshal(body, &text) == nil {
+		if isBase64 {
+			if decoded, err := base64.StdEncoding.DecodeString(text); err == nil {
+				return decoded
+			}
+		}
+		return []byte(text)
+	}
+	return body
+}
+
+// bodyKind tells how a body of the content type is compared and scrubbed.
+type bodyKind int
+
+const (
+	bodyOther bodyKind = iota
+	bodyJSON
+	bodyForm
+)
+
+func kindOf(contentType string, body json.RawMessage) bodyKind {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case len(body) > 0 && body[0] != '"':
+		return bodyJSON
+	case mediaType == "application/x-www-form-urlencoded":
+		return bodyForm
+	}
+	return bodyOther
+}
+
+// formBody parses a form body which is stored as JSON string.
+func formBody(body json.RawMessage) (url.Values, bool) {
+	values, err := url.ParseQuery(string(decodeBody(body, false)))
+	return values, err == nil
+}
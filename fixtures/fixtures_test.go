@@ -0,0 +1,198 @@
+package fixtures
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yazver/Rocket.Chat.Go.SDK/fakeserver"
+	"github.com/yazver/Rocket.Chat.Go.SDK/models"
+	"github.com/yazver/Rocket.Chat.Go.SDK/realtime"
+	"github.com/yazver/Rocket.Chat.Go.SDK/rest"
+)
+
+// session uses both clients like a small bot and returns what it saw.
+func session(t *testing.T, serverURL *url.URL) []string {
+	var seen []string
+
+	client := rest.NewClient(serverURL, false)
+	me, err := client.Login(&models.UserCredentials{Username: "bot", Password: "secret-password"})
+	require.NoError(t, err)
+	seen = append(seen, me.UserName)
+
+	channel, err := client.GetChannelInfo(&models.Channel{Name: "general"})
+	require.NoError(t, err)
+	_, err = client.PostMessage(&models.PostMessage{RoomID: channel.ID, Text: "hello from rest"})
+	require.NoError(t, err)
+
+	realtimeClient, err := realtime.NewClient(serverURL, false)
+	require.NoError(t, err)
+	defer realtimeClient.Close()
+
+	_, err = realtimeClient.Login(&models.UserCredentials{Username: "bot", Password: "secret-password"})
+	require.NoError(t, err)
+	_, err = realtimeClient.SendMessage(realtimeClient.NewMessage(channel, "hello from realtime"))
+	require.NoError(t, err)
+
+	history, err := realtimeClient.LoadHistory(channel.ID)
+	require.NoError(t, err)
+	for _, message := range history {
+		seen = append(seen, message.Msg)
+	}
+	return seen
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	server := fakeserver.New()
+	defer server.Close()
+	server.AddUser("bot", "secret-password", "bot")
+
+	recorder := NewRecorder(server.URL())
+	recorded := session(t, recorder.URL())
+	recorder.Close()
+
+	path := filepath.Join(t.TempDir(), "session.json")
+	require.NoError(t, recorder.Save(path))
+
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "secret-password")
+	assert.NotContains(t, string(data), "fake00", "IDs are scrubbed")
+	assert.NotContains(t, string(data), "token-", "tokens are scrubbed")
+
+	cassette, err := Load(path)
+	require.NoError(t, err)
+	assert.Len(t, cassette.DDP, 1)
+	assert.NotEmpty(t, cassette.HTTP)
+
+	replayer := NewReplayer(cassette)
+	defer replayer.Close()
+
+	replayed := session(t, replayer.URL())
+	assert.Equal(t, recorded, replayed)
+	assert.Empty(t, replayer.Unmatched())
+}
+
+func TestReplayer_Unmatched(t *testing.T) {
+	replayer := NewReplayer(&Cassette{})
+	defer replayer.Close()
+
+	client := rest.NewClient(replayer.URL(), false)
+	_, err := client.GetServerInfo()
+	assert.Error(t, err)
+	assert.Equal(t, []string{"GET /api/info"}, replayer.Unmatched())
+}
+
+func TestScrubber_Scrub(t *testing.T) {
+	cassette := &Cassette{
+		HTTP: []Interaction{{
+			Method:   "GET",
+			Path:     "/api/v1/rooms.upload/ROOM1234567",
+			Query:    "roomId=ROOM1234567&count=10",
+			Status:   200,
+			Response: []byte(`{"room":{"_id":"ROOM1234567","name":"general"},"authToken":"abcdefgh","text":"see ROOM1234567"}`),
+		}},
+		DDP: []Session{{Frames: []Frame{
+			{Send: []byte(`{"msg":"method","id":"1","method":"login","params":[{"resume":"abcdefgh"}]}`)},
+			{Recv: []byte(`{"msg":"result","id":"1","result":{"id":"USER1234567","token":"abcdefgh"}}`)},
+		}}},
+	}
+
+	scrubbed := DefaultScrubber.Scrub(cassette)
+
+	interaction := scrubbed.HTTP[0]
+	assert.Equal(t, "/api/v1/rooms.upload/id-1", interaction.Path)
+	assert.Equal(t, "count=10&roomId=id-1", interaction.Query)
+	assert.JSONEq(t, `{"room":{"_id":"id-1","name":"general"},"authToken":"secret-1","text":"see id-1"}`, string(interaction.Response))
+
+	frames := scrubbed.DDP[0].Frames
+	assert.JSONEq(t, `{"msg":"method","id":"id-2","method":"login","params":[{"resume":"secret-1"}]}`, string(frames[0].Send))
+	assert.JSONEq(t, `{"msg":"result","id":"id-2","result":{"id":"id-3","token":"secret-1"}}`, string(frames[1].Recv))
+
+	assert.Contains(t, string(cassette.HTTP[0].Response), "ROOM1234567", "the cassette isn't changed")
+}
+
+func TestRecorder_ScrubsCredentials(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/login":
+			fmt.Fprint(w, `{"status":"success","data":{"userId":"USER1234567","authToken":"login-token-123"}}`)
+		case "/api/v1/me":
+			fmt.Fprint(w, `{"success":true,"_id":"USER1234567","username":"bob"}`)
+		default:
+			fmt.Fprint(w, `{"success":true,"user":{"_id":"USER1234567","username":"bob"}}`)
+		}
+	}))
+	defer server.Close()
+	serverURL, _ := url.Parse(server.URL)
+
+	recorder := NewRecorder(serverURL)
+	client := rest.NewClient(recorder.URL(), false)
+	_, err := client.Login(&models.UserCredentials{Username: "bob", Password: "ldap-password-1", LDAP: true})
+	require.NoError(t, err)
+	_, err = client.UpdateOwnBasicInfo(&models.UpdateOwnBasicInfoRequest{
+		CurrentPassword: "current-password-2",
+		NewPassword:     "new-password-3",
+	})
+	require.NoError(t, err)
+	recorder.Close()
+
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	require.NoError(t, recorder.Save(path))
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+
+	digest := sha256.Sum256([]byte("current-password-2"))
+	for _, secret := range []string{"ldap-password-1", hex.EncodeToString(digest[:]), "new-password-3", "login-token-123"} {
+		assert.NotContains(t, string(data), secret)
+	}
+	assert.Contains(t, string(data), `"ldapPass"`, "the login has to be recorded")
+}
+
+// binarySession uploads and downloads a file which isn't UTF-8.
+func binarySession(t *testing.T, serverURL *url.URL, content []byte) []byte {
+	client := rest.NewClient(serverURL, false)
+	_, err := client.Login(&models.UserCredentials{Username: "bot", Password: "secret-password"})
+	require.NoError(t, err)
+
+	channel, err := client.GetChannelInfo(&models.Channel{Name: "general"})
+	require.NoError(t, err)
+	message, err := client.UploadFile(channel.ID, "image.png", bytes.NewReader(content), "")
+	require.NoError(t, err)
+	require.Len(t, message.Attachments, 1)
+
+	var downloaded bytes.Buffer
+	require.NoError(t, client.DownloadFile(message.Attachments[0].TitleLink, &downloaded))
+	return downloaded.Bytes()
+}
+
+func TestRecordAndReplay_Binary(t *testing.T) {
+	server := fakeserver.New()
+	defer server.Close()
+	server.AddUser("bot", "secret-password", "bot")
+	content := []byte{0x89, 'P', 'N', 'G', 0xff, 0xfe, 0x00, 0x80}
+
+	recorder := NewRecorder(server.URL())
+	assert.Equal(t, content, binarySession(t, recorder.URL(), content))
+	recorder.Close()
+
+	path := filepath.Join(t.TempDir(), "binary.json")
+	require.NoError(t, recorder.Save(path))
+	cassette, err := Load(path)
+	require.NoError(t, err)
+
+	replayer := NewReplayer(cassette)
+	defer replayer.Close()
+	assert.Equal(t, content, binarySession(t, replayer.URL(), content), "the body was changed by the cassette")
+	assert.Empty(t, replayer.Unmatched())
+}
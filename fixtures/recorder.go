@@ -0,0 +1,205 @@
+package fixtures
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/websocket"
+)
+
+// Recorder is a proxy to a server which records the traffic of the clients connected to its URL.
+type Recorder struct {
+	// Scrubber scrubs the recorded traffic before it's returned by Cassette or saved.
+	Scrubber Scrubber
+
+	target *url.URL
+	server *httptest.Server
+
+	mu       sync.Mutex
+	cassette Cassette
+}
+
+// NewRecorder starts a proxy to the server. It has to be closed after use.
+func NewRecorder(target *url.URL) *Recorder {
+	r := &Recorder{Scrubber: DefaultScrubber, target: target}
+
+	mux := http.NewServeMux()
+	mux.Handle("/websocket", websocket.Handler(r.serveDDP))
+	mux.HandleFunc("/", r.serveHTTP)
+	r.server = httptest.NewServer(mux)
+	return r
+}
+
+// URL is the address of the proxy, to be used by the clients instead of the server address.
+func (r *Recorder) URL() *url.URL {
+	u, _ := url.Parse(r.server.URL)
+	return u
+}
+
+// Close stops the proxy. Websocket connections should be closed before.
+func (r *Recorder) Close() {
+	r.server.CloseClientConnections()
+	r.server.Close()
+}
+
+// Cassette returns the scrubbed traffic recorded so far.
+func (r *Recorder) Cassette() *Cassette {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.Scrubber.Scrub(&r.cassette)
+}
+
+// Save writes the scrubbed traffic recorded so far to a golden file.
+func (r *Recorder) Save(path string) error {
+	return r.Cassette().Save(path)
+}
+
+func (r *Recorder) serveHTTP(w http.ResponseWriter, req *http.Request) {
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	target := *r.target
+	target.Path = strings.TrimSuffix(r.target.Path, "/") + req.URL.Path
+	target.RawQuery = req.URL.RawQuery
+
+	forward, err := http.NewRequest(req.Method, target.String(), bytes.NewReader(body))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	forward.Header = req.Header.Clone()
+	// The recorded responses shouldn't be compressed.
+	forward.Header.Del("Accept-Encoding")
+
+	resp, err := http.DefaultTransport.RoundTrip(forward)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+
+	response, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	encodedBody, bodyBase64 := encodeBody(body)
+	encodedResponse, responseBase64 := encodeBody(response)
+	r.mu.Lock()
+	r.cassette.HTTP = append(r.cassette.HTTP, Interaction{
+		Method:         req.Method,
+		Path:           req.URL.Path,
+		Query:          req.URL.RawQuery,
+		ContentType:    req.Header.Get("Content-Type"),
+		Body:           encodedBody,
+		BodyBase64:     bodyBase64,
+		Status:         resp.StatusCode,
+		ResponseType:   resp.Header.Get("Content-Type"),
+		Response:       encodedResponse,
+		ResponseBase64: responseBase64,
+	})
+	if strings.HasSuffix(req.URL.Path, "/api/info") {
+		r.cassette.Server = serverVersion(response)
+	}
+	r.mu.Unlock()
+
+	for key, values := range resp.Header {
+		switch key {
+		case "Content-Length", "Content-Encoding", "Transfer-Encoding":
+			continue
+		}
+		w.Header()[key] = values
+	}
+	w.WriteHeader(resp.StatusCode)
+	_, _ = w.Write(response)
+}
+
+func serverVersion(info []byte) string {
+	var response struct {
+		Version string `json:"version"`
+		Info    struct {
+			Version string `json:"version"`
+		} `json:"info"`
+	}
+	if json.Unmarshal(info, &response) != nil {
+		return ""
+	}
+	if response.Info.Version != "" {
+		return response.Info.Version
+	}
+	return response.Version
+}
+
+func (r *Recorder) serveDDP(client *websocket.Conn) {
+	defer client.Close()
+
+	wsURL := *r.target
+	wsURL.Scheme = "ws"
+	if r.target.Scheme == "https" {
+		wsURL.Scheme = "wss"
+	}
+	wsURL.Path = strings.TrimSuffix(r.target.Path, "/") + "/websocket"
+
+	server, err := websocket.Dial(wsURL.String(), "", r.target.String())
+	if err != nil {
+		return
+	}
+	defer server.Close()
+
+	r.mu.Lock()
+	r.cassette.DDP = append(r.cassette.DDP, Session{})
+	session := len(r.cassette.DDP) - 1
+	r.mu.Unlock()
+
+	done := make(chan struct{}, 2)
+	go r.pipe(client, server, session, true, done)
+	go r.pipe(server, client, session, false, done)
+	<-done
+}
+
+// pipe forwards the messages of a websocket until it's closed and records them.
+func (r *Recorder) pipe(from, to *websocket.Conn, session int, sent bool, done chan<- struct{}) {
+	defer func() { done <- struct{}{} }()
+
+	for {
+		var msg string
+		if err := websocket.Message.Receive(from, &msg); err != nil {
+			return
+		}
+		if err := websocket.Message.Send(to, msg); err != nil {
+			return
+		}
+		if isHeartbeat(msg) {
+			continue
+		}
+
+		frame := Frame{Recv: encodeText(msg)}
+		if sent {
+			frame = Frame{Send: encodeText(msg)}
+		}
+		r.mu.Lock()
+		r.cassette.DDP[session].Frames = append(r.cassette.DDP[session].Frames, frame)
+		r.mu.Unlock()
+	}
+}
+
+// isHeartbeat tells if the message is a ping or pong, they depend on timing and aren't recorded.
+func isHeartbeat(msg string) bool {
+	var message struct {
+		Msg string `json:"msg"`
+	}
+	if json.Unmarshal([]byte(msg), &message) != nil {
+		return false
+	}
+	return message.Msg == "ping" || message.Msg == "pong"
+}
@@ -0,0 +1,393 @@
+package fixtures
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+
+	"golang.org/x/net/websocket"
+)
+
+// Replayer is a server which answers with the responses of a cassette.
+//
+// A request gets the response of the first unused recorded request which equals it, secrets
+// aside. If there is none, IDs are ignored too, e.g. the random ID of a sent message.
+// Requests repeated more often than recorded get the last matching response again.
+// DDP responses are sent when their request arrives, messages which the server sent on its
+// own follow the response to the preceding client message.
+type Replayer struct {
+	// Scrubber tells which values are ignored when requests are compared. It has to have the
+	// keys of the scrubber used for recording.
+	Scrubber Scrubber
+
+	server *httptest.Server
+
+	mu          sync.Mutex
+	cassette    *Cassette
+	usedHTTP    []bool
+	nextSession int
+	unmatched   []string
+}
+
+// NewReplayer starts a server replaying the cassette. It has to be closed after use.
+func NewReplayer(cassette *Cassette) *Replayer {
+	r := &Replayer{
+		Scrubber: DefaultScrubber,
+		cassette: cassette,
+		usedHTTP: make([]bool, len(cassette.HTTP)),
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/websocket", websocket.Handler(r.serveDDP))
+	mux.HandleFunc("/", r.serveHTTP)
+	r.server = httptest.NewServer(mux)
+	return r
+}
+
+// LoadReplayer starts a server replaying the golden file.
+func LoadReplayer(path string) (*Replayer, error) {
+	cassette, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewReplayer(cassette), nil
+}
+
+// URL is the address of the server, to be used with rest.NewClient and realtime.NewClient.
+func (r *Replayer) URL() *url.URL {
+	u, _ := url.Parse(r.server.URL)
+	return u
+}
+
+// Close stops the server.
+func (r *Replayer) Close() {
+	r.server.CloseClientConnections()
+	r.server.Close()
+}
+
+// Unmatched returns the requests which weren't recorded, a test can check it's empty.
+func (r *Replayer) Unmatched() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string{}, r.unmatched...)
+}
+
+func (r *Replayer) miss(request string) {
+	r.mu.Lock()
+	r.unmatched = append(r.unmatched, request)
+	r.mu.Unlock()
+}
+
+// matcher masks the values of the keys which are ignored by a comparison.
+type matcher map[string]bool
+
+func (m matcher) mask(key, value string) string {
+	if m[key] {
+		return "*"
+	}
+	return value
+}
+
+func (m matcher) sameHTTP(recorded, request Interaction) bool {
+	if recorded.Method != request.Method || recorded.Path != request.Path {
+		return false
+	}
+	if visitQuery(recorded.Query, m.mask) != visitQuery(request.Query, m.mask) {
+		return false
+	}
+	if recorded.BodyBase64 || request.BodyBase64 {
+		return true
+	}
+
+	switch kindOf(request.ContentType, request.Body) {
+	case bodyJSON, bodyForm:
+		recordedBody := visitBody(recorded.ContentType, recorded.Body, m.mask, identity)
+		requestBody := visitBody(request.ContentType, request.Body, m.mask, identity)
+		return string(recordedBody) == string(requestBody)
+	}
+	// Other bodies, e.g. multipart uploads, have random parts and aren't compared.
+	return true
+}
+
+func (m matcher) sameDDP(recorded, request json.RawMessage) bool {
+	return string(visitJSON(recorded, m.mask)) == string(visitJSON(request, m.mask))
+}
+
+func identity(value string) string {
+	return value
+}
+
+// matchers returns the strict matcher, which ignores secrets and DDP message IDs, and the loose
+// one, which ignores all IDs too.
+func (r *Replayer) matchers() []matcher {
+	strict := setOf(append([]string{"id"}, r.Scrubber.Secrets...))
+	loose := setOf(append(r.Scrubber.IDs, r.Scrubber.Secrets...))
+	return []matcher{strict, loose}
+}
+
+func (r *Replayer) serveHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := ioutil.ReadAll(req.Body)
+	encodedBody, bodyBase64 := encodeBody(body)
+	request := Interaction{
+		Method:      req.Method,
+		Path:        req.URL.Path,
+		Query:       req.URL.RawQuery,
+		ContentType: req.Header.Get("Content-Type"),
+		Body:        encodedBody,
+		BodyBase64:  bodyBase64,
+	}
+
+	interaction := r.matchHTTP(request)
+	if interaction == nil {
+		description := fmt.Sprintf("%s %s", req.Method, req.URL.RequestURI())
+		r.miss(description)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   "no recorded response for " + description,
+		})
+		return
+	}
+
+	if interaction.ResponseType != "" {
+		w.Header().Set("Content-Type", interaction.ResponseType)
+	}
+	w.WriteHeader(interaction.Status)
+	_, _ = w.Write(decodeBody(interaction.Response, interaction.ResponseBase64))
+}
+
+func (r *Replayer) matchHTTP(request Interaction) *Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, m := range r.matchers() {
+		for i, recorded := range r.cassette.HTTP {
+			if !r.usedHTTP[i] && m.sameHTTP(recorded, request) {
+				r.usedHTTP[i] = true
+				return &r.cassette.HTTP[i]
+			}
+		}
+	}
+
+	for _, m := range r.matchers() {
+		for i := len(r.cassette.HTTP) - 1; i >= 0; i-- {
+			if m.sameHTTP(r.cassette.HTTP[i], request) {
+				return &r.cassette.HTTP[i]
+			}
+		}
+	}
+	return nil
+}
+
+// ddpMessage is the part of a DDP message the replayer looks at.
+type ddpMessage struct {
+	Msg  string `json:"msg"`
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Method is the name of a called method.
+	Method string `json:"method"`
+}
+
+func (r *Replayer) serveDDP(ws *websocket.Conn) {
+	defer ws.Close()
+
+	r.mu.Lock()
+	var frames []Frame
+	if r.nextSession < len(r.cassette.DDP) {
+		frames = r.cassette.DDP[r.nextSession].Frames
+	}
+	r.nextSession++
+	r.mu.Unlock()
+
+	answers := answersOf(frames)
+	used := make([]bool, len(frames))
+	ids := map[string]string{}
+	send := func(sent int) error {
+		for _, i := range answers[sent] {
+			if err := websocket.Message.Send(ws, string(replaceIDs(frames[i].Recv, ids))); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// The server may send messages before the client does, e.g. its ID.
+	if err := send(-1); err != nil {
+		return
+	}
+
+	for {
+		var raw []byte
+		if err := websocket.Message.Receive(ws, &raw); err != nil {
+			return
+		}
+
+		var msg ddpMessage
+		if err := json.Unmarshal(raw, &msg); err != nil {
+			continue
+		}
+
+		switch msg.Msg {
+		case "ping":
+			pong := map[string]interface{}{"msg": "pong"}
+			if msg.ID != "" {
+				pong["id"] = msg.ID
+			}
+			_ = websocket.JSON.Send(ws, pong)
+			continue
+		case "pong":
+			continue
+		}
+
+		index := r.matchDDP(frames, used, raw)
+		if index < 0 {
+			r.miss(describeDDP(msg))
+			if err := websocket.JSON.Send(ws, unmatchedDDP(msg)); err != nil {
+				return
+			}
+			continue
+		}
+
+		var recorded ddpMessage
+		_ = json.Unmarshal(frames[index].Send, &recorded)
+		if recorded.ID != "" {
+			ids[recorded.ID] = msg.ID
+		}
+		if err := send(index); err != nil {
+			return
+		}
+	}
+}
+
+func (r *Replayer) matchDDP(frames []Frame, used []bool, request json.RawMessage) int {
+	for _, m := range r.matchers() {
+		for i, frame := range frames {
+			if frame.Send != nil && !used[i] && m.sameDDP(frame.Send, request) {
+				used[i] = true
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// answersOf assigns the received frames to the index of the sent frame they answer. Results and
+// ready or updated messages belong to the message with their ID, connected to connect and
+// others to the preceding sent frame. Frames received before the first sent one are at -1.
+func answersOf(frames []Frame) map[int][]int {
+	sent := map[string]int{}
+	connect := -1
+	for i, frame := range frames {
+		var msg ddpMessage
+		if frame.Send != nil && json.Unmarshal(frame.Send, &msg) == nil {
+			if msg.ID != "" {
+				sent[msg.ID] = i
+			}
+			if msg.Msg == "connect" {
+				connect = i
+			}
+		}
+	}
+
+	answers := map[int][]int{}
+	last := -1
+	for i, frame := range frames {
+		if frame.Send != nil {
+			last = i
+			continue
+		}
+
+		var msg struct {
+			ddpMessage
+			Subs    []string `json:"subs"`
+			Methods []string `json:"methods"`
+		}
+		_ = json.Unmarshal(frame.Recv, &msg)
+
+		answered := last
+		switch msg.Msg {
+		case "connected", "failed":
+			if connect >= 0 {
+				answered = connect
+			}
+		case "result", "nosub":
+			if index, ok := sent[msg.ID]; ok {
+				answered = index
+			}
+		case "ready", "updated":
+			found := -1
+			for _, id := range append(msg.Subs, msg.Methods...) {
+				if index, ok := sent[id]; ok && index > found {
+					found = index
+				}
+			}
+			if found >= 0 {
+				answered = found
+			}
+		}
+		answers[answered] = append(answers[answered], i)
+	}
+	return answers
+}
+
+// replaceIDs gives a response the IDs of the replayed requests instead of the recorded ones.
+func replaceIDs(frame json.RawMessage, ids map[string]string) json.RawMessage {
+	var msg map[string]interface{}
+	if len(ids) == 0 || json.Unmarshal(frame, &msg) != nil {
+		return frame
+	}
+
+	changed := false
+	if id, ok := msg["id"].(string); ok && ids[id] != "" {
+		msg["id"] = ids[id]
+		changed = true
+	}
+	for _, key := range []string{"subs", "methods"} {
+		list, _ := msg[key].([]interface{})
+		for i, value := range list {
+			if id, ok := value.(string); ok && ids[id] != "" {
+				list[i] = ids[id]
+				changed = true
+			}
+		}
+	}
+	if !changed {
+		return frame
+	}
+
+	replaced, err := json.Marshal(msg)
+	if err != nil {
+		return frame
+	}
+	return replaced
+}
+
+func describeDDP(msg ddpMessage) string {
+	switch msg.Msg {
+	case "method":
+		return "DDP method " + msg.Method
+	case "sub":
+		return "DDP sub " + msg.Name
+	}
+	return "DDP " + msg.Msg
+}
+
+// unmatchedDDP is the error response to a message which wasn't recorded.
+func unmatchedDDP(msg ddpMessage) map[string]interface{} {
+	reason := "no recorded response for " + describeDDP(msg)
+	err := map[string]interface{}{"error": 404, "reason": reason, "message": reason + " [404]"}
+
+	switch msg.Msg {
+	case "method":
+		return map[string]interface{}{"msg": "result", "id": msg.ID, "error": err}
+	case "sub":
+		return map[string]interface{}{"msg": "nosub", "id": msg.ID, "error": err}
+	}
+	return map[string]interface{}{"msg": "error", "reason": reason}
+}
@@ -0,0 +1,228 @@
+package fixtures
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// minReplaceLength is the length from which a scrubbed value is also replaced where it's part of
+// another string, e.g. an ID in a path. Shorter values like DDP method IDs are only replaced
+// as values of their keys.
+const minReplaceLength = 6
+
+// Scrubber replaces secrets and IDs by placeholders. The same value always gets the same
+// placeholder, so an ID of a response still matches the later requests which use it.
+type Scrubber struct {
+	// Secrets are the keys of JSON objects, queries and forms whose values are secret.
+	Secrets []string
+	// IDs are the keys whose values are IDs.
+	IDs []string
+}
+
+// DefaultScrubber scrubs the tokens, passwords and IDs used by the REST and the realtime API,
+// including the LDAP, OAuth and SAML logins, password changes and two-factor codes. The
+// recorder doesn't keep headers, so the X-Auth-Token and X-2fa-Code headers aren't stored.
+var DefaultScrubber = Scrubber{
+	Secrets: []string{
+		"authToken", "token", "resume", "digest", "password", "pass", "hashedToken",
+		"ldapPass", "accessToken", "accessTokenSecret", "secret", "credentialToken",
+		"currentPassword", "newPassword", "code",
+	},
+	IDs: []string{"_id", "id", "rid", "userId", "roomId", "msgId", "tmid", "subs", "methods", "session"},
+}
+
+// Scrub returns a copy of the cassette with the secrets and IDs replaced.
+func (s Scrubber) Scrub(cassette *Cassette) *Cassette {
+	state := &scrubState{
+		secrets:      setOf(s.Secrets),
+		ids:          setOf(s.IDs),
+		placeholders: map[string]string{},
+	}
+
+	scrubbed := &Cassette{Server: cassette.Server, HTTP: append([]Interaction{}, cassette.HTTP...)}
+	for _, session := range cassette.DDP {
+		scrubbed.DDP = append(scrubbed.DDP, Session{Frames: append([]Frame{}, session.Frames...)})
+	}
+
+	state.visit(scrubbed, state.learn)
+	state.replacer = state.newReplacer()
+	state.visit(scrubbed, state.replace)
+	return scrubbed
+}
+
+type scrubState struct {
+	secrets, ids map[string]bool
+	placeholders map[string]string
+	nextSecret   int
+	nextID       int
+	replacer     *strings.Replacer
+}
+
+// visit calls fn for every keyed string of the cassette, in a stable order, and stores the results.
+func (s *scrubState) visit(cassette *Cassette, fn func(key, value string) string) {
+	for i := range cassette.HTTP {
+		interaction := &cassette.HTTP[i]
+		interaction.Path = s.text(interaction.Path)
+		interaction.Query = visitQuery(interaction.Query, fn)
+		// Binary bodies can't be scrubbed as text.
+		if !interaction.BodyBase64 {
+			interaction.Body = visitBody(interaction.ContentType, interaction.Body, fn, s.text)
+		}
+		if !interaction.ResponseBase64 {
+			interaction.Response = visitBody(interaction.ResponseType, interaction.Response, fn, s.text)
+		}
+	}
+	for _, session := range cassette.DDP {
+		for i := range session.Frames {
+			frame := &session.Frames[i]
+			frame.Send = visitJSON(frame.Send, fn)
+			frame.Recv = visitJSON(frame.Recv, fn)
+		}
+	}
+}
+
+// text replaces the known values in a string which isn't structured, once they are learned.
+func (s *scrubState) text(value string) string {
+	if s.replacer == nil {
+		return value
+	}
+	return s.replacer.Replace(value)
+}
+
+func (s *scrubState) learn(key, value string) string {
+	if value == "" || s.placeholders[value] != "" {
+		return value
+	}
+	switch {
+	case s.secrets[key]:
+		s.nextSecret++
+		s.placeholders[value] = fmt.Sprintf("secret-%d", s.nextSecret)
+	case s.ids[key]:
+		s.nextID++
+		s.placeholders[value] = fmt.Sprintf("id-%d", s.nextID)
+	}
+	return value
+}
+
+func (s *scrubState) replace(key, value string) string {
+	if placeholder, ok := s.placeholders[value]; ok && (s.secrets[key] || s.ids[key]) {
+		return placeholder
+	}
+	return s.replacer.Replace(value)
+}
+
+// newReplacer replaces the longer values in any string, the longest first.
+func (s *scrubState) newReplacer() *strings.Replacer {
+	values := make([]string, 0, len(s.placeholders))
+	for value := range s.placeholders {
+		if len(value) >= minReplaceLength {
+			values = append(values, value)
+		}
+	}
+	sort.Slice(values, func(i, j int) bool {
+		if len(values[i]) != len(values[j]) {
+			return len(values[i]) > len(values[j])
+		}
+		return values[i] < values[j]
+	})
+
+	pairs := make([]string, 0, 2*len(values))
+	for _, value := range values {
+		pairs = append(pairs, value, s.placeholders[value])
+	}
+	return strings.NewReplacer(pairs...)
+}
+
+func visitBody(contentType string, body json.RawMessage, fn func(key, value string) string, text func(string) string) json.RawMessage {
+	switch kindOf(contentType, body) {
+	case bodyJSON:
+		return visitJSON(body, fn)
+	case bodyForm:
+		if _, ok := formBody(body); ok {
+			return encodeText(visitQuery(string(decodeBody(body, false)), fn))
+		}
+	}
+	if len(body) == 0 {
+		return body
+	}
+	return encodeText(text(string(decodeBody(body, false))))
+}
+
+func visitQuery(query string, fn func(key, value string) string) string {
+	if query == "" {
+		return query
+	}
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return fn("", query)
+	}
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		for i, value := range values[key] {
+			values[key][i] = fn(key, value)
+		}
+	}
+	return values.Encode()
+}
+
+// visitJSON calls fn for every string of the JSON document with the key of the object holding
+// it, strings in arrays get the key of the array.
+func visitJSON(raw json.RawMessage, fn func(key, value string) string) json.RawMessage {
+	if len(raw) == 0 {
+		return raw
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var document interface{}
+	if err := decoder.Decode(&document); err != nil {
+		return raw
+	}
+
+	document = visitValue("", document, fn)
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(document); err != nil {
+		return raw
+	}
+	return bytes.TrimSpace(buf.Bytes())
+}
+
+func visitValue(key string, value interface{}, fn func(key, value string) string) interface{} {
+	switch v := value.(type) {
+	case string:
+		return fn(key, v)
+	case []interface{}:
+		for i := range v {
+			v[i] = visitValue(key, v[i], fn)
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			v[k] = visitValue(k, v[k], fn)
+		}
+	}
+	return value
+}
+
+func setOf(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		set[value] = true
+	}
+	return set
+}
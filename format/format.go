@@ -0,0 +1,175 @@
+// Package format builds and parses Rocket.Chat markdown message text.
+package format
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/yazver/Rocket.Chat.Go.SDK/models"
+)
+
+// markdownReplacer escapes all characters with a meaning in Rocket.Chat markdown.
+var markdownReplacer = strings.NewReplacer(
+	`\`, `\\`,
+	"*", `\*`,
+	"_", `\_`,
+	"~", `\~`,
+	"`", "\\`",
+	"[", `\[`,
+	"]", `\]`,
+	"(", `\(`,
+	")", `\)`,
+	"#", `\#`,
+	"@", `\@`,
+	":", `\:`,
+	">", `\>`,
+	"|", `\|`,
+)
+
+// urlTrailing are the characters ending a sentence rather than a link, they are escaped.
+const urlTrailing = ".,;:!?*~`|"
+
+// Escape escapes user content so it is shown as is and can't trigger mentions, emoji or formatting.
+// Links are kept as they are, so they still work.
+func Escape(text string) string {
+	var escaped strings.Builder
+	last := 0
+	for _, span := range linkRegexp.FindAllStringIndex(text, -1) {
+		end := span[0] + len(strings.TrimRight(text[span[0]:span[1]], urlTrailing))
+		escaped.WriteString(markdownReplacer.Replace(text[last:span[0]]))
+		escaped.WriteString(text[span[0]:end])
+		last = end
+	}
+	escaped.WriteString(markdownReplacer.Replace(text[last:]))
+	return escaped.String()
+}
+
+// MentionUser mentions a user by username, e.g. @john. Rocket.Chat markdown has no mentions by
+// ID, the server resolves the usernames and sends the IDs in the mentions of the message. Use
+// MentionUserID to mention a user of whom only the ID is known.
+func MentionUser(user *models.User) string {
+	return "@" + user.UserName
+}
+
+// MentionUsername mentions a user by username, e.g. @john.
+func MentionUsername(username string) string {
+	return "@" + strings.TrimPrefix(username, "@")
+}
+
+// UserResolver looks users up, rest.Client is one.
+type UserResolver interface {
+	GetUserInfo(user *models.User) (*models.User, error)
+}
+
+// MentionUserID mentions the user with the ID by looking up the username.
+func MentionUserID(resolver UserResolver, userID string) (string, error) {
+	user, err := resolver.GetUserInfo(&models.User{ID: userID})
+	if err != nil {
+		return "", fmt.Errorf("mention user %s: %w", userID, err)
+	}
+	return MentionUser(user), nil
+}
+
+// MentionAll notifies all members of the room.
+func MentionAll() string {
+	return "@all"
+}
+
+// MentionHere notifies all active members of the room.
+func MentionHere() string {
+	return "@here"
+}
+
+// MentionRoom links a room by its name, e.g. #general. Like user mentions, room links are
+// made by name, use MentionRoomID for a room of which only the ID is known.
+func MentionRoom(channel *models.Channel) string {
+	return "#" + channel.Name
+}
+
+// RoomResolver looks rooms up, rest.Client is one.
+type RoomResolver interface {
+	GetRoomInfo(room *models.Channel) (*models.Channel, error)
+}
+
+// MentionRoomID links the room with the ID by looking up its name. Direct messages have no
+// name and can't be linked.
+func MentionRoomID(resolver RoomResolver, roomID string) (string, error) {
+	room, err := resolver.GetRoomInfo(&models.Channel{ID: roomID})
+	if err != nil {
+		return "", fmt.Errorf("mention room %s: %w", roomID, err)
+	}
+	if room.Name == "" {
+		return "", fmt.Errorf("mention room %s: the room has no name", roomID)
+	}
+	return MentionRoom(room), nil
+}
+
+// Bold makes the text bold.
+func Bold(text string) string {
+	return "*" + text + "*"
+}
+
+// Italic makes the text italic.
+func Italic(text string) string {
+	return "_" + text + "_"
+}
+
+// Strike strikes the text through.
+func Strike(text string) string {
+	return "~" + text + "~"
+}
+
+// Code formats inline code. The fence grows if the code contains backticks.
+func Code(code string) string {
+	fence := strings.Repeat("`", longestRun(code, '`')+1)
+	if strings.HasPrefix(code, "`") || strings.HasSuffix(code, "`") {
+		code = " " + code + " "
+	}
+	return fence + code + fence
+}
+
+// CodeBlock formats a fenced code block with an optional language for highlighting.
+func CodeBlock(language, code string) string {
+	fence := "```"
+	if n := longestRun(code, '`'); n >= len(fence) {
+		fence = strings.Repeat("`", n+1)
+	}
+	return fence + language + "\n" + strings.TrimSuffix(code, "\n") + "\n" + fence
+}
+
+// Quote prefixes every line of the text with a quote marker.
+func Quote(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = "> " + line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Link creates a named link. The text is escaped, an empty text shows the URL only.
+func Link(text, url string) string {
+	if text == "" {
+		return "<" + url + ">"
+	}
+	return fmt.Sprintf("[%s](%s)", Escape(text), strings.NewReplacer("(", "%28", ")", "%29", " ", "%20").Replace(url))
+}
+
+// Emoji formats an emoji shortcode, e.g. :smile:.
+func Emoji(name string) string {
+	return ":" + strings.Trim(name, ":") + ":"
+}
+
+func longestRun(text string, r rune) int {
+	longest, current := 0, 0
+	for _, c := range text {
+		if c == r {
+			current++
+			if current > longest {
+				longest = current
+			}
+		} else {
+			current = 0
+		}
+	}
+	return longest
+}
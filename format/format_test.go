@@ -0,0 +1,80 @@
+package format
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yazver/Rocket.Chat.Go.SDK/models"
+)
+
+func TestFormat(t *testing.T) {
+	assert.Equal(t, `\*not bold\* \@all`, Escape("*not bold* @all"))
+	assert.Equal(t, `see https://x.com/a_b\: \_x\_ \*https://x.com/\*`, Escape("see https://x.com/a_b: _x_ *https://x.com/*"))
+	assert.Equal(t, "@john", MentionUser(&models.User{UserName: "john"}))
+	assert.Equal(t, "#general", MentionRoom(&models.Channel{Name: "general"}))
+	assert.Equal(t, "`x`", Code("x"))
+	assert.Equal(t, "``a`b``", Code("a`b"))
+	assert.Equal(t, "```go\nfmt.Println()\n```", CodeBlock("go", "fmt.Println()\n"))
+	assert.Equal(t, "````\n```\n````", CodeBlock("", "```"))
+	assert.Equal(t, "> a\n> b", Quote("a\nb"))
+	assert.Equal(t, "[a\\_b](http://x/a%20b)", Link("a_b", "http://x/a b"))
+	assert.Equal(t, ":smile:", Emoji(":smile:"))
+}
+
+func TestParse(t *testing.T) {
+	parsed := Parse("hi @john and @jane.doe, see #general and https://rocket.chat. [docs](https://docs.rocket.chat/api) :+1: `@ignored` \\@escaped")
+
+	assert.Equal(t, []string{"john", "jane.doe"}, parsed.Users)
+	assert.Equal(t, []string{"general"}, parsed.Rooms)
+	assert.Equal(t, []string{"https://docs.rocket.chat/api", "https://rocket.chat"}, parsed.Links)
+	assert.Equal(t, []string{"+1"}, parsed.Emoji)
+	assert.True(t, parsed.Mentions("john"))
+	assert.Equal(t, []string{"thumbsup", "smile"}, Parse(":thumbsup::smile: a:b: \\:c: at 10:30:tada:").Emoji)
+	assert.False(t, parsed.Mentions("ignored"))
+
+	assert.True(t, ParseMessage(&models.Message{Msg: "@here now"}).Mentions("anyone"))
+}
+
+func TestParseCommand(t *testing.T) {
+	assert.Equal(t, &Command{Name: "deploy", Args: []string{"app", "prod"}}, ParseCommand("@bot: !deploy app prod", "!", "bot"))
+	assert.Equal(t, &Command{Name: "help", Args: []string{}}, ParseCommand("/help", "/", ""))
+	assert.Nil(t, ParseCommand("just text", "!", "bot"))
+	assert.Nil(t, ParseCommand("!", "!", ""))
+	assert.Nil(t, ParseCommand("deploy app", "", ""), "an empty prefix matches nothing")
+	assert.Nil(t, ParseCommand("@botany !deploy", "!", "bot"), "another user is mentioned")
+	assert.Equal(t, &Command{Name: "help", Args: []string{}}, ParseCommand("@bot !help", "!", "bot"))
+}
+
+type resolver struct{}
+
+func (resolver) GetUserInfo(user *models.User) (*models.User, error) {
+	if user.ID != "u1" {
+		return nil, errors.New("not found")
+	}
+	return &models.User{ID: user.ID, UserName: "john"}, nil
+}
+
+func (resolver) GetRoomInfo(room *models.Channel) (*models.Channel, error) {
+	switch room.ID {
+	case "r1":
+		return &models.Channel{ID: room.ID, Name: "general"}, nil
+	case "d1":
+		return &models.Channel{ID: room.ID, Type: models.RoomTypeDirect}, nil
+	}
+	return nil, errors.New("not found")
+}
+
+func TestMentionByID(t *testing.T) {
+	mention, err := MentionUserID(resolver{}, "u1")
+	assert.Nil(t, err)
+	assert.Equal(t, "@john", mention)
+	_, err = MentionUserID(resolver{}, "unknown")
+	assert.NotNil(t, err)
+
+	mention, err = MentionRoomID(resolver{}, "r1")
+	assert.Nil(t, err)
+	assert.Equal(t, "#general", mention)
+	_, err = MentionRoomID(resolver{}, "d1")
+	assert.NotNil(t, err)
+}
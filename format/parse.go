@@ -0,0 +1,153 @@
+package format
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/yazver/Rocket.Chat.Go.SDK/models"
+)
+
+var (
+	codeBlockRegexp  = regexp.MustCompile("(?s)```.*?(```|$)")
+	inlineCodeRegexp = regexp.MustCompile("`[^`\n]*`")
+	mentionRegexp    = regexp.MustCompile(`(?:^|[^\w\\])@([\w.\-]+)`)
+	roomRegexp       = regexp.MustCompile(`(?:^|[^\w\\&])#([\w.\-]+)`)
+	markdownLinkRe   = regexp.MustCompile(`\[[^\]]*\]\((https?://[^\s)]+)\)`)
+	linkRegexp       = regexp.MustCompile(`https?://[^\s<>()\[\]]+`)
+	emojiRegexp      = regexp.MustCompile(`:([\w+\-]+):`)
+)
+
+// Parsed is the information extracted from a message text.
+type Parsed struct {
+	// Users are the mentioned usernames without @, including "all" and "here".
+	Users []string
+	// Rooms are the mentioned room names without #.
+	Rooms []string
+	Links []string
+	// Emoji are the emoji shortcodes without colons.
+	Emoji []string
+}
+
+// Parse extracts mentions, room links, links and emoji from a text. Code is ignored.
+func Parse(text string) *Parsed {
+	text = stripCode(text)
+
+	parsed := new(Parsed)
+	parsed.Users = submatches(mentionRegexp, text)
+	parsed.Rooms = submatches(roomRegexp, text)
+	parsed.Emoji = emojiShortcodes(text)
+
+	parsed.Links = submatches(markdownLinkRe, text)
+	for _, link := range linkRegexp.FindAllString(markdownLinkRe.ReplaceAllString(text, ""), -1) {
+		parsed.Links = appendUnique(parsed.Links, strings.TrimRight(link, ".,;:!?"))
+	}
+
+	return parsed
+}
+
+// ParseMessage parses the text of a message.
+func ParseMessage(message *models.Message) *Parsed {
+	return Parse(message.Msg)
+}
+
+// Mentions reports whether the text mentions the user, directly or with @all/@here.
+func (p *Parsed) Mentions(username string) bool {
+	for _, user := range p.Users {
+		if user == username || user == "all" || user == "here" {
+			return true
+		}
+	}
+	return false
+}
+
+// Command is a bot command like "!deploy app production".
+type Command struct {
+	Name string
+	Args []string
+}
+
+// ParseCommand extracts a command from a text starting with the prefix, e.g. "!" or "/".
+// A leading mention of the bot (e.g. "@bot !help") is skipped if botName is set.
+// It returns nil if the text isn't a command or the prefix is empty.
+func ParseCommand(text, prefix, botName string) *Command {
+	if prefix == "" {
+		return nil
+	}
+
+	text = strings.TrimSpace(text)
+	if botName != "" {
+		// The mention has to end there, "@botany" doesn't mention "bot".
+		mention := MentionUsername(botName)
+		if rest := strings.TrimPrefix(text, mention); rest != text && !startsWithUsername(rest) {
+			text = strings.TrimLeft(rest, " \t:,")
+		}
+	}
+
+	if !strings.HasPrefix(text, prefix) {
+		return nil
+	}
+
+	fields := strings.Fields(strings.TrimPrefix(text, prefix))
+	if len(fields) == 0 {
+		return nil
+	}
+
+	return &Command{Name: fields[0], Args: fields[1:]}
+}
+
+// startsWithUsername reports whether the text starts with a character allowed in usernames.
+func startsWithUsername(text string) bool {
+	for _, r := range text {
+		return r == '.' || r == '-' || r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+	}
+	return false
+}
+
+func stripCode(text string) string {
+	text = codeBlockRegexp.ReplaceAllString(text, " ")
+	return inlineCodeRegexp.ReplaceAllString(text, " ")
+}
+
+func submatches(re *regexp.Regexp, text string) []string {
+	var result []string
+	for _, match := range re.FindAllStringSubmatch(text, -1) {
+		result = appendUnique(result, strings.TrimRight(match[1], ".-"))
+	}
+	return result
+}
+
+// emojiShortcodes finds the emoji, which can't follow a word or a backslash. The preceding
+// character is checked apart from the pattern, so emoji next to each other like
+// ":thumbsup::smile:" are all found.
+func emojiShortcodes(text string) []string {
+	var result []string
+	for start := 0; start < len(text); {
+		match := emojiRegexp.FindStringSubmatchIndex(text[start:])
+		if match == nil {
+			break
+		}
+		begin, end := start+match[0], start+match[1]
+		if begin > 0 && (text[begin-1] == '\\' || isWordByte(text[begin-1])) {
+			// The colon may still start another shortcode.
+			start = begin + 1
+			continue
+		}
+		result = appendUnique(result, text[start+match[2]:start+match[3]])
+		start = end
+	}
+	return result
+}
+
+func isWordByte(b byte) bool {
+	return b == '_' || 'a' <= b && b <= 'z' || 'A' <= b && b <= 'Z' || '0' <= b && b <= '9'
+}
+
+func appendUnique(list []string, value string) []string {
+	for _, v := range list {
+		if v == value {
+			return list
+		}
+	}
+	return append(list, value)
+}
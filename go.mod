@@ -9,5 +9,7 @@ require (
 	github.com/onsi/gomega v1.10.2 // indirect
 	github.com/sony/sonyflake v1.0.0
 	github.com/stretchr/testify v1.6.1
-	golang.org/x/net v0.0.0-20200904194848-62affa334b73 // indirect
+	golang.org/x/net v0.0.0-20200904194848-62affa334b73
+	golang.org/x/term v0.0.0-20210220032956-6a3ed077a48d
+	gopkg.in/yaml.v3 v3.0.1
 )
@@ -0,0 +1,185 @@
+package messagestore
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/yazver/Rocket.Chat.Go.SDK/models"
+)
+
+// FileStore is a Store kept in memory and in a file. The file is a log of JSON lines, every
+// change is appended to it and it's read again on open. Compact rewrites it with only the
+// messages stored.
+type FileStore struct {
+	memory *MemoryStore
+	path   string
+	file   *os.File
+}
+
+// fileEntry is a line of the file, either a message put or a message deleted.
+type fileEntry struct {
+	Message *models.Message `json:"message,omitempty"`
+	Deleted *deletedMessage `json:"deleted,omitempty"`
+}
+
+type deletedMessage struct {
+	RoomID string `json:"rid"`
+	ID     string `json:"_id"`
+}
+
+// OpenFileStore opens the store of the file, which is created if it doesn't exist.
+func OpenFileStore(path string) (*FileStore, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("opening message store: %w", err)
+	}
+
+	s := &FileStore{memory: NewMemoryStore(), path: path, file: file}
+	if err := s.load(); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("reading message store %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// load replays the file. A broken last line, e.g. of a crash while writing, is cut off.
+func (s *FileStore) load() error {
+	reader := bufio.NewReader(s.file)
+	var offset int64
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			if len(line) > 0 {
+				return s.file.Truncate(offset)
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var entry fileEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return fmt.Errorf("line at offset %d: %w", offset, err)
+		}
+		switch {
+		case entry.Message != nil:
+			s.memory.put(*entry.Message)
+		case entry.Deleted != nil:
+			s.memory.delete(entry.Deleted.RoomID, entry.Deleted.ID)
+		}
+		offset += int64(len(line))
+	}
+}
+
+func (s *FileStore) Put(messages ...models.Message) error {
+	s.memory.mu.Lock()
+	defer s.memory.mu.Unlock()
+
+	var entries []fileEntry
+	for _, message := range messages {
+		if s.memory.put(message) {
+			m := message
+			entries = append(entries, fileEntry{Message: &m})
+		}
+	}
+	return s.append(entries)
+}
+
+func (s *FileStore) Delete(roomID, messageID string) error {
+	s.memory.mu.Lock()
+	defer s.memory.mu.Unlock()
+
+	if !s.memory.delete(roomID, messageID) {
+		return nil
+	}
+	return s.append([]fileEntry{{Deleted: &deletedMessage{RoomID: roomID, ID: messageID}}})
+}
+
+// append writes the entries to the end of the file, it has to be called with the lock held.
+func (s *FileStore) append(entries []fileEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	var data []byte
+	for _, entry := range entries {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("encoding message: %w", err)
+		}
+		data = append(append(data, line...), '\n')
+	}
+	if _, err := s.file.Seek(0, io.SeekEnd); err != nil {
+		return fmt.Errorf("writing message store: %w", err)
+	}
+	if _, err := s.file.Write(data); err != nil {
+		return fmt.Errorf("writing message store: %w", err)
+	}
+	return nil
+}
+
+func (s *FileStore) Messages(roomID string) ([]models.Message, error) {
+	return s.memory.Messages(roomID)
+}
+
+func (s *FileStore) Oldest(roomID string) (*models.Message, error) {
+	return s.memory.Oldest(roomID)
+}
+
+func (s *FileStore) Newest(roomID string) (*models.Message, error) {
+	return s.memory.Newest(roomID)
+}
+
+// Compact rewrites the file with the messages stored, dropping replaced and deleted ones.
+func (s *FileStore) Compact() error {
+	s.memory.mu.Lock()
+	defer s.memory.mu.Unlock()
+
+	temp, err := os.CreateTemp(filepath.Dir(s.path), ".messages-*")
+	if err != nil {
+		return fmt.Errorf("compacting message store: %w", err)
+	}
+	defer os.Remove(temp.Name())
+
+	writer := bufio.NewWriter(temp)
+	encoder := json.NewEncoder(writer)
+	for _, room := range s.memory.rooms {
+		for _, message := range room {
+			m := message
+			if err := encoder.Encode(fileEntry{Message: &m}); err != nil {
+				temp.Close()
+				return fmt.Errorf("compacting message store: %w", err)
+			}
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		temp.Close()
+		return fmt.Errorf("compacting message store: %w", err)
+	}
+	if err := temp.Close(); err != nil {
+		return fmt.Errorf("compacting message store: %w", err)
+	}
+	if err := os.Rename(temp.Name(), s.path); err != nil {
+		return fmt.Errorf("compacting message store: %w", err)
+	}
+
+	file, err := os.OpenFile(s.path, os.O_RDWR, 0o600)
+	if err != nil {
+		return fmt.Errorf("reopening message store: %w", err)
+	}
+	s.file.Close()
+	s.file = file
+	return nil
+}
+
+// Close closes the file.
+func (s *FileStore) Close() error {
+	s.memory.mu.Lock()
+	defer s.memory.mu.Unlock()
+	return s.file.Close()
+}
@@ -0,0 +1,73 @@
+package messagestore
+
+import (
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/yazver/Rocket.Chat.Go.SDK/models"
+	"github.com/yazver/Rocket.Chat.Go.SDK/realtime"
+	"github.com/yazver/Rocket.Chat.Go.SDK/rest"
+)
+
+// RealtimeLoader loads with the loadHistory method.
+type RealtimeLoader struct {
+	Client *realtime.Client
+}
+
+func (l RealtimeLoader) History(room *models.Channel, before time.Time, count int) ([]models.Message, error) {
+	return l.Client.LoadHistoryBefore(room.ID, before, count)
+}
+
+func (l RealtimeLoader) Missed(room *models.Channel, after time.Time) ([]models.Message, error) {
+	return l.Client.LoadMissedMessages(room.ID, after)
+}
+
+func (l RealtimeLoader) Changes(room *models.Channel, since time.Time) (*models.MessageChanges, error) {
+	return l.Client.GetMessageChanges(room.ID, since)
+}
+
+// RESTLoader loads with the history endpoint of the room type, rooms without a type are
+// looked up first.
+type RESTLoader struct {
+	Client *rest.Client
+}
+
+func (l RESTLoader) History(room *models.Channel, before time.Time, count int) ([]models.Message, error) {
+	params := url.Values{"count": []string{strconv.Itoa(count)}}
+	if !before.IsZero() {
+		params.Set("latest", before.UTC().Format(time.RFC3339Nano))
+	}
+
+	response, err := l.Client.RoomHistory(room, params)
+	if err != nil {
+		return nil, err
+	}
+	return response.Messages, nil
+}
+
+// Missed pages through the history after the time. New messages move the pages, which only
+// loads some messages twice.
+func (l RESTLoader) Missed(room *models.Channel, after time.Time) ([]models.Message, error) {
+	params := url.Values{
+		"oldest": []string{after.UTC().Format(time.RFC3339Nano)},
+		"count":  []string{strconv.Itoa(DefaultPageSize)},
+	}
+
+	var messages []models.Message
+	for {
+		params.Set("offset", strconv.Itoa(len(messages)))
+		response, err := l.Client.RoomHistory(room, params)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, response.Messages...)
+		if len(response.Messages) < DefaultPageSize {
+			return messages, nil
+		}
+	}
+}
+
+func (l RESTLoader) Changes(room *models.Channel, since time.Time) (*models.MessageChanges, error) {
+	return l.Client.SyncMessages(room.ID, since)
+}
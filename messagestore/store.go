@@ -0,0 +1,149 @@
+// Package messagestore keeps a local copy of the messages of rooms. A Syncer fills a Store with
+// the history of rooms, loaded backward from the oldest message stored, catches up with the
+// messages sent after the newest one and the edits and deletions made meanwhile, and keeps it
+// up to date with the messages streamed by the server, including edits and deletions.
+//
+//	store, err := messagestore.OpenFileStore("messages.jsonl")
+//	...
+//	syncer := messagestore.NewSyncer(store, messagestore.RealtimeLoader{Client: client})
+//	if err := syncer.Watch(client, rooms...); err != nil { ... }
+//	for _, room := range rooms {
+//		if _, err := syncer.Backfill(room, 0); err != nil { ... }
+//	}
+package messagestore
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/yazver/Rocket.Chat.Go.SDK/models"
+)
+
+// Store keeps messages by their IDs. Implementations must be safe for concurrent use.
+type Store interface {
+	// Put adds messages and replaces the stored ones with the same IDs, unless the stored
+	// version was updated later.
+	Put(messages ...models.Message) error
+	// Delete removes a message, unknown messages are ignored.
+	Delete(roomID, messageID string) error
+	// Messages returns the messages of a room, oldest first.
+	Messages(roomID string) ([]models.Message, error)
+	// Oldest returns the oldest message of a room, or nil if there is none.
+	Oldest(roomID string) (*models.Message, error)
+	// Newest returns the newest message of a room, or nil if there is none.
+	Newest(roomID string) (*models.Message, error)
+}
+
+// MemoryStore is a Store in memory.
+type MemoryStore struct {
+	mu    sync.Mutex
+	rooms map[string]map[string]models.Message
+}
+
+// NewMemoryStore creates an empty store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{rooms: map[string]map[string]models.Message{}}
+}
+
+func (s *MemoryStore) Put(messages ...models.Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, message := range messages {
+		s.put(message)
+	}
+	return nil
+}
+
+// put stores the message if it's new or newer, it has to be called with the lock held.
+func (s *MemoryStore) put(message models.Message) bool {
+	room, ok := s.rooms[message.RoomID]
+	if !ok {
+		room = map[string]models.Message{}
+		s.rooms[message.RoomID] = room
+	}
+
+	if stored, ok := room[message.ID]; ok && !newer(message, stored) {
+		return false
+	}
+	room[message.ID] = message
+	return true
+}
+
+func (s *MemoryStore) Delete(roomID, messageID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.delete(roomID, messageID)
+	return nil
+}
+
+// delete removes the message if it's stored, it has to be called with the lock held.
+func (s *MemoryStore) delete(roomID, messageID string) bool {
+	if _, ok := s.rooms[roomID][messageID]; !ok {
+		return false
+	}
+	delete(s.rooms[roomID], messageID)
+	return true
+}
+
+func (s *MemoryStore) Messages(roomID string) ([]models.Message, error) {
+	s.mu.Lock()
+	messages := make([]models.Message, 0, len(s.rooms[roomID]))
+	for _, message := range s.rooms[roomID] {
+		messages = append(messages, message)
+	}
+	s.mu.Unlock()
+
+	sortMessages(messages)
+	return messages, nil
+}
+
+func (s *MemoryStore) Oldest(roomID string) (*models.Message, error) {
+	return s.first(roomID, before), nil
+}
+
+func (s *MemoryStore) Newest(roomID string) (*models.Message, error) {
+	return s.first(roomID, func(a, b models.Message) bool { return before(b, a) }), nil
+}
+
+// first returns the message of the room which comes first in the order of less.
+func (s *MemoryStore) first(roomID string, less func(a, b models.Message) bool) *models.Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var first *models.Message
+	for _, message := range s.rooms[roomID] {
+		if first == nil || less(message, *first) {
+			m := message
+			first = &m
+		}
+	}
+	return first
+}
+
+// newer tells if the message was updated after the stored version. Without update times the
+// message is taken as newer.
+func newer(message, stored models.Message) bool {
+	if message.UpdatedAt == nil || stored.UpdatedAt == nil {
+		return true
+	}
+	return !message.UpdatedAt.Before(*stored.UpdatedAt)
+}
+
+// before orders messages by their time and their IDs for messages of the same time.
+func before(a, b models.Message) bool {
+	switch {
+	case a.Timestamp == nil || b.Timestamp == nil:
+		if a.Timestamp != b.Timestamp {
+			return a.Timestamp == nil
+		}
+	case !a.Timestamp.Equal(*b.Timestamp):
+		return a.Timestamp.Before(*b.Timestamp)
+	}
+	return a.ID < b.ID
+}
+
+func sortMessages(messages []models.Message) {
+	sort.Slice(messages, func(i, j int) bool { return before(messages[i], messages[j]) })
+}
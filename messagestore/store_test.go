@@ -0,0 +1,310 @@
+package messagestore
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yazver/Rocket.Chat.Go.SDK/fakeserver"
+	"github.com/yazver/Rocket.Chat.Go.SDK/models"
+	"github.com/yazver/Rocket.Chat.Go.SDK/realtime"
+	"github.com/yazver/Rocket.Chat.Go.SDK/rest"
+)
+
+func message(id, text string, sent, updated time.Time) models.Message {
+	return models.Message{ID: id, RoomID: "ROOM", Msg: text, Timestamp: &sent, UpdatedAt: &updated}
+}
+
+func texts(t *testing.T, store Store) []string {
+	messages, err := store.Messages("ROOM")
+	require.NoError(t, err)
+
+	var result []string
+	for _, m := range messages {
+		result = append(result, m.Msg)
+	}
+	return result
+}
+
+func testStore(t *testing.T, store Store) {
+	start := time.Date(2021, 5, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, store.Put(
+		message("b", "second", start.Add(time.Minute), start.Add(time.Minute)),
+		message("a", "first", start, start),
+		message("c", "third", start.Add(2*time.Minute), start.Add(2*time.Minute)),
+	))
+	assert.Equal(t, []string{"first", "second", "third"}, texts(t, store))
+
+	require.NoError(t, store.Put(message("b", "second, edited", start.Add(time.Minute), start.Add(time.Hour))))
+	require.NoError(t, store.Put(message("b", "second", start.Add(time.Minute), start.Add(time.Minute))), "a stale version is ignored")
+	require.NoError(t, store.Delete("ROOM", "c"))
+	require.NoError(t, store.Delete("ROOM", "unknown"))
+	assert.Equal(t, []string{"first", "second, edited"}, texts(t, store))
+
+	oldest, err := store.Oldest("ROOM")
+	require.NoError(t, err)
+	require.NotNil(t, oldest)
+	assert.Equal(t, "a", oldest.ID)
+
+	oldest, err = store.Oldest("EMPTY")
+	require.NoError(t, err)
+	assert.Nil(t, oldest)
+
+	newest, err := store.Newest("ROOM")
+	require.NoError(t, err)
+	require.NotNil(t, newest)
+	assert.Equal(t, "b", newest.ID)
+
+	newest, err = store.Newest("EMPTY")
+	require.NoError(t, err)
+	assert.Nil(t, newest)
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, NewMemoryStore())
+}
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "messages.jsonl")
+	store, err := OpenFileStore(path)
+	require.NoError(t, err)
+	testStore(t, store)
+	require.NoError(t, store.Close())
+
+	store, err = OpenFileStore(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"first", "second, edited"}, texts(t, store))
+
+	require.NoError(t, store.Compact())
+	require.NoError(t, store.Put(message("d", "fourth", time.Now(), time.Now())))
+	require.NoError(t, store.Close())
+
+	store, err = OpenFileStore(path)
+	require.NoError(t, err)
+	defer store.Close()
+	assert.Equal(t, []string{"first", "second, edited", "fourth"}, texts(t, store))
+}
+
+func TestSyncer_Backfill(t *testing.T) {
+	server := fakeserver.New()
+	defer server.Close()
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	server.Now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+	server.AddUser("bot", "pass", "bot")
+	news := server.AddRoom("news", models.RoomTypeChannel, "bot")
+	for i := 0; i < 25; i++ {
+		server.AddMessage(news.ID, "bot", "message")
+	}
+
+	client := rest.NewClient(server.URL(), false)
+	_, err := client.Login(&models.UserCredentials{Username: "bot", Password: "pass"})
+	require.NoError(t, err)
+
+	store := NewMemoryStore()
+	syncer := NewSyncer(store, RESTLoader{Client: client})
+	syncer.PageSize = 10
+
+	loaded, err := syncer.Backfill(news, 15)
+	require.NoError(t, err)
+	assert.Equal(t, 15, loaded)
+
+	loaded, err = syncer.Backfill(&models.Channel{ID: news.ID}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 10, loaded, "the backfill goes on from the oldest message stored")
+
+	stored, err := store.Messages(news.ID)
+	require.NoError(t, err)
+	expected := server.Messages(news.ID)
+	require.Len(t, stored, len(expected))
+	for i := range expected {
+		assert.Equal(t, expected[i].ID, stored[i].ID)
+	}
+}
+
+func TestSyncer_Watch(t *testing.T) {
+	server := fakeserver.New()
+	defer server.Close()
+	server.AddUser("bot", "pass", "bot")
+	server.AddUser("alice", "pass")
+	general := server.Room(fakeserver.GeneralRoomID)
+	first := server.AddMessage(general.ID, "alice", "first")
+
+	client, err := realtime.NewClient(server.URL(), false)
+	require.NoError(t, err)
+	defer client.Close()
+	_, err = client.Login(&models.UserCredentials{Username: "bot", Password: "pass"})
+	require.NoError(t, err)
+
+	store := NewMemoryStore()
+	syncer := NewSyncer(store, RealtimeLoader{Client: client})
+	require.NoError(t, syncer.Watch(client, general))
+	_, err = syncer.Backfill(general, 0)
+	require.NoError(t, err)
+
+	second := server.AddMessage(general.ID, "alice", "second")
+	first.Msg = "first, edited"
+	require.NoError(t, client.EditMessage(first))
+	require.NoError(t, client.DeleteMessage(second))
+
+	assert.Eventually(t, func() bool {
+		messages, err := store.Messages(general.ID)
+		return err == nil && len(messages) == 1 && messages[0].Msg == "first, edited"
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestSyncer_CatchUp(t *testing.T) {
+	server := fakeserver.New()
+	defer server.Close()
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	server.Now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+	server.AddUser("bot", "pass", "bot")
+	news := server.AddRoom("news", models.RoomTypeChannel, "bot")
+	server.AddMessage(news.ID, "bot", "old")
+
+	client := rest.NewClient(server.URL(), false)
+	_, err := client.Login(&models.UserCredentials{Username: "bot", Password: "pass"})
+	require.NoError(t, err)
+
+	store := NewMemoryStore()
+	syncer := NewSyncer(store, RESTLoader{Client: client})
+	loaded, err := syncer.CatchUp(news)
+	require.NoError(t, err)
+	assert.Equal(t, 0, loaded, "empty rooms are left to the backfill")
+
+	_, err = syncer.Backfill(news, 0)
+	require.NoError(t, err)
+	for i := 0; i < DefaultPageSize+5; i++ {
+		server.AddMessage(news.ID, "bot", "missed")
+	}
+
+	_, err = syncer.CatchUp(news)
+	require.NoError(t, err)
+	stored, err := store.Messages(news.ID)
+	require.NoError(t, err)
+	assert.Len(t, stored, DefaultPageSize+6)
+}
+
+func TestSyncer_WatchCatchesUp(t *testing.T) {
+	server := fakeserver.New()
+	defer server.Close()
+	server.AddUser("bot", "pass", "bot")
+	server.AddUser("alice", "pass")
+	general := server.Room(fakeserver.GeneralRoomID)
+	server.AddMessage(general.ID, "alice", "first")
+
+	client, err := realtime.NewClient(server.URL(), false)
+	require.NoError(t, err)
+	defer client.Close()
+	_, err = client.Login(&models.UserCredentials{Username: "bot", Password: "pass"})
+	require.NoError(t, err)
+
+	store := NewMemoryStore()
+	syncer := NewSyncer(store, RealtimeLoader{Client: client})
+	_, err = syncer.Backfill(general, 0)
+	require.NoError(t, err)
+
+	// Sent while the syncer wasn't watching.
+	server.AddMessage(general.ID, "alice", "missed")
+	require.NoError(t, syncer.Watch(client, general))
+
+	messages, err := store.Messages(general.ID)
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+	assert.Equal(t, "missed", messages[1].Msg)
+}
+
+// historyLoader returns the history it was given at once.
+type historyLoader []models.Message
+
+func (l historyLoader) History(room *models.Channel, before time.Time, count int) ([]models.Message, error) {
+	return l, nil
+}
+
+func (l historyLoader) Missed(room *models.Channel, after time.Time) ([]models.Message, error) {
+	return nil, nil
+}
+
+func (l historyLoader) Changes(room *models.Channel, since time.Time) (*models.MessageChanges, error) {
+	return &models.MessageChanges{}, nil
+}
+
+func TestSyncer_BackfillDeleted(t *testing.T) {
+	start := time.Date(2021, 5, 1, 12, 0, 0, 0, time.UTC)
+	deleted := message("b", "", start.Add(time.Minute), start.Add(time.Hour))
+	deleted.Type = "rm"
+
+	store := NewMemoryStore()
+	require.NoError(t, store.Put(message("b", "second", start.Add(time.Minute), start.Add(time.Minute))))
+	syncer := NewSyncer(store, historyLoader{message("c", "third", start.Add(2*time.Minute), start.Add(2*time.Minute)), deleted})
+
+	_, err := syncer.Backfill(&models.Channel{ID: "ROOM"}, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"third"}, texts(t, store))
+}
+
+func TestSyncer_CatchUpChanges(t *testing.T) {
+	for _, useREST := range []bool{false, true} {
+		server := fakeserver.New()
+		now := time.Now().UTC().Truncate(time.Millisecond)
+		server.Now = func() time.Time {
+			now = now.Add(time.Second)
+			return now
+		}
+		server.AddUser("bot", "pass", "bot")
+		news := server.AddRoom("news", models.RoomTypeChannel, "bot")
+		server.AddMessage(news.ID, "bot", "before the backfill")
+		first := server.AddMessage(news.ID, "bot", "first")
+		second := server.AddMessage(news.ID, "bot", "second")
+		server.AddMessage(news.ID, "bot", "third")
+
+		client, err := realtime.NewClient(server.URL(), false)
+		require.NoError(t, err)
+		_, err = client.Login(&models.UserCredentials{Username: "bot", Password: "pass"})
+		require.NoError(t, err)
+		var loader HistoryLoader = RealtimeLoader{Client: client}
+		if useREST {
+			restClient := rest.NewClient(server.URL(), false)
+			_, err = restClient.Login(&models.UserCredentials{Username: "bot", Password: "pass"})
+			require.NoError(t, err)
+			loader = RESTLoader{Client: restClient}
+		}
+
+		store := NewMemoryStore()
+		syncer := NewSyncer(store, loader)
+		_, err = syncer.Backfill(news, 3)
+		require.NoError(t, err)
+
+		// Changed while the syncer wasn't running.
+		before := server.Messages(news.ID)[0]
+		before.Msg = "before the backfill, edited"
+		require.NoError(t, client.EditMessage(&before))
+		first.Msg = "first, edited"
+		require.NoError(t, client.EditMessage(first))
+		require.NoError(t, client.DeleteMessage(second))
+		server.AddMessage(news.ID, "bot", "fourth")
+
+		loaded, err := syncer.CatchUp(news)
+		require.NoError(t, err)
+		assert.Equal(t, 4, loaded, "rest: %t", useREST)
+
+		messages, err := store.Messages(news.ID)
+		require.NoError(t, err)
+		var stored []string
+		for _, m := range messages {
+			stored = append(stored, m.Msg)
+		}
+		assert.Equal(t, []string{"first, edited", "third", "fourth"}, stored, "rest: %t", useREST)
+
+		client.Close()
+		server.Close()
+	}
+}
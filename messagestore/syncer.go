@@ -0,0 +1,209 @@
+package messagestore
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/yazver/Rocket.Chat.Go.SDK/models"
+	"github.com/yazver/Rocket.Chat.Go.SDK/realtime"
+)
+
+// DefaultPageSize is the number of messages loaded at once by Backfill.
+const DefaultPageSize = 100
+
+// HistoryLoader loads the history of rooms.
+type HistoryLoader interface {
+	// History loads up to count messages of the room sent before the time, newest first. The
+	// zero time loads the newest messages.
+	History(room *models.Channel, before time.Time, count int) ([]models.Message, error)
+	// Missed loads all messages of the room sent after the time.
+	Missed(room *models.Channel, after time.Time) ([]models.Message, error)
+	// Changes loads the messages of the room updated and deleted after the time.
+	Changes(room *models.Channel, since time.Time) (*models.MessageChanges, error)
+}
+
+// Syncer fills a store with the history of rooms and the messages the server streams.
+type Syncer struct {
+	// PageSize is the number of messages loaded at once, DefaultPageSize if not set.
+	PageSize int
+
+	store  Store
+	loader HistoryLoader
+}
+
+// NewSyncer creates a syncer putting the messages loaded with the loader into the store.
+func NewSyncer(store Store, loader HistoryLoader) *Syncer {
+	return &Syncer{store: store, loader: loader}
+}
+
+// Backfill loads the history of the room older than the oldest message stored, page by page,
+// until the first message of the room or limit messages were loaded. A limit of 0 loads the
+// whole history. It returns the number of messages loaded.
+func (s *Syncer) Backfill(room *models.Channel, limit int) (int, error) {
+	pageSize := s.PageSize
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+
+	var before time.Time
+	oldest, err := s.store.Oldest(room.ID)
+	if err != nil {
+		return 0, fmt.Errorf("reading oldest message of room %s: %w", room.ID, err)
+	}
+	if oldest != nil && oldest.Timestamp != nil {
+		before = *oldest.Timestamp
+	}
+
+	loaded := 0
+	for limit == 0 || loaded < limit {
+		count := pageSize
+		if limit > 0 && limit-loaded < count {
+			count = limit - loaded
+		}
+
+		messages, err := s.loader.History(room, before, count)
+		if err != nil {
+			return loaded, fmt.Errorf("loading history of room %s: %w", room.ID, err)
+		}
+		if err := s.apply(messages); err != nil {
+			return loaded, fmt.Errorf("storing history of room %s: %w", room.ID, err)
+		}
+		loaded += len(messages)
+
+		// The messages are the newest first, a short page is the start of the room.
+		if len(messages) < count || messages[len(messages)-1].Timestamp == nil {
+			break
+		}
+		next := *messages[len(messages)-1].Timestamp
+		if !next.Before(before) && !before.IsZero() {
+			break
+		}
+		before = next
+	}
+	return loaded, nil
+}
+
+// CatchUp loads the messages of the room sent after the newest message stored, e.g. while
+// the syncer wasn't running, and applies the edits and deletions of the stored messages made
+// since the last update stored. Rooms without messages stored are left to Backfill. It returns
+// the number of messages loaded, including the edited and deleted ones.
+func (s *Syncer) CatchUp(room *models.Channel) (int, error) {
+	stored, err := s.store.Messages(room.ID)
+	if err != nil {
+		return 0, fmt.Errorf("reading messages of room %s: %w", room.ID, err)
+	}
+	if len(stored) == 0 || stored[0].Timestamp == nil || stored[len(stored)-1].Timestamp == nil {
+		return 0, nil
+	}
+	oldest, newest, lastUpdate := *stored[0].Timestamp, *stored[len(stored)-1].Timestamp, lastUpdate(stored)
+
+	// Messages sent at the same time as the newest one may be missing as well.
+	messages, err := s.loader.Missed(room, newest.Add(-time.Millisecond))
+	if err != nil {
+		return 0, fmt.Errorf("loading missed messages of room %s: %w", room.ID, err)
+	}
+	if err := s.apply(messages); err != nil {
+		return 0, fmt.Errorf("storing missed messages of room %s: %w", room.ID, err)
+	}
+
+	changes, err := s.loader.Changes(room, lastUpdate.Add(-time.Millisecond))
+	if err != nil {
+		return len(messages), fmt.Errorf("loading changed messages of room %s: %w", room.ID, err)
+	}
+	missed := make(map[string]bool, len(messages))
+	for _, message := range messages {
+		missed[message.ID] = true
+	}
+	// Edits of messages older than the stored ones are left to Backfill, they would
+	// make it skip the messages between.
+	var changed []models.Message
+	for _, message := range changes.Updated {
+		if !missed[message.ID] && message.Timestamp != nil && !message.Timestamp.Before(oldest) {
+			changed = append(changed, message)
+		}
+	}
+	for _, deleted := range changes.Deleted {
+		changed = append(changed, models.Message{ID: deleted.ID, RoomID: room.ID, Type: "rm"})
+	}
+	if err := s.apply(changed); err != nil {
+		return len(messages), fmt.Errorf("storing changed messages of room %s: %w", room.ID, err)
+	}
+	return len(messages) + len(changed), nil
+}
+
+// lastUpdate returns the time the newest update of the messages was made.
+func lastUpdate(messages []models.Message) time.Time {
+	var last time.Time
+	for _, message := range messages {
+		updated := message.UpdatedAt
+		if updated == nil {
+			updated = message.Timestamp
+		}
+		if updated != nil && updated.After(last) {
+			last = *updated
+		}
+	}
+	return last
+}
+
+// Watch stores the messages sent, edited and deleted in the rooms from now on. After
+// subscribing it catches up with the messages sent since the newest message stored, so none
+// are lost between runs. The client sends the messages of all rooms to the channel of its
+// first message stream subscription, so it must not have subscribed to message streams
+// before. Errors of the store are logged, since the messages come in the background.
+func (s *Syncer) Watch(client *realtime.Client, rooms ...*models.Channel) error {
+	messages := make(chan models.Message, DefaultPageSize)
+	for _, room := range rooms {
+		roomID := room.ID
+		if err := client.SubscribeToMessageStream(room, messages); err != nil {
+			return fmt.Errorf("subscribing to messages of room %s: %w", roomID, err)
+		}
+		// Deletions take the way of the messages, so they are applied in order.
+		err := client.SubscribeToMessageDeletions(roomID, func(messageID string) {
+			messages <- models.Message{ID: messageID, RoomID: roomID, Type: "rm"}
+		})
+		if err != nil {
+			return fmt.Errorf("subscribing to deletions of room %s: %w", roomID, err)
+		}
+	}
+
+	go func() {
+		for message := range messages {
+			if err := s.Apply(message); err != nil {
+				log.Printf("storing message %s: %v", message.ID, err)
+			}
+		}
+	}()
+
+	for _, room := range rooms {
+		if _, err := s.CatchUp(room); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Apply stores a message received from the server. Messages of the type "rm" are deleted,
+// the server sends them if it keeps the deleted messages.
+func (s *Syncer) Apply(message models.Message) error {
+	return s.apply([]models.Message{message})
+}
+
+// apply stores messages like Apply, the ones which aren't deleted at once.
+func (s *Syncer) apply(messages []models.Message) error {
+	var put []models.Message
+	for _, message := range messages {
+		if message.Type != "rm" {
+			put = append(put, message)
+			continue
+		}
+		if err := s.store.Delete(message.RoomID, message.ID); err != nil {
+			return err
+		}
+	}
+	if len(put) == 0 {
+		return nil
+	}
+	return s.store.Put(put...)
+}
@@ -0,0 +1,8 @@
+package models
+
+// Avatar is the image of a user or a room.
+type Avatar struct {
+	Data        []byte
+	ContentType string
+	ETag        string
+}
@@ -2,31 +2,129 @@ package models
 
 import "time"
 
+// The types of rooms.
+const (
+	RoomTypeChannel  = "c"
+	RoomTypeGroup    = "p"
+	RoomTypeDirect   = "d"
+	RoomTypeLivechat = "l"
+)
+
+// Channel is a room of any type, despite its name.
 type Channel struct {
-	ID    string `json:"_id"`
-	Name  string `json:"name"`
-	Fname string `json:"fname,omitempty"`
-	Type  string `json:"t"`
-	Msgs  int    `json:"msgs"`
+	ID         string `json:"_id"`
+	Name       string `json:"name"`
+	Fname      string `json:"fname,omitempty"`
+	Type       string `json:"t"`
+	Msgs       int    `json:"msgs"`
+	UsersCount int    `json:"usersCount,omitempty"`
+
+	Topic        string `json:"topic,omitempty"`
+	Description  string `json:"description,omitempty"`
+	Announcement string `json:"announcement,omitempty"`
+
+	ReadOnly          bool `json:"ro,omitempty"`
+	SysMes            bool `json:"sysMes,omitempty"`
+	Default           bool `json:"default"`
+	Broadcast         bool `json:"broadcast,omitempty"`
+	Featured          bool `json:"featured,omitempty"`
+	Archived          bool `json:"archived,omitempty"`
+	Encrypted         bool `json:"encrypted,omitempty"`
+	JoinCodeRequired  bool `json:"joinCodeRequired,omitempty"`
+	ReactWhenReadOnly bool `json:"reactWhenReadOnly,omitempty"`
 
-	ReadOnly  bool `json:"ro,omitempty"`
-	SysMes    bool `json:"sysMes,omitempty"`
-	Default   bool `json:"default"`
-	Broadcast bool `json:"broadcast,omitempty"`
+	// Muted are the usernames which can't write, Unmuted the ones which can write in read-only rooms.
+	Muted   []string `json:"muted,omitempty"`
+	Unmuted []string `json:"unmuted,omitempty"`
+
+	// TeamID is the team of the room, TeamMain is set for the main room of the team.
+	TeamID   string `json:"teamId,omitempty"`
+	TeamMain bool   `json:"teamMain,omitempty"`
+	// ParentRoomID is the room of a discussion.
+	ParentRoomID string `json:"prid,omitempty"`
+
+	E2EKeyID   string `json:"e2eKeyId,omitempty"`
+	AvatarETag string `json:"avatarETag,omitempty"`
 
 	Timestamp *time.Time `json:"ts,omitempty"`
 	UpdatedAt *time.Time `json:"_updatedAt,omitempty"`
+	// DeletedAt is set for the removed rooms of incremental updates.
+	DeletedAt *time.Time `json:"_deletedAt,omitempty"`
+	// LastMessageAt is the time of the last message.
+	LastMessageAt *time.Time `json:"lm,omitempty"`
 
 	User        *User    `json:"u,omitempty"`
 	LastMessage *Message `json:"lastMessage,omitempty"`
 
 	Users []string `json:"usernames"`
+	// UserIDs are the members of direct messages.
+	UserIDs []string `json:"uids,omitempty"`
+
+	CustomFields map[string]interface{} `json:"customFields,omitempty"`
+}
+
+// Subscription is the membership of a user in a room with its state, e.g. the unread messages.
+type Subscription struct {
+	ID          string   `json:"_id"`
+	RoomID      string   `json:"rid"`
+	Name        string   `json:"name"`
+	DisplayName string   `json:"fname,omitempty"`
+	Type        string   `json:"t"`
+	User        User     `json:"u"`
+	Roles       []string `json:"roles,omitempty"`
+
+	Open             bool `json:"open"`
+	Alert            bool `json:"alert"`
+	Favorite         bool `json:"f,omitempty"`
+	Archived         bool `json:"archived,omitempty"`
+	HideUnreadStatus bool `json:"hideUnreadStatus,omitempty"`
+	Blocked          bool `json:"blocked,omitempty"`
+	Blocker          bool `json:"blocker,omitempty"`
+
+	Unread        int `json:"unread"`
+	UserMentions  int `json:"userMentions"`
+	GroupMentions int `json:"groupMentions"`
+	// ThreadUnread are the threads with unread messages, ThreadUnreadUser and ThreadUnreadGroup
+	// the ones mentioning the user or all.
+	ThreadUnread      []string `json:"tunread,omitempty"`
+	ThreadUnreadUser  []string `json:"tunreadUser,omitempty"`
+	ThreadUnreadGroup []string `json:"tunreadGroup,omitempty"`
+
+	DisableNotifications    bool   `json:"disableNotifications,omitempty"`
+	DesktopNotifications    string `json:"desktopNotifications,omitempty"`
+	MobilePushNotifications string `json:"mobilePushNotifications,omitempty"`
+	EmailNotifications      string `json:"emailNotifications,omitempty"`
+
+	// ParentRoomID is the room of a discussion.
+	ParentRoomID string `json:"prid,omitempty"`
+
+	Timestamp *time.Time `json:"ts,omitempty"`
+	UpdatedAt *time.Time `json:"_updatedAt,omitempty"`
+	// DeletedAt is set for the removed subscriptions of incremental updates.
+	DeletedAt *time.Time `json:"_deletedAt,omitempty"`
+	// LastSeen is when the user read the room the last time.
+	LastSeen *time.Time `json:"ls,omitempty"`
+	// LastReply is when the user wrote in the room the last time.
+	LastReply *time.Time `json:"lr,omitempty"`
+}
+
+// RoomChange is sent by the server when a room of the user was changed.
+// Action is one of "inserted", "updated" or "removed".
+type RoomChange struct {
+	Action string
+	Room   Channel
+}
 
-	// Lm          interface{} `json:"lm"`
-	// CustomFields struct {
-	// } `json:"customFields,omitempty"`
+// SubscriptionChange is sent by the server when a subscription of the user was changed.
+// Action is one of "inserted", "updated" or "removed".
+type SubscriptionChange struct {
+	Action       string
+	Subscription Subscription
 }
 
+// ChannelSubscription is the former subscription model.
+//
+// Deprecated: Use Subscription, it has all fields.
 type ChannelSubscription struct {
 	ID          string   `json:"_id"`
 	Alert       bool     `json:"alert"`
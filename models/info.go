@@ -30,14 +30,11 @@ type Pagination struct {
 
 type Directory struct {
 	Result []struct {
-		ID        string    `json:"_id"`
-		CreatedAt time.Time `json:"createdAt"`
-		Emails    []struct {
-			Address  string `json:"address"`
-			Verified bool   `json:"verified"`
-		} `json:"emails"`
-		Name     string `json:"name"`
-		Username string `json:"username"`
+		ID        string      `json:"_id"`
+		CreatedAt time.Time   `json:"createdAt"`
+		Emails    []UserEmail `json:"emails"`
+		Name      string      `json:"name"`
+		Username  string      `json:"username"`
 	} `json:"result"`
 
 	Pagination
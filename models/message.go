@@ -25,6 +25,19 @@ type Message struct {
 	// SandstormSessionID interface{} `json:"sandstormSessionId"`
 }
 
+// MessageChanges are the messages of a room updated and deleted since a time, used to sync a
+// local copy of the messages.
+type MessageChanges struct {
+	Updated []Message        `json:"updated"`
+	Deleted []DeletedMessage `json:"deleted"`
+}
+
+// DeletedMessage is a message deleted from a room.
+type DeletedMessage struct {
+	ID        string     `json:"_id"`
+	DeletedAt *time.Time `json:"_deletedAt,omitempty"`
+}
+
 // PostMessage Payload for postmessage rest API
 //
 // https://rocket.chat/docs/developer-guides/rest-api/chat/postmessage/
@@ -37,6 +50,7 @@ type PostMessage struct {
 	Emoji       string       `json:"emoji,omitempty"`
 	Avatar      string       `json:"avatar,omitempty"`
 	Attachments []Attachment `json:"attachments,omitempty"`
+	Blocks      Blocks       `json:"blocks,omitempty"`
 }
 
 // Attachment Payload for postmessage rest API
@@ -56,7 +70,7 @@ type Attachment struct {
 
 	Title             string `json:"title,omitempty"`
 	TitleLink         string `json:"title_link,omitempty"`
-	TitleLinkDownload string `json:"title_link_download,omitempty"`
+	TitleLinkDownload bool   `json:"title_link_download,omitempty"`
 
 	ImageURL string `json:"image_url,omitempty"`
 
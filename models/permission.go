@@ -1,7 +1,22 @@
 package models
 
+import "time"
+
 type Permission struct {
-	ID        string   `json:"_id"`
-	UpdatedAt string   `json:"_updatedAt.$date"`
-	Roles     []string `json:"roles"`
+	ID        string     `json:"_id"`
+	UpdatedAt *time.Time `json:"_updatedAt,omitempty"`
+	Roles     []string   `json:"roles"`
+
+	Group     string `json:"group,omitempty"`
+	Level     string `json:"level,omitempty"`
+	Section   string `json:"section,omitempty"`
+	SettingID string `json:"settingId,omitempty"`
+	Sorter    int    `json:"sorter,omitempty"`
+}
+
+// PermissionChange is sent by the server when a permission was changed.
+// Action is one of "inserted", "updated" or "removed".
+type PermissionChange struct {
+	Action     string
+	Permission Permission
 }
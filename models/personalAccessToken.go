@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// PersonalAccessToken describes a token of the logged in user. The token itself is only returned on creation.
+type PersonalAccessToken struct {
+	Name            string    `json:"name"`
+	CreatedAt       time.Time `json:"createdAt"`
+	LastTokenPart   string    `json:"lastTokenPart"`
+	BypassTwoFactor bool      `json:"bypassTwoFactor"`
+}
+
+type GeneratePersonalAccessTokenRequest struct {
+	TokenName       string `json:"tokenName"`
+	BypassTwoFactor bool   `json:"bypassTwoFactor"`
+}
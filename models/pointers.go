@@ -0,0 +1,24 @@
+package models
+
+// Bool returns a pointer to the value, e.g. for optional fields of update requests.
+func Bool(v bool) *bool {
+	return &v
+}
+
+// String returns a pointer to the value, e.g. for optional fields of update requests.
+func String(v string) *string {
+	return &v
+}
+
+// Int returns a pointer to the value, e.g. for optional fields of update requests.
+func Int(v int) *int {
+	return &v
+}
+
+// Strings returns a pointer to the values, e.g. for optional lists of update requests.
+func Strings(v ...string) *[]string {
+	if v == nil {
+		v = []string{}
+	}
+	return &v
+}
@@ -0,0 +1,63 @@
+package models
+
+import "time"
+
+// RoleScope tells where a role is assigned, globally to users or to room subscriptions.
+type RoleScope string
+
+const (
+	RoleScopeUsers         RoleScope = "Users"
+	RoleScopeSubscriptions RoleScope = "Subscriptions"
+)
+
+type Role struct {
+	ID           string     `json:"_id"`
+	Name         string     `json:"name"`
+	Description  string     `json:"description"`
+	Scope        RoleScope  `json:"scope"`
+	Protected    bool       `json:"protected"`
+	Mandatory2FA bool       `json:"mandatory2fa"`
+	UpdatedAt    *time.Time `json:"_updatedAt,omitempty"`
+}
+
+type CreateRoleRequest struct {
+	Name         string    `json:"name"`
+	Scope        RoleScope `json:"scope,omitempty"`
+	Description  string    `json:"description,omitempty"`
+	Mandatory2FA bool      `json:"mandatory2fa"`
+}
+
+// UpdateRoleRequest replaces the role, an empty description clears the current one.
+type UpdateRoleRequest struct {
+	RoleID       string    `json:"roleId"`
+	Name         string    `json:"name"`
+	Scope        RoleScope `json:"scope,omitempty"`
+	Description  string    `json:"description"`
+	Mandatory2FA bool      `json:"mandatory2fa"`
+}
+
+// UserRoleRequest adds a role to a user or removes it. RoomID is needed for roles with the subscriptions scope.
+type UserRoleRequest struct {
+	RoleName string    `json:"roleName,omitempty"`
+	RoleID   string    `json:"roleId,omitempty"`
+	Username string    `json:"username"`
+	RoomID   string    `json:"roomId,omitempty"`
+	Scope    RoleScope `json:"scope,omitempty"`
+}
+
+// RoomRoles are the roles a user has in a room, e.g. owner or moderator.
+type RoomRoles struct {
+	ID     string   `json:"_id"`
+	RoomID string   `json:"rid"`
+	User   User     `json:"u"`
+	Roles  []string `json:"roles"`
+}
+
+// RoleChange is sent by the server when a role was given to a user or taken away.
+// Scope is the room ID for roles with the subscriptions scope.
+type RoleChange struct {
+	Type     string `json:"type"`
+	RoleName string `json:"_id"`
+	User     *User  `json:"u,omitempty"`
+	Scope    string `json:"scope,omitempty"`
+}
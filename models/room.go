@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+// CleanHistoryRequest deletes the messages of a room between Oldest and Latest.
+type CleanHistoryRequest struct {
+	RoomID string    `json:"roomId"`
+	Latest time.Time `json:"latest"`
+	Oldest time.Time `json:"oldest"`
+	// Inclusive deletes the messages at Oldest and Latest as well.
+	Inclusive     bool `json:"inclusive,omitempty"`
+	ExcludePinned bool `json:"excludePinned,omitempty"`
+	FilesOnly     bool `json:"filesOnly,omitempty"`
+	// Users limits the deletion to the messages of these usernames.
+	Users            []string `json:"users,omitempty"`
+	Limit            int      `json:"limit,omitempty"`
+	IgnoreThreads    bool     `json:"ignoreThreads,omitempty"`
+	IgnoreDiscussion bool     `json:"ignoreDiscussion,omitempty"`
+}
+
+// RoomNotifications are the notification preferences of the user for a room. Unset fields
+// aren't changed. The server takes the flags as "0" and "1", the preferences as "default",
+// "all", "mentions" or "nothing".
+type RoomNotifications struct {
+	DisableNotifications    *string `json:"disableNotifications,omitempty"`
+	MuteGroupMentions       *string `json:"muteGroupMentions,omitempty"`
+	HideUnreadStatus        *string `json:"hideUnreadStatus,omitempty"`
+	DesktopNotifications    *string `json:"desktopNotifications,omitempty"`
+	AudioNotificationValue  *string `json:"audioNotificationValue,omitempty"`
+	MobilePushNotifications *string `json:"mobilePushNotifications,omitempty"`
+	EmailNotifications      *string `json:"emailNotifications,omitempty"`
+}
+
+// The ways to export a room.
+const (
+	RoomExportEmail = "email"
+	RoomExportFile  = "file"
+)
+
+// ExportRoomRequest exports the messages of a room, either sent by email or as file for the
+// user to download.
+type ExportRoomRequest struct {
+	RoomID string `json:"rid"`
+	Type   string `json:"type"`
+
+	// DateFrom, DateTo and Format ("html" or "json") are for file exports.
+	DateFrom *time.Time `json:"dateFrom,omitempty"`
+	DateTo   *time.Time `json:"dateTo,omitempty"`
+	Format   string     `json:"format,omitempty"`
+
+	// ToUsers, ToEmails, Subject and Messages are for email exports, Messages are message IDs.
+	ToUsers  []string `json:"toUsers,omitempty"`
+	ToEmails []string `json:"toEmails,omitempty"`
+	Subject  string   `json:"subject,omitempty"`
+	Messages []string `json:"messages,omitempty"`
+}
@@ -1,21 +1,242 @@
 package models
 
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// SettingType is the type of a setting, it tells how its value looks like.
+type SettingType string
+
+const (
+	SettingTypeBoolean     SettingType = "boolean"
+	SettingTypeString      SettingType = "string"
+	SettingTypeInt         SettingType = "int"
+	SettingTypeSelect      SettingType = "select"
+	SettingTypeMultiSelect SettingType = "multiSelect"
+	SettingTypeCode        SettingType = "code"
+	SettingTypeColor       SettingType = "color"
+	SettingTypeAsset       SettingType = "asset"
+	SettingTypeAction      SettingType = "action"
+	SettingTypeRelativeURL SettingType = "relativeUrl"
+)
+
 type Setting struct {
-	ID           string  `json:"_id"`
-	Blocked      bool    `json:"blocked"`
-	Group        string  `json:"group"`
-	Hidden       bool    `json:"hidden"`
-	Public       bool    `json:"public"`
-	Type         string  `json:"type"`
-	PackageValue string  `json:"packageValue"`
-	Sorter       int     `json:"sorter"`
-	Value        string  `json:"value"`
-	ValueBool    bool    `json:"valueBool"`
-	ValueInt     float64 `json:"valueInt"`
-	ValueSource  string  `json:"valueSource"`
-	ValueAsset   Asset   `json:"asset"`
+	ID           string          `json:"_id"`
+	Blocked      bool            `json:"blocked,omitempty"`
+	Group        string          `json:"group,omitempty"`
+	Section      string          `json:"section,omitempty"`
+	I18nLabel    string          `json:"i18nLabel,omitempty"`
+	Hidden       bool            `json:"hidden,omitempty"`
+	Public       bool            `json:"public,omitempty"`
+	Type         SettingType     `json:"type,omitempty"`
+	PackageValue SettingValue    `json:"packageValue,omitempty"`
+	Sorter       int             `json:"sorter,omitempty"`
+	Value        SettingValue    `json:"value"`
+	ValueSource  string          `json:"valueSource,omitempty"`
+	Values       []SettingOption `json:"values,omitempty"`
+	Editor       string          `json:"editor,omitempty"`
+}
+
+// UnmarshalJSON decodes the values by the type of the setting. Values which don't fit the
+// type are decoded by their JSON kind, see DecodeSettingValue.
+func (s *Setting) UnmarshalJSON(data []byte) error {
+	type setting Setting
+	if err := json.Unmarshal(data, (*setting)(s)); err != nil {
+		return err
+	}
+
+	if s.Type == "" {
+		return nil
+	}
+
+	s.Value, _ = DecodeSettingValue(s.Type, s.Value.Raw)
+	s.PackageValue, _ = DecodeSettingValue(s.Type, s.PackageValue.Raw)
+	return nil
+}
+
+// SettingOption is a choice of a select or multiSelect setting.
+type SettingOption struct {
+	Key       string `json:"key"`
+	I18nLabel string `json:"i18nLabel,omitempty"`
+}
+
+// UnmarshalJSON accepts numeric keys too, some select settings use them. They are kept as
+// their JSON text, e.g. "0".
+func (o *SettingOption) UnmarshalJSON(data []byte) error {
+	var option struct {
+		Key       json.RawMessage `json:"key"`
+		I18nLabel string          `json:"i18nLabel"`
+	}
+	if err := json.Unmarshal(data, &option); err != nil {
+		return err
+	}
+
+	o.I18nLabel = option.I18nLabel
+	if err := json.Unmarshal(option.Key, &o.Key); err != nil {
+		o.Key = string(bytes.TrimSpace(option.Key))
+	}
+	return nil
+}
+
+// SettingValue is the value of a setting. Which field is set depends on the type:
+// Bool for boolean, Int for int, Strings for multiSelect, Asset for asset and String for
+// all the others. Raw always keeps the value as sent by the server.
+type SettingValue struct {
+	Type    SettingType
+	Bool    bool
+	String  string
+	Int     int64
+	Strings []string
+	Asset   *Asset
+	Raw     json.RawMessage
+}
+
+func NewBoolValue(value bool) SettingValue {
+	return SettingValue{Type: SettingTypeBoolean, Bool: value}
+}
+
+// NewStringValue creates a value for the string like types, e.g. string, select, code, color,
+// action or relativeUrl.
+func NewStringValue(typ SettingType, value string) SettingValue {
+	return SettingValue{Type: typ, String: value}
+}
+
+func NewIntValue(value int64) SettingValue {
+	return SettingValue{Type: SettingTypeInt, Int: value}
+}
+
+func NewMultiSelectValue(values ...string) SettingValue {
+	return SettingValue{Type: SettingTypeMultiSelect, Strings: values}
+}
+
+// DecodeSettingValue decodes the raw value of a setting of the type. If the type is unknown,
+// the value is decoded by its JSON kind. If the value doesn't fit the type, it's decoded by
+// its JSON kind as well, so its Type differs from typ, and the error tells the mismatch.
+func DecodeSettingValue(typ SettingType, raw json.RawMessage) (SettingValue, error) {
+	value := SettingValue{Type: typ, Raw: raw}
+	if len(bytes.TrimSpace(raw)) == 0 || bytes.Equal(bytes.TrimSpace(raw), []byte("null")) {
+		return value, nil
+	}
+
+	var err error
+	switch typ {
+	case SettingTypeBoolean:
+		err = json.Unmarshal(raw, &value.Bool)
+	case SettingTypeInt:
+		var number float64
+		err = json.Unmarshal(raw, &number)
+		value.Int = int64(number)
+	case SettingTypeMultiSelect:
+		err = json.Unmarshal(raw, &value.Strings)
+	case SettingTypeAsset:
+		value.Asset = new(Asset)
+		err = json.Unmarshal(raw, value.Asset)
+	case SettingTypeString, SettingTypeSelect, SettingTypeCode, SettingTypeColor, SettingTypeAction, SettingTypeRelativeURL:
+		err = json.Unmarshal(raw, &value.String)
+	default:
+		return inferSettingValue(typ, raw), nil
+	}
+	if err != nil {
+		return inferSettingValue("", raw), fmt.Errorf("decoding %s value: %w", typ, err)
+	}
+	return value, nil
+}
+
+func inferSettingValue(typ SettingType, raw json.RawMessage) SettingValue {
+	value := SettingValue{Type: typ, Raw: raw}
+
+	var decoded interface{}
+	if json.Unmarshal(raw, &decoded) != nil {
+		return value
+	}
+
+	inferred := typ
+	switch v := decoded.(type) {
+	case bool:
+		inferred, value.Bool = SettingTypeBoolean, v
+	case float64:
+		inferred, value.Int = SettingTypeInt, int64(v)
+	case string:
+		inferred, value.String = SettingTypeString, v
+	case []interface{}:
+		var strings []string
+		if json.Unmarshal(raw, &strings) == nil {
+			inferred, value.Strings = SettingTypeMultiSelect, strings
+		}
+	case map[string]interface{}:
+		_, hasURL := v["url"]
+		_, hasDefaultURL := v["defaultUrl"]
+		if hasURL || hasDefaultURL {
+			value.Asset = new(Asset)
+			_ = json.Unmarshal(raw, value.Asset)
+			inferred = SettingTypeAsset
+		}
+	}
+
+	if value.Type == "" {
+		value.Type = inferred
+	}
+	return value
+}
+
+// Interface returns the value as a Go value fitting to the type.
+func (v SettingValue) Interface() interface{} {
+	switch v.Type {
+	case SettingTypeBoolean:
+		return v.Bool
+	case SettingTypeInt:
+		return v.Int
+	case SettingTypeMultiSelect:
+		return v.Strings
+	case SettingTypeAsset:
+		return v.Asset
+	case SettingTypeString, SettingTypeSelect, SettingTypeCode, SettingTypeColor, SettingTypeAction, SettingTypeRelativeURL:
+		return v.String
+	}
+	return v.Raw
+}
+
+// MarshalJSON encodes the field fitting to the type, or the raw value for unknown types.
+func (v SettingValue) MarshalJSON() ([]byte, error) {
+	if value := v.Interface(); value != nil {
+		if raw, ok := value.(json.RawMessage); !ok || len(raw) > 0 {
+			return json.Marshal(value)
+		}
+	}
+	return []byte("null"), nil
+}
+
+// UnmarshalJSON decodes a value without knowing the type of the setting, the type is guessed.
+func (v *SettingValue) UnmarshalJSON(data []byte) error {
+	*v = inferSettingValue("", append(json.RawMessage{}, data...))
+	return nil
 }
 
 type Asset struct {
-	DefaultUrl string `json:"defaultUrl"`
+	URL        string `json:"url,omitempty"`
+	DefaultUrl string `json:"defaultUrl,omitempty"`
+}
+
+// UpdateSettingRequest changes a setting. Editor is only used by color settings and Execute runs
+// the method of an action setting.
+type UpdateSettingRequest struct {
+	Value   *SettingValue `json:"value,omitempty"`
+	Editor  string        `json:"editor,omitempty"`
+	Execute bool          `json:"execute,omitempty"`
+}
+
+// OAuthService is an OAuth login service configured on the server.
+type OAuthService struct {
+	ID               string `json:"_id"`
+	Name             string `json:"name"`
+	Service          string `json:"service"`
+	ClientID         string `json:"clientId"`
+	Custom           bool   `json:"custom"`
+	ServerURL        string `json:"serverURL,omitempty"`
+	Scope            string `json:"scope,omitempty"`
+	ButtonLabelText  string `json:"buttonLabelText,omitempty"`
+	ButtonLabelColor string `json:"buttonLabelColor,omitempty"`
+	ButtonColor      string `json:"buttonColor,omitempty"`
 }
@@ -0,0 +1,14 @@
+package models
+
+// TwoFactorMethod is the way a two-factor authentication code is delivered.
+type TwoFactorMethod string
+
+const (
+	TwoFactorMethodTOTP     TwoFactorMethod = "totp"
+	TwoFactorMethodEmail    TwoFactorMethod = "email"
+	TwoFactorMethodPassword TwoFactorMethod = "password"
+)
+
+// TwoFactorCodeFunc returns the code for the requested method, e.g. by asking the user or
+// computing a TOTP. It's called when the server answers with a totp-required error.
+type TwoFactorCodeFunc func(method TwoFactorMethod) (string, error)
@@ -0,0 +1,536 @@
+package models
+
+import "encoding/json"
+
+// BlockType is the type of a UIKit layout block.
+type BlockType string
+
+const (
+	BlockTypeSection BlockType = "section"
+	BlockTypeActions BlockType = "actions"
+	BlockTypeContext BlockType = "context"
+	BlockTypeDivider BlockType = "divider"
+	BlockTypeImage   BlockType = "image"
+	BlockTypeInput   BlockType = "input"
+)
+
+// ElementType is the type of a UIKit block element or text object.
+type ElementType string
+
+const (
+	ElementTypePlainText      ElementType = "plain_text"
+	ElementTypeMarkdown       ElementType = "mrkdwn"
+	ElementTypeButton         ElementType = "button"
+	ElementTypeImage          ElementType = "image"
+	ElementTypeStaticSelect   ElementType = "static_select"
+	ElementTypeMultiSelect    ElementType = "multi_static_select"
+	ElementTypeOverflow       ElementType = "overflow"
+	ElementTypePlainTextInput ElementType = "plain_text_input"
+)
+
+// ButtonStyle configures the color of a button element.
+type ButtonStyle string
+
+const (
+	ButtonStylePrimary ButtonStyle = "primary"
+	ButtonStyleDanger  ButtonStyle = "danger"
+)
+
+// Block is a UIKit layout block. Use the *Block types of this package.
+//
+// https://developer.rocket.chat/reference/api/apps-engine/ui-kit
+type Block interface {
+	BlockType() BlockType
+}
+
+// BlockElement is an interactive or display element used inside of blocks.
+type BlockElement interface {
+	ElementType() ElementType
+}
+
+// Blocks is a list of layout blocks which can be decoded from a message.
+type Blocks []Block
+
+// TextObject is a plain_text or mrkdwn text used by blocks and elements.
+type TextObject struct {
+	Type  ElementType `json:"type"`
+	Text  string      `json:"text"`
+	Emoji bool        `json:"emoji,omitempty"`
+}
+
+func (t TextObject) ElementType() ElementType { return t.Type }
+
+// NewPlainText creates a plain_text object.
+func NewPlainText(text string) *TextObject {
+	return &TextObject{Type: ElementTypePlainText, Text: text, Emoji: true}
+}
+
+// NewMarkdownText creates a mrkdwn object.
+func NewMarkdownText(text string) *TextObject {
+	return &TextObject{Type: ElementTypeMarkdown, Text: text}
+}
+
+// OptionObject is an entry of a select or overflow element.
+type OptionObject struct {
+	Text  *TextObject `json:"text"`
+	Value string      `json:"value"`
+}
+
+// SectionBlock shows text with optional fields and a single accessory element.
+type SectionBlock struct {
+	BlockID   string       `json:"blockId,omitempty"`
+	AppID     string       `json:"appId,omitempty"`
+	Text      *TextObject  `json:"text,omitempty"`
+	Fields    []TextObject `json:"fields,omitempty"`
+	Accessory BlockElement `json:"accessory,omitempty"`
+}
+
+func (SectionBlock) BlockType() BlockType { return BlockTypeSection }
+
+// ActionsBlock holds interactive elements like buttons, selects and overflow menus.
+type ActionsBlock struct {
+	BlockID  string         `json:"blockId,omitempty"`
+	AppID    string         `json:"appId,omitempty"`
+	Elements []BlockElement `json:"elements"`
+}
+
+func (ActionsBlock) BlockType() BlockType { return BlockTypeActions }
+
+// ContextBlock shows small text and images.
+type ContextBlock struct {
+	BlockID  string         `json:"blockId,omitempty"`
+	AppID    string         `json:"appId,omitempty"`
+	Elements []BlockElement `json:"elements"`
+}
+
+func (ContextBlock) BlockType() BlockType { return BlockTypeContext }
+
+// DividerBlock is a horizontal rule between blocks.
+type DividerBlock struct {
+	BlockID string `json:"blockId,omitempty"`
+	AppID   string `json:"appId,omitempty"`
+}
+
+func (DividerBlock) BlockType() BlockType { return BlockTypeDivider }
+
+// ImageBlock shows a single image.
+type ImageBlock struct {
+	BlockID  string      `json:"blockId,omitempty"`
+	AppID    string      `json:"appId,omitempty"`
+	ImageURL string      `json:"imageUrl"`
+	AltText  string      `json:"altText"`
+	Title    *TextObject `json:"title,omitempty"`
+}
+
+func (ImageBlock) BlockType() BlockType { return BlockTypeImage }
+
+// InputBlock collects data in modals.
+type InputBlock struct {
+	BlockID  string       `json:"blockId,omitempty"`
+	AppID    string       `json:"appId,omitempty"`
+	Label    *TextObject  `json:"label"`
+	Element  BlockElement `json:"element"`
+	Hint     *TextObject  `json:"hint,omitempty"`
+	Optional bool         `json:"optional,omitempty"`
+}
+
+func (InputBlock) BlockType() BlockType { return BlockTypeInput }
+
+// ButtonElement is a clickable button. Either ActionID or URL should be set.
+type ButtonElement struct {
+	ActionID string      `json:"actionId,omitempty"`
+	AppID    string      `json:"appId,omitempty"`
+	BlockID  string      `json:"blockId,omitempty"`
+	Text     *TextObject `json:"text"`
+	Value    string      `json:"value,omitempty"`
+	URL      string      `json:"url,omitempty"`
+	Style    ButtonStyle `json:"style,omitempty"`
+}
+
+func (ButtonElement) ElementType() ElementType { return ElementTypeButton }
+
+// ImageElement is a small image used as accessory or in context blocks.
+type ImageElement struct {
+	ImageURL string `json:"imageUrl"`
+	AltText  string `json:"altText"`
+}
+
+func (ImageElement) ElementType() ElementType { return ElementTypeImage }
+
+// StaticSelectElement is a drop down with a fixed list of options.
+type StaticSelectElement struct {
+	ActionID      string         `json:"actionId,omitempty"`
+	AppID         string         `json:"appId,omitempty"`
+	BlockID       string         `json:"blockId,omitempty"`
+	Placeholder   *TextObject    `json:"placeholder,omitempty"`
+	Options       []OptionObject `json:"options"`
+	InitialValue  string         `json:"initialValue,omitempty"`
+	InitialOption *OptionObject  `json:"initialOption,omitempty"`
+}
+
+func (StaticSelectElement) ElementType() ElementType { return ElementTypeStaticSelect }
+
+// MultiStaticSelectElement is a drop down allowing to choose several options.
+type MultiStaticSelectElement struct {
+	ActionID     string         `json:"actionId,omitempty"`
+	AppID        string         `json:"appId,omitempty"`
+	BlockID      string         `json:"blockId,omitempty"`
+	Placeholder  *TextObject    `json:"placeholder,omitempty"`
+	Options      []OptionObject `json:"options"`
+	InitialValue []string       `json:"initialValue,omitempty"`
+}
+
+func (MultiStaticSelectElement) ElementType() ElementType { return ElementTypeMultiSelect }
+
+// OverflowElement is a menu button showing a list of options.
+type OverflowElement struct {
+	ActionID string         `json:"actionId,omitempty"`
+	AppID    string         `json:"appId,omitempty"`
+	BlockID  string         `json:"blockId,omitempty"`
+	Options  []OptionObject `json:"options"`
+}
+
+func (OverflowElement) ElementType() ElementType { return ElementTypeOverflow }
+
+// PlainTextInputElement is a text field used inside of input blocks.
+type PlainTextInputElement struct {
+	ActionID     string      `json:"actionId,omitempty"`
+	AppID        string      `json:"appId,omitempty"`
+	BlockID      string      `json:"blockId,omitempty"`
+	Placeholder  *TextObject `json:"placeholder,omitempty"`
+	InitialValue string      `json:"initialValue,omitempty"`
+	Multiline    bool        `json:"multiline,omitempty"`
+}
+
+func (PlainTextInputElement) ElementType() ElementType { return ElementTypePlainTextInput }
+
+func (b SectionBlock) MarshalJSON() ([]byte, error) {
+	type block SectionBlock
+	return marshalTyped(string(BlockTypeSection), block(b))
+}
+
+func (b ActionsBlock) MarshalJSON() ([]byte, error) {
+	type block ActionsBlock
+	return marshalTyped(string(BlockTypeActions), block(b))
+}
+
+func (b ContextBlock) MarshalJSON() ([]byte, error) {
+	type block ContextBlock
+	return marshalTyped(string(BlockTypeContext), block(b))
+}
+
+func (b DividerBlock) MarshalJSON() ([]byte, error) {
+	type block DividerBlock
+	return marshalTyped(string(BlockTypeDivider), block(b))
+}
+
+func (b ImageBlock) MarshalJSON() ([]byte, error) {
+	type block ImageBlock
+	return marshalTyped(string(BlockTypeImage), block(b))
+}
+
+func (b InputBlock) MarshalJSON() ([]byte, error) {
+	type block InputBlock
+	return marshalTyped(string(BlockTypeInput), block(b))
+}
+
+func (e ButtonElement) MarshalJSON() ([]byte, error) {
+	type element ButtonElement
+	return marshalTyped(string(ElementTypeButton), element(e))
+}
+
+func (e ImageElement) MarshalJSON() ([]byte, error) {
+	type element ImageElement
+	return marshalTyped(string(ElementTypeImage), element(e))
+}
+
+func (e StaticSelectElement) MarshalJSON() ([]byte, error) {
+	type element StaticSelectElement
+	return marshalTyped(string(ElementTypeStaticSelect), element(e))
+}
+
+func (e MultiStaticSelectElement) MarshalJSON() ([]byte, error) {
+	type element MultiStaticSelectElement
+	return marshalTyped(string(ElementTypeMultiSelect), element(e))
+}
+
+func (e OverflowElement) MarshalJSON() ([]byte, error) {
+	type element OverflowElement
+	return marshalTyped(string(ElementTypeOverflow), element(e))
+}
+
+func (e PlainTextInputElement) MarshalJSON() ([]byte, error) {
+	type element PlainTextInputElement
+	return marshalTyped(string(ElementTypePlainTextInput), element(e))
+}
+
+// marshalTyped encodes v and adds the "type" discriminator used by UIKit.
+func marshalTyped(typ string, v interface{}) ([]byte, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+	fields["type"], _ = json.Marshal(typ)
+
+	return json.Marshal(fields)
+}
+
+// UnknownBlock is a block of a type this package doesn't know, or which couldn't be decoded.
+// Raw is the block as it was received, it's encoded unchanged.
+type UnknownBlock struct {
+	Type string
+	Raw  json.RawMessage
+}
+
+func (b UnknownBlock) BlockType() BlockType { return BlockType(b.Type) }
+
+func (b UnknownBlock) MarshalJSON() ([]byte, error) {
+	if len(b.Raw) == 0 {
+		return []byte("null"), nil
+	}
+	return b.Raw, nil
+}
+
+// UnknownElement is an element of a type this package doesn't know, or which couldn't be
+// decoded. Raw is the element as it was received, it's encoded unchanged.
+type UnknownElement struct {
+	Type string
+	Raw  json.RawMessage
+}
+
+func (e UnknownElement) ElementType() ElementType { return ElementType(e.Type) }
+
+func (e UnknownElement) MarshalJSON() ([]byte, error) {
+	if len(e.Raw) == 0 {
+		return []byte("null"), nil
+	}
+	return e.Raw, nil
+}
+
+// UnmarshalJSON decodes a list of blocks into their concrete types. Blocks which can't be
+// decoded are kept as UnknownBlock, so they don't fail the message they are part of.
+func (b *Blocks) UnmarshalJSON(data []byte) error {
+	var raws []json.RawMessage
+	if err := json.Unmarshal(data, &raws); err != nil {
+		*b = nil
+		return nil
+	}
+
+	blocks := make(Blocks, 0, len(raws))
+	for _, raw := range raws {
+		blocks = append(blocks, unmarshalBlock(raw))
+	}
+
+	*b = blocks
+	return nil
+}
+
+type typeProbe struct {
+	Type string `json:"type"`
+}
+
+// probeType returns the type of a block or an element, empty if it has none.
+func probeType(data []byte) string {
+	var probe typeProbe
+	_ = json.Unmarshal(data, &probe)
+	return probe.Type
+}
+
+type rawBlock struct {
+	BlockID   string            `json:"blockId"`
+	AppID     string            `json:"appId"`
+	Text      *TextObject       `json:"text"`
+	Fields    []TextObject      `json:"fields"`
+	Accessory json.RawMessage   `json:"accessory"`
+	Elements  []json.RawMessage `json:"elements"`
+	Element   json.RawMessage   `json:"element"`
+	ImageURL  string            `json:"imageUrl"`
+	AltText   string            `json:"altText"`
+	Title     *TextObject       `json:"title"`
+	Label     *TextObject       `json:"label"`
+	Hint      *TextObject       `json:"hint"`
+	Optional  bool              `json:"optional"`
+}
+
+func unmarshalBlock(data []byte) Block {
+	typ := probeType(data)
+	unknown := UnknownBlock{Type: typ, Raw: append(json.RawMessage{}, data...)}
+
+	var raw rawBlock
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return unknown
+	}
+
+	switch BlockType(typ) {
+	case BlockTypeSection:
+		return SectionBlock{BlockID: raw.BlockID, AppID: raw.AppID, Text: raw.Text, Fields: raw.Fields, Accessory: unmarshalOptionalElement(raw.Accessory)}
+	case BlockTypeActions:
+		return ActionsBlock{BlockID: raw.BlockID, AppID: raw.AppID, Elements: unmarshalElements(raw.Elements)}
+	case BlockTypeContext:
+		return ContextBlock{BlockID: raw.BlockID, AppID: raw.AppID, Elements: unmarshalElements(raw.Elements)}
+	case BlockTypeDivider:
+		return DividerBlock{BlockID: raw.BlockID, AppID: raw.AppID}
+	case BlockTypeImage:
+		return ImageBlock{BlockID: raw.BlockID, AppID: raw.AppID, ImageURL: raw.ImageURL, AltText: raw.AltText, Title: raw.Title}
+	case BlockTypeInput:
+		return InputBlock{BlockID: raw.BlockID, AppID: raw.AppID, Label: raw.Label, Element: unmarshalOptionalElement(raw.Element), Hint: raw.Hint, Optional: raw.Optional}
+	default:
+		return unknown
+	}
+}
+
+func unmarshalElements(raws []json.RawMessage) []BlockElement {
+	elements := make([]BlockElement, 0, len(raws))
+	for _, raw := range raws {
+		elements = append(elements, unmarshalElement(raw))
+	}
+	return elements
+}
+
+func unmarshalOptionalElement(data json.RawMessage) BlockElement {
+	if len(data) == 0 || string(data) == "null" {
+		return nil
+	}
+	return unmarshalElement(data)
+}
+
+func unmarshalElement(data []byte) BlockElement {
+	typ := probeType(data)
+	unknown := UnknownElement{Type: typ, Raw: append(json.RawMessage{}, data...)}
+
+	var element BlockElement
+	switch ElementType(typ) {
+	case ElementTypePlainText, ElementTypeMarkdown:
+		element = &TextObject{}
+	case ElementTypeButton:
+		element = &ButtonElement{}
+	case ElementTypeImage:
+		element = &ImageElement{}
+	case ElementTypeStaticSelect:
+		element = &StaticSelectElement{}
+	case ElementTypeMultiSelect:
+		element = &MultiStaticSelectElement{}
+	case ElementTypeOverflow:
+		element = &OverflowElement{}
+	case ElementTypePlainTextInput:
+		element = &PlainTextInputElement{}
+	default:
+		return unknown
+	}
+
+	if err := json.Unmarshal(data, element); err != nil {
+		return unknown
+	}
+
+	// Elements are returned as values, the same way they are usually built.
+	switch e := element.(type) {
+	case *TextObject:
+		return *e
+	case *ButtonElement:
+		return *e
+	case *ImageElement:
+		return *e
+	case *StaticSelectElement:
+		return *e
+	case *MultiStaticSelectElement:
+		return *e
+	case *OverflowElement:
+		return *e
+	default:
+		return *element.(*PlainTextInputElement)
+	}
+}
+
+// InteractionType is the kind of a UIKit user interaction.
+type InteractionType string
+
+const (
+	InteractionTypeBlockAction InteractionType = "blockAction"
+	InteractionTypeViewSubmit  InteractionType = "viewSubmit"
+	InteractionTypeViewClosed  InteractionType = "viewClosed"
+)
+
+// ContainerType is the surface the interaction originated from.
+type ContainerType string
+
+const (
+	ContainerTypeMessage ContainerType = "message"
+	ContainerTypeView    ContainerType = "view"
+)
+
+// ViewType is the type of a UIKit surface like a modal.
+type ViewType string
+
+const (
+	ViewTypeModal ViewType = "modal"
+)
+
+// View is a UIKit surface, currently modals.
+type View struct {
+	ID     string         `json:"id,omitempty"`
+	AppID  string         `json:"appId,omitempty"`
+	Type   ViewType       `json:"type,omitempty"`
+	Title  *TextObject    `json:"title,omitempty"`
+	Submit *ButtonElement `json:"submit,omitempty"`
+	Close  *ButtonElement `json:"close,omitempty"`
+	Blocks Blocks         `json:"blocks"`
+
+	// State contains the values of the input elements, keyed by block ID and action ID.
+	State map[string]map[string]interface{} `json:"state,omitempty"`
+}
+
+// InteractionContainer describes where an interaction took place.
+type InteractionContainer struct {
+	Type ContainerType `json:"type"`
+	ID   string        `json:"id"`
+}
+
+// InteractionPayload is the type dependent part of an interaction.
+type InteractionPayload struct {
+	BlockID   string      `json:"blockId,omitempty"`
+	Value     interface{} `json:"value,omitempty"`
+	View      *View       `json:"view,omitempty"`
+	ViewID    string      `json:"viewId,omitempty"`
+	IsCleared bool        `json:"isCleared,omitempty"`
+}
+
+// Interaction is a block action, view submission or view close sent to an app.
+//
+// https://developer.rocket.chat/reference/api/apps-engine/ui-kit
+type Interaction struct {
+	Type      InteractionType       `json:"type"`
+	AppID     string                `json:"appId"`
+	ActionID  string                `json:"actionId,omitempty"`
+	TriggerID string                `json:"triggerId,omitempty"`
+	Container *InteractionContainer `json:"container,omitempty"`
+	MessageID string                `json:"mid,omitempty"`
+	RoomID    string                `json:"rid,omitempty"`
+	ThreadID  string                `json:"tmid,omitempty"`
+	User      *User                 `json:"user,omitempty"`
+	Payload   InteractionPayload    `json:"payload"`
+}
+
+// InteractionResponseType tells the client what to do after an interaction.
+type InteractionResponseType string
+
+const (
+	InteractionResponseModalOpen   InteractionResponseType = "modal.open"
+	InteractionResponseModalUpdate InteractionResponseType = "modal.update"
+	InteractionResponseModalClose  InteractionResponseType = "modal.close"
+	InteractionResponseErrors      InteractionResponseType = "errors"
+)
+
+// InteractionResponse is the answer to an Interaction.
+type InteractionResponse struct {
+	Type      InteractionResponseType `json:"type"`
+	AppID     string                  `json:"appId,omitempty"`
+	TriggerID string                  `json:"triggerId,omitempty"`
+	ViewID    string                  `json:"viewId,omitempty"`
+	View      *View                   `json:"view,omitempty"`
+	Errors    map[string]string       `json:"errors,omitempty"`
+}
@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 type User struct {
 	ID           string `json:"_id"`
 	Name         string `json:"name"`
@@ -7,6 +9,49 @@ type User struct {
 	Status       string `json:"status"`
 	Token        string `json:"token"`
 	TokenExpires int64  `json:"tokenExpires"`
+
+	Emails           []UserEmail            `json:"emails,omitempty"`
+	Roles            []string               `json:"roles,omitempty"`
+	Active           bool                   `json:"active,omitempty"`
+	Type             string                 `json:"type,omitempty"`
+	UTCOffset        float64                `json:"utcOffset,omitempty"`
+	StatusText       string                 `json:"statusText,omitempty"`
+	StatusConnection string                 `json:"statusConnection,omitempty"`
+	AvatarETag       string                 `json:"avatarETag,omitempty"`
+	Language         string                 `json:"language,omitempty"`
+	CustomFields     map[string]interface{} `json:"customFields,omitempty"`
+	Settings         *UserSettings          `json:"settings,omitempty"`
+	Services         *UserServices          `json:"services,omitempty"`
+
+	RequirePasswordChange bool `json:"requirePasswordChange,omitempty"`
+
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+	UpdatedAt *time.Time `json:"_updatedAt,omitempty"`
+	LastLogin *time.Time `json:"lastLogin,omitempty"`
+}
+
+type UserEmail struct {
+	Address  string `json:"address"`
+	Verified bool   `json:"verified"`
+}
+
+type UserSettings struct {
+	Preferences UserPreferences `json:"preferences"`
+}
+
+// UserServices contains the login services of a user, only visible to admins.
+type UserServices struct {
+	Password *struct {
+		Bcrypt string `json:"bcrypt"`
+	} `json:"password,omitempty"`
+}
+
+// Email returns the first email address of the user or an empty string.
+func (u *User) Email() string {
+	if len(u.Emails) == 0 {
+		return ""
+	}
+	return u.Emails[0].Address
 }
 
 type CreateUserRequest struct {
@@ -18,13 +63,107 @@ type CreateUserRequest struct {
 	CustomFields map[string]string `json:"customFields,omitempty"`
 }
 
+// UpdateUserRequest changes a user. Only the set fields of Data are sent, all others stay unchanged,
+// so fields can be cleared with a pointer to the empty value, e.g. Roles: models.Strings().
 type UpdateUserRequest struct {
-	UserID string `json:"userId"`
-	Data   struct {
-		Name         string            `json:"name"`
-		Email        string            `json:"email"`
-		Password     string            `json:"password"`
-		Username     string            `json:"username"`
-		CustomFields map[string]string `json:"customFields,omitempty"`
-	} `json:"data"`
+	UserID string         `json:"userId"`
+	Data   UpdateUserData `json:"data"`
+}
+
+type UpdateUserData struct {
+	Name         *string           `json:"name,omitempty"`
+	Email        *string           `json:"email,omitempty"`
+	Password     *string           `json:"password,omitempty"`
+	Username     *string           `json:"username,omitempty"`
+	StatusText   *string           `json:"statusText,omitempty"`
+	Roles        *[]string         `json:"roles,omitempty"`
+	CustomFields map[string]string `json:"customFields,omitempty"`
+
+	Active                *bool `json:"active,omitempty"`
+	Verified              *bool `json:"verified,omitempty"`
+	RequirePasswordChange *bool `json:"requirePasswordChange,omitempty"`
+	SendWelcomeEmail      *bool `json:"sendWelcomeEmail,omitempty"`
+	JoinDefaultChannels   *bool `json:"joinDefaultChannels,omitempty"`
+}
+
+// UpdateOwnBasicInfoRequest changes the profile of the logged in user. Only set fields are sent.
+// CurrentPassword is needed to change the email, the username or the password.
+type UpdateOwnBasicInfoRequest struct {
+	Email           string            `json:"email,omitempty"`
+	Name            string            `json:"name,omitempty"`
+	Username        string            `json:"username,omitempty"`
+	StatusText      *string           `json:"statusText,omitempty"`
+	NickName        *string           `json:"nickname,omitempty"`
+	Bio             *string           `json:"bio,omitempty"`
+	CurrentPassword string            `json:"currentPassword,omitempty"`
+	NewPassword     string            `json:"newPassword,omitempty"`
+	CustomFields    map[string]string `json:"customFields,omitempty"`
+}
+
+type UserPresence struct {
+	Presence         string     `json:"presence"`
+	ConnectionStatus string     `json:"connectionStatus,omitempty"`
+	LastLogin        *time.Time `json:"lastLogin,omitempty"`
+}
+
+// UserPreferences are the account preferences of a user. Only set fields are changed by users.setPreferences.
+type UserPreferences struct {
+	Language                              *string  `json:"language,omitempty"`
+	NewRoomNotification                   *string  `json:"newRoomNotification,omitempty"`
+	NewMessageNotification                *string  `json:"newMessageNotification,omitempty"`
+	UseEmojis                             *bool    `json:"useEmojis,omitempty"`
+	ConvertASCIIEmoji                     *bool    `json:"convertAsciiEmoji,omitempty"`
+	SaveMobileBandwidth                   *bool    `json:"saveMobileBandwidth,omitempty"`
+	CollapseMediaByDefault                *bool    `json:"collapseMediaByDefault,omitempty"`
+	AutoImageLoad                         *bool    `json:"autoImageLoad,omitempty"`
+	EmailNotificationMode                 *string  `json:"emailNotificationMode,omitempty"`
+	UnreadAlert                           *bool    `json:"unreadAlert,omitempty"`
+	NotificationsSoundVolume              *int     `json:"notificationsSoundVolume,omitempty"`
+	DesktopNotifications                  *string  `json:"desktopNotifications,omitempty"`
+	MobileNotifications                   *string  `json:"pushNotifications,omitempty"`
+	EnableAutoAway                        *bool    `json:"enableAutoAway,omitempty"`
+	IdleTimeLimit                         *int     `json:"idleTimeLimit,omitempty"`
+	Highlights                            []string `json:"highlights,omitempty"`
+	DesktopNotificationRequireInteraction *bool    `json:"desktopNotificationRequireInteraction,omitempty"`
+	MessageViewMode                       *int     `json:"messageViewMode,omitempty"`
+	HideUsernames                         *bool    `json:"hideUsernames,omitempty"`
+	HideRoles                             *bool    `json:"hideRoles,omitempty"`
+	HideAvatars                           *bool    `json:"hideAvatars,omitempty"`
+	SendOnEnter                           *string  `json:"sendOnEnter,omitempty"`
+	SidebarShowFavorites                  *bool    `json:"sidebarShowFavorites,omitempty"`
+	SidebarShowUnread                     *bool    `json:"sidebarShowUnread,omitempty"`
+	SidebarSortBy                         *string  `json:"sidebarSortby,omitempty"`
+	SidebarViewMode                       *string  `json:"sidebarViewMode,omitempty"`
+	SidebarHideAvatar                     *bool    `json:"sidebarHideAvatar,omitempty"`
+	SidebarGroupByType                    *bool    `json:"sidebarGroupByType,omitempty"`
+	MuteFocusedConversations              *bool    `json:"muteFocusedConversations,omitempty"`
+}
+
+type SetUserStatusRequest struct {
+	UserID  string `json:"userId,omitempty"`
+	Status  string `json:"status,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type RegisterUserRequest struct {
+	Username  string `json:"username"`
+	Email     string `json:"email"`
+	Password  string `json:"pass"`
+	Name      string `json:"name"`
+	SecretURL string `json:"secretURL,omitempty"`
+}
+
+// ExportOperation is the state of a personal data download requested by a user.
+type ExportOperation struct {
+	ID            string     `json:"_id"`
+	UserID        string     `json:"userId"`
+	RoomList      []string   `json:"roomList,omitempty"`
+	Status        string     `json:"status"`
+	ExportPath    string     `json:"exportPath,omitempty"`
+	AssetsPath    string     `json:"assetsPath,omitempty"`
+	FileList      []string   `json:"fileList,omitempty"`
+	GeneratedFile string     `json:"generatedFile,omitempty"`
+	FullExport    bool       `json:"fullExport"`
+	CreatedAt     *time.Time `json:"createdAt,omitempty"`
+	UpdatedAt     *time.Time `json:"_updatedAt,omitempty"`
 }
@@ -1,10 +1,68 @@
 package models
 
+// LoginMethod selects how UserCredentials are used to log in.
+type LoginMethod string
+
+const (
+	// LoginMethodAuto picks the method from the fields set in the credentials.
+	LoginMethodAuto     LoginMethod = ""
+	LoginMethodToken    LoginMethod = "token"
+	LoginMethodEmail    LoginMethod = "email"
+	LoginMethodUsername LoginMethod = "username"
+	LoginMethodLDAP     LoginMethod = "ldap"
+	LoginMethodOAuth    LoginMethod = "oauth"
+	LoginMethodSAML     LoginMethod = "saml"
+)
+
 type UserCredentials struct {
 	ID    string `json:"id"`
 	Token string `json:"token"`
 
 	Email    string `json:"email"`
+	Username string `json:"username,omitempty"`
 	Name     string `json:"name"`
 	Password string `json:"pass"`
+
+	// LoginMethod forces a way to log in, by default it's chosen from the set fields.
+	LoginMethod LoginMethod `json:"-"`
+
+	// LDAP logs in with Username, or Email, and Password against the LDAP server.
+	LDAP bool `json:"ldap,omitempty"`
+
+	// ServiceName and AccessToken log in with a token of an OAuth provider, e.g. "google" or "github".
+	ServiceName       string `json:"serviceName,omitempty"`
+	AccessToken       string `json:"accessToken,omitempty"`
+	AccessTokenSecret string `json:"accessTokenSecret,omitempty"`
+	ExpiresIn         int    `json:"expiresIn,omitempty"`
+
+	// SAMLCredentialToken logs in with the credential token of a finished SAML flow.
+	SAMLCredentialToken string `json:"samlCredentialToken,omitempty"`
+}
+
+// Method returns the login method, either the selected one or the one matching the set fields.
+func (c *UserCredentials) Method() LoginMethod {
+	switch {
+	case c.LoginMethod != LoginMethodAuto:
+		return c.LoginMethod
+	case c.Token != "":
+		return LoginMethodToken
+	case c.LDAP:
+		return LoginMethodLDAP
+	case c.ServiceName != "":
+		return LoginMethodOAuth
+	case c.SAMLCredentialToken != "":
+		return LoginMethodSAML
+	case c.Email == "" && c.Username != "":
+		return LoginMethodUsername
+	default:
+		return LoginMethodEmail
+	}
+}
+
+// Login returns the name used to log in, the username if set or the email.
+func (c *UserCredentials) Login() string {
+	if c.Username != "" {
+		return c.Username
+	}
+	return c.Email
 }
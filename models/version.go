@@ -0,0 +1,167 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a server version like 6.5.1 or 4.0.0-rc.2.
+type Version struct {
+	Major      int
+	Minor      int
+	Patch      int
+	PreRelease string
+}
+
+// ParseVersion parses a version as reported by the server, a leading v and build metadata are
+// allowed. Missing minor or patch numbers are zero.
+func ParseVersion(version string) (Version, error) {
+	var v Version
+
+	s := strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if i := strings.Index(s, "+"); i >= 0 {
+		s = s[:i]
+	}
+	if i := strings.Index(s, "-"); i >= 0 {
+		s, v.PreRelease = s[:i], s[i+1:]
+	}
+
+	parts := strings.Split(s, ".")
+	if len(parts) > 3 {
+		return Version{}, fmt.Errorf("invalid version %q", version)
+	}
+	numbers := []*int{&v.Major, &v.Minor, &v.Patch}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return Version{}, fmt.Errorf("invalid version %q", version)
+		}
+		*numbers[i] = n
+	}
+	return v, nil
+}
+
+func (v Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.PreRelease != "" {
+		s += "-" + v.PreRelease
+	}
+	return s
+}
+
+// IsZero tells if the version is unknown.
+func (v Version) IsZero() bool {
+	return v == Version{}
+}
+
+// Compare returns -1, 0 or 1 if the version is older, equal or newer than the other one.
+// A pre-release is older than its release.
+func (v Version) Compare(other Version) int {
+	for _, d := range []int{v.Major - other.Major, v.Minor - other.Minor, v.Patch - other.Patch} {
+		if d < 0 {
+			return -1
+		}
+		if d > 0 {
+			return 1
+		}
+	}
+
+	switch {
+	case v.PreRelease == other.PreRelease:
+		return 0
+	case v.PreRelease == "":
+		return 1
+	case other.PreRelease == "":
+		return -1
+	case v.PreRelease < other.PreRelease:
+		return -1
+	}
+	return 1
+}
+
+// AtLeast tells if the version is the other one or newer.
+func (v Version) AtLeast(other Version) bool {
+	return v.Compare(other) >= 0
+}
+
+// Capability is a feature of the server which depends on its version.
+type Capability string
+
+const (
+	// CapabilityCustomStatus is users.setStatus with a status message.
+	CapabilityCustomStatus Capability = "custom-status"
+	// CapabilityPermissionsListAll is permissions.listAll, older servers have permissions.list.
+	CapabilityPermissionsListAll Capability = "permissions.listAll"
+	// CapabilityMethodCall is method.call which calls DDP methods over REST.
+	CapabilityMethodCall Capability = "method.call"
+	// CapabilityTeams are teams and their main rooms.
+	CapabilityTeams Capability = "teams"
+	// CapabilityRoleIDs are roles with IDs besides their names, which roles.update and
+	// roles.delete need.
+	CapabilityRoleIDs Capability = "role-ids"
+)
+
+// VersionRange are the versions a capability is available in, Until is the first version
+// without it.
+type VersionRange struct {
+	Since Version
+	Until *Version
+}
+
+// Contains tells if the version is in the range. Pre-releases count as their release, since
+// they mostly have its features already.
+func (r VersionRange) Contains(v Version) bool {
+	v.PreRelease = ""
+	return v.AtLeast(r.Since) && (r.Until == nil || !v.AtLeast(*r.Until))
+}
+
+func (r VersionRange) String() string {
+	if r.Until == nil {
+		return r.Since.String() + " or newer"
+	}
+	return fmt.Sprintf("%s up to %s", r.Since, r.Until)
+}
+
+// Capabilities is the registry of the capabilities and the versions which have them.
+var Capabilities = map[Capability]VersionRange{
+	CapabilityPermissionsListAll: {Since: Version{Minor: 73}},
+	CapabilityCustomStatus:       {Since: Version{Major: 1, Minor: 2}},
+	CapabilityMethodCall:         {Since: Version{Major: 2, Minor: 4}},
+	CapabilityTeams:              {Since: Version{Major: 3, Minor: 13}},
+	CapabilityRoleIDs:            {Since: Version{Major: 4}},
+}
+
+// ErrUnsupported is wrapped by the errors of features the server doesn't have.
+var ErrUnsupported = errors.New("unsupported by the server")
+
+// UnsupportedError tells that the server version lacks a capability.
+type UnsupportedError struct {
+	Capability Capability
+	Version    Version
+	Range      VersionRange
+}
+
+func (e *UnsupportedError) Error() string {
+	return fmt.Sprintf("%s needs Rocket.Chat %s, the server has %s: %v", e.Capability, e.Range, e.Version, ErrUnsupported)
+}
+
+func (e *UnsupportedError) Unwrap() error {
+	return ErrUnsupported
+}
+
+// Supports tells if the version has the capability. Unknown capabilities and versions are
+// assumed to be supported.
+func (v Version) Supports(capability Capability) bool {
+	return v.Require(capability) == nil
+}
+
+// Require returns an UnsupportedError if the version lacks the capability.
+func (v Version) Require(capability Capability) error {
+	r, ok := Capabilities[capability]
+	if !ok || v.IsZero() || r.Contains(v) {
+		return nil
+	}
+	return &UnsupportedError{Capability: capability, Version: v, Range: r}
+}
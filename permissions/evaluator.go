@@ -0,0 +1,234 @@
+// Package permissions answers permission questions locally, e.g. whether a user may run an admin
+// command of a bot, using the role to permission mapping of the server.
+package permissions
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/yazver/Rocket.Chat.Go.SDK/models"
+	"github.com/yazver/Rocket.Chat.Go.SDK/realtime"
+)
+
+// DefaultRole is the role every user has, even if the server doesn't list it.
+const DefaultRole = "user"
+
+// Loader loads the data the Evaluator works on.
+type Loader interface {
+	// Permissions loads all permissions with their roles.
+	Permissions() ([]models.Permission, error)
+	// UserRoles loads the global roles of a user.
+	UserRoles(userID string) ([]string, error)
+	// RoomRoles loads the users having roles in a room.
+	RoomRoles(roomID string) ([]models.RoomRoles, error)
+}
+
+// Evaluator decides whether a user has a permission. Permissions and roles are loaded when they are
+// needed first and kept until they are changed on the server, see Watch, or Refresh is called.
+type Evaluator struct {
+	loader Loader
+
+	// The loader is called without holding mu, the changes of Watch are applied by the
+	// goroutine receiving the messages of the client, which the loader may use. Loaded data
+	// is only kept if generation, which counts the changes, didn't change meanwhile.
+	mu          sync.Mutex
+	generation  uint64
+	permissions map[string][]string
+	userRoles   map[string][]string
+	roomRoles   map[string]map[string][]string
+}
+
+// NewEvaluator creates an evaluator loading its data with the loader.
+func NewEvaluator(loader Loader) *Evaluator {
+	return &Evaluator{
+		loader:    loader,
+		userRoles: map[string][]string{},
+		roomRoles: map[string]map[string][]string{},
+	}
+}
+
+// HasPermission tells whether the user has the permission, either by a global role or by a role
+// in the room. The roomID may be empty to check only global roles.
+func (e *Evaluator) HasPermission(userID, permission, roomID string) (bool, error) {
+	allowed, err := e.permissionRoles(permission)
+	if err != nil {
+		return false, err
+	}
+	if len(allowed) == 0 {
+		return false, nil
+	}
+
+	roles, err := e.UserRoles(userID, roomID)
+	if err != nil {
+		return false, err
+	}
+
+	for _, role := range roles {
+		for _, allowedRole := range allowed {
+			if role == allowedRole {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// UserRoles returns the global roles of the user and, if roomID is set, the roles in the room.
+func (e *Evaluator) UserRoles(userID, roomID string) ([]string, error) {
+	roles, err := e.globalRoles(userID)
+	if err != nil {
+		return nil, err
+	}
+	if roomID == "" {
+		return roles, nil
+	}
+
+	roomRoles, err := e.roomUserRoles(roomID)
+	if err != nil {
+		return nil, err
+	}
+	return append(append([]string{}, roles...), roomRoles[userID]...), nil
+}
+
+// Refresh drops everything loaded, so it's loaded again when needed.
+func (e *Evaluator) Refresh() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.generation++
+	e.permissions = nil
+	e.userRoles = map[string][]string{}
+	e.roomRoles = map[string]map[string][]string{}
+}
+
+// Watch keeps the evaluator up to date with the permission and role changes the server sends.
+// Changes missed while the client was disconnected are not seen, call Refresh after reconnects.
+func (e *Evaluator) Watch(client *realtime.Client) error {
+	if err := client.SubscribeToPermissionChanges(e.applyPermissionChange); err != nil {
+		return fmt.Errorf("subscribing to permission changes: %w", err)
+	}
+	if err := client.SubscribeToRoleChanges(e.applyRoleChange); err != nil {
+		return fmt.Errorf("subscribing to role changes: %w", err)
+	}
+	return nil
+}
+
+func (e *Evaluator) applyPermissionChange(change models.PermissionChange) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.generation++
+	if e.permissions == nil {
+		return
+	}
+
+	if change.Action == "removed" {
+		delete(e.permissions, change.Permission.ID)
+		return
+	}
+	e.permissions[change.Permission.ID] = change.Permission.Roles
+}
+
+func (e *Evaluator) applyRoleChange(change models.RoleChange) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.generation++
+	switch {
+	case change.Scope != "":
+		delete(e.roomRoles, change.Scope)
+	case change.User != nil && change.User.ID != "":
+		delete(e.userRoles, change.User.ID)
+	default:
+		e.userRoles = map[string][]string{}
+		e.roomRoles = map[string]map[string][]string{}
+	}
+}
+
+func (e *Evaluator) permissionRoles(permission string) ([]string, error) {
+	e.mu.Lock()
+	cached, generation := e.permissions, e.generation
+	e.mu.Unlock()
+	if cached != nil {
+		return cached[permission], nil
+	}
+
+	loaded, err := e.loader.Permissions()
+	if err != nil {
+		return nil, fmt.Errorf("loading permissions: %w", err)
+	}
+	permissions := make(map[string][]string, len(loaded))
+	for _, p := range loaded {
+		permissions[p.ID] = p.Roles
+	}
+
+	e.mu.Lock()
+	if e.generation == generation {
+		e.permissions = permissions
+	}
+	e.mu.Unlock()
+	return permissions[permission], nil
+}
+
+func (e *Evaluator) globalRoles(userID string) ([]string, error) {
+	e.mu.Lock()
+	cached, ok := e.userRoles[userID]
+	generation := e.generation
+	e.mu.Unlock()
+	if ok {
+		return cached, nil
+	}
+
+	loaded, err := e.loader.UserRoles(userID)
+	if err != nil {
+		return nil, fmt.Errorf("loading roles of user %s: %w", userID, err)
+	}
+	// The slice of the loader isn't appended to, it may be shared.
+	roles := append(make([]string, 0, len(loaded)+1), loaded...)
+	if !contains(roles, DefaultRole) {
+		roles = append(roles, DefaultRole)
+	}
+
+	e.mu.Lock()
+	if e.generation == generation {
+		e.userRoles[userID] = roles
+	}
+	e.mu.Unlock()
+	return roles, nil
+}
+
+func (e *Evaluator) roomUserRoles(roomID string) (map[string][]string, error) {
+	e.mu.Lock()
+	cached, ok := e.roomRoles[roomID]
+	generation := e.generation
+	e.mu.Unlock()
+	if ok {
+		return cached, nil
+	}
+
+	roomRoles, err := e.loader.RoomRoles(roomID)
+	if err != nil {
+		return nil, fmt.Errorf("loading roles of room %s: %w", roomID, err)
+	}
+
+	roles := make(map[string][]string, len(roomRoles))
+	for _, r := range roomRoles {
+		roles[r.User.ID] = append(roles[r.User.ID], r.Roles...)
+	}
+
+	e.mu.Lock()
+	if e.generation == generation {
+		e.roomRoles[roomID] = roles
+	}
+	e.mu.Unlock()
+	return roles, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,155 @@
+package permissions
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yazver/Rocket.Chat.Go.SDK/models"
+)
+
+type fakeLoader struct {
+	permissions []models.Permission
+	userRoles   map[string][]string
+	roomRoles   map[string][]models.RoomRoles
+	loads       int
+	// loading is called by the loads, e.g. to apply a change meanwhile.
+	loading func()
+}
+
+func (l *fakeLoader) Permissions() ([]models.Permission, error) {
+	l.load()
+	return l.permissions, nil
+}
+
+func (l *fakeLoader) UserRoles(userID string) ([]string, error) {
+	l.load()
+	return l.userRoles[userID], nil
+}
+
+func (l *fakeLoader) RoomRoles(roomID string) ([]models.RoomRoles, error) {
+	l.load()
+	return l.roomRoles[roomID], nil
+}
+
+func (l *fakeLoader) load() {
+	l.loads++
+	if l.loading != nil {
+		l.loading()
+	}
+}
+
+func TestEvaluator_HasPermission(t *testing.T) {
+	loader := &fakeLoader{
+		permissions: []models.Permission{
+			{ID: "view-statistics", Roles: []string{"admin"}},
+			{ID: "delete-message", Roles: []string{"admin", "owner", "moderator"}},
+			{ID: "create-c", Roles: []string{"admin", "user", "bot"}},
+		},
+		userRoles: map[string][]string{"admin1": {"admin", "user"}},
+		roomRoles: map[string][]models.RoomRoles{
+			"room1": {{RoomID: "room1", User: models.User{ID: "mod1"}, Roles: []string{"moderator"}}},
+		},
+	}
+	evaluator := NewEvaluator(loader)
+
+	tests := []struct {
+		userID, permission, roomID string
+		want                       bool
+	}{
+		{"admin1", "view-statistics", "", true},
+		{"user1", "view-statistics", "", false},
+		{"user1", "create-c", "", true},
+		{"mod1", "delete-message", "", false},
+		{"mod1", "delete-message", "room1", true},
+		{"mod1", "delete-message", "room2", false},
+		{"admin1", "unknown", "", false},
+	}
+	for _, tt := range tests {
+		got, err := evaluator.HasPermission(tt.userID, tt.permission, tt.roomID)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, got, "%s %s %s", tt.userID, tt.permission, tt.roomID)
+	}
+
+	loads := loader.loads
+	_, err := evaluator.HasPermission("mod1", "delete-message", "room1")
+	require.NoError(t, err)
+	assert.Equal(t, loads, loader.loads, "cached data has to be used")
+}
+
+func TestEvaluator_Changes(t *testing.T) {
+	loader := &fakeLoader{
+		permissions: []models.Permission{{ID: "view-statistics", Roles: []string{"admin"}}},
+		userRoles:   map[string][]string{},
+		roomRoles:   map[string][]models.RoomRoles{},
+	}
+	evaluator := NewEvaluator(loader)
+
+	ok, err := evaluator.HasPermission("user1", "view-statistics", "room1")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	evaluator.applyPermissionChange(models.PermissionChange{
+		Action:     "updated",
+		Permission: models.Permission{ID: "view-statistics", Roles: []string{"admin", "moderator"}},
+	})
+	loader.roomRoles["room1"] = []models.RoomRoles{{User: models.User{ID: "user1"}, Roles: []string{"moderator"}}}
+
+	ok, err = evaluator.HasPermission("user1", "view-statistics", "room1")
+	require.NoError(t, err)
+	assert.False(t, ok, "room roles are cached until a role change")
+
+	evaluator.applyRoleChange(models.RoleChange{Type: "added", RoleName: "moderator", User: &models.User{ID: "user1"}, Scope: "room1"})
+	ok, err = evaluator.HasPermission("user1", "view-statistics", "room1")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	loader.userRoles["user1"] = []string{"admin"}
+	evaluator.applyRoleChange(models.RoleChange{Type: "added", RoleName: "admin", User: &models.User{ID: "user1"}})
+	ok, err = evaluator.HasPermission("user1", "view-statistics", "")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	evaluator.applyPermissionChange(models.PermissionChange{Action: "removed", Permission: models.Permission{ID: "view-statistics"}})
+	ok, err = evaluator.HasPermission("user1", "view-statistics", "")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestEvaluator_ChangeWhileLoading(t *testing.T) {
+	roles := make([]string, 1, 2)
+	roles[0] = "admin"
+	loader := &fakeLoader{
+		permissions: []models.Permission{{ID: "view-statistics", Roles: []string{"admin"}}},
+		userRoles:   map[string][]string{"user1": roles},
+	}
+	evaluator := NewEvaluator(loader)
+
+	// The changes arrive on the goroutine of the client, which the loader waits for.
+	loader.loading = func() {
+		done := make(chan struct{})
+		go func() {
+			evaluator.applyRoleChange(models.RoleChange{Type: "removed", RoleName: "admin", User: &models.User{ID: "user1"}})
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("the change is blocked by the load")
+		}
+	}
+
+	ok, err := evaluator.HasPermission("user1", "view-statistics", "")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"admin"}, roles[:cap(roles)][:1], "the roles of the loader were changed")
+	assert.Equal(t, "", roles[:cap(roles)][1], "the roles of the loader were appended to")
+
+	loader.loading = nil
+	loader.userRoles["user1"] = nil
+	ok, err = evaluator.HasPermission("user1", "view-statistics", "")
+	require.NoError(t, err)
+	assert.False(t, ok, "roles loaded before a change must not be kept")
+}
@@ -0,0 +1,71 @@
+package permissions
+
+import (
+	"github.com/yazver/Rocket.Chat.Go.SDK/models"
+	"github.com/yazver/Rocket.Chat.Go.SDK/realtime"
+	"github.com/yazver/Rocket.Chat.Go.SDK/rest"
+)
+
+// RealtimeLoader loads with the realtime api.
+type RealtimeLoader struct {
+	Client *realtime.Client
+}
+
+func (l RealtimeLoader) Permissions() ([]models.Permission, error) {
+	return l.Client.GetPermissions()
+}
+
+// UserRoles looks the user up in the users having roles besides the default one.
+func (l RealtimeLoader) UserRoles(userID string) ([]string, error) {
+	users, err := l.Client.GetUserRoles()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, user := range users {
+		if user.ID == userID {
+			return user.Roles, nil
+		}
+	}
+	return nil, nil
+}
+
+func (l RealtimeLoader) RoomRoles(roomID string) ([]models.RoomRoles, error) {
+	return l.Client.GetChannelRoles(roomID)
+}
+
+// RESTLoader loads with the rest api. Reading the roles of other users may need the
+// view-full-other-user-info permission.
+type RESTLoader struct {
+	Client *rest.Client
+}
+
+func (l RESTLoader) Permissions() ([]models.Permission, error) {
+	response, err := l.Client.ListAllPermissions(nil)
+	if err != nil {
+		return nil, err
+	}
+	return response.Update, nil
+}
+
+func (l RESTLoader) UserRoles(userID string) ([]string, error) {
+	user, err := l.Client.GetUserInfo(&models.User{ID: userID})
+	if err != nil {
+		return nil, err
+	}
+	return user.Roles, nil
+}
+
+// RoomRoles tries the room as a channel first and as a private group then.
+func (l RESTLoader) RoomRoles(roomID string) ([]models.RoomRoles, error) {
+	roles, err := l.Client.GetChannelRoles(roomID)
+	if err == nil {
+		return roles, nil
+	}
+
+	roles, groupErr := l.Client.GetGroupRoles(roomID)
+	if groupErr != nil {
+		return nil, err
+	}
+	return roles, nil
+}
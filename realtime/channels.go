@@ -3,13 +3,14 @@ package realtime
 import (
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/Jeffail/gabs"
 	"github.com/yazver/Rocket.Chat.Go.SDK/models"
 )
 
 func (c *Client) GetChannelID(name string) (string, error) {
-	rawResponse, err := c.ddp.Call("getRoomIdByNameOrId", name)
+	rawResponse, err := c.call("getRoomIdByNameOrId", name)
 	if err != nil {
 		return "", fmt.Errorf("getting channel ID: %w", err)
 	}
@@ -19,101 +20,158 @@ func (c *Client) GetChannelID(name string) (string, error) {
 	return rawResponse.(string), nil
 }
 
-// GetChannelsIn returns list of channels
-// Optionally includes date to get all since last check or 0 to get all
+// GetChannelsIn returns the rooms of the user
 //
 // https://rocket.chat/docs/developer-guides/realtime-api/method-calls/get-rooms/
 func (c *Client) GetChannelsIn() ([]models.Channel, error) {
-	rawResponse, err := c.ddp.Call("rooms/get", map[string]int{
-		"$date": 0,
-	})
+	rooms, _, err := c.GetRoomsUpdatedSince(time.Time{})
+	return rooms, err
+}
+
+// GetRoomsUpdatedSince returns the rooms of the user changed and removed since the time,
+// the zero time returns all rooms. Removed rooms have only their ID and DeletedAt.
+//
+// https://rocket.chat/docs/developer-guides/realtime-api/method-calls/get-rooms/
+func (c *Client) GetRoomsUpdatedSince(since time.Time) (updated, removed []models.Channel, err error) {
+	rawResponse, err := c.call("rooms/get", ejsonDate(since))
 	if err != nil {
-		return nil, fmt.Errorf("getting channels: %w", err)
+		return nil, nil, fmt.Errorf("getting channels: %w", err)
 	}
 
-	document, _ := gabs.Consume(rawResponse.(map[string]interface{})["update"])
-
-	chans, err := document.Children()
-	if err != nil {
-		return nil, err
+	var response struct {
+		Update []models.Channel `json:"update"`
+		Remove []models.Channel `json:"remove"`
+	}
+	if err := decodeDocument(rawResponse, &response); err != nil {
+		return nil, nil, fmt.Errorf("decoding channels: %w", err)
 	}
 
-	channels := make([]models.Channel, 0, len(chans))
+	return response.Update, response.Remove, nil
+}
 
-	for _, i := range chans {
-		channels = append(channels, models.Channel{
-			ID: stringOrZero(i.Path("_id").Data()),
-			//Default: stringOrZero(i.Path("default").Data()),
-			Name: stringOrZero(i.Path("name").Data()),
-			Type: stringOrZero(i.Path("t").Data()),
-		})
+// GetTeamRooms returns the rooms of the user which belong to the team, including its main
+// room. Teams need Rocket.Chat 3.13, older servers return a models.UnsupportedError.
+func (c *Client) GetTeamRooms(teamID string) ([]models.Channel, error) {
+	if err := c.require(models.CapabilityTeams); err != nil {
+		return nil, fmt.Errorf("getting team rooms: %w", err)
 	}
 
-	return channels, nil
+	rooms, err := c.GetChannelsIn()
+	if err != nil {
+		return nil, err
+	}
+	var teamRooms []models.Channel
+	for _, room := range rooms {
+		if room.TeamID == teamID {
+			teamRooms = append(teamRooms, room)
+		}
+	}
+	return teamRooms, nil
 }
 
 // GetChannelSubscriptions gets users channel subscriptions
-// Optionally includes date to get all since last check or 0 to get all
 //
 // https://rocket.chat/docs/developer-guides/realtime-api/method-calls/get-subscriptions
-func (c *Client) GetChannelSubscriptions() ([]models.ChannelSubscription, error) {
-	rawResponse, err := c.ddp.Call("subscriptions/get", map[string]int{
-		"$date": 0,
-	})
+func (c *Client) GetChannelSubscriptions() ([]models.Subscription, error) {
+	subscriptions, _, err := c.GetSubscriptionsUpdatedSince(time.Time{})
+	return subscriptions, err
+}
+
+// GetSubscriptionsUpdatedSince returns the subscriptions of the user changed and removed since
+// the time, the zero time returns all subscriptions. Removed subscriptions have only their ID
+// and DeletedAt.
+//
+// https://rocket.chat/docs/developer-guides/realtime-api/method-calls/get-subscriptions
+func (c *Client) GetSubscriptionsUpdatedSince(since time.Time) (updated, removed []models.Subscription, err error) {
+	rawResponse, err := c.call("subscriptions/get", ejsonDate(since))
 	if err != nil {
-		return nil, fmt.Errorf("getting channel subscriptions: %w", err)
+		return nil, nil, fmt.Errorf("getting channel subscriptions: %w", err)
 	}
 
-	document, _ := gabs.Consume(rawResponse.(map[string]interface{})["update"])
-
-	channelSubs, err := document.Children()
-	if err != nil {
-		return nil, err
+	var response struct {
+		Update []models.Subscription `json:"update"`
+		Remove []models.Subscription `json:"remove"`
+	}
+	if err := decodeDocument(rawResponse, &response); err != nil {
+		return nil, nil, fmt.Errorf("decoding channel subscriptions: %w", err)
 	}
 
-	channelSubscriptions := make([]models.ChannelSubscription, 0, len(channelSubs))
-
-	for _, sub := range channelSubs {
-		channelSubscription := models.ChannelSubscription{
-			ID:          stringOrZero(sub.Path("_id").Data()),
-			Alert:       sub.Path("alert").Data().(bool),
-			Name:        stringOrZero(sub.Path("name").Data()),
-			DisplayName: stringOrZero(sub.Path("fname").Data()),
-			Open:        sub.Path("open").Data().(bool),
-			Type:        stringOrZero(sub.Path("t").Data()),
-			RoomId:      stringOrZero(sub.Path("rid").Data()),
-			User: models.User{
-				ID:       stringOrZero(sub.Path("u._id").Data()),
-				UserName: stringOrZero(sub.Path("u.username").Data()),
-			},
-			Unread: sub.Path("unread").Data().(float64),
-		}
+	return response.Update, response.Remove, nil
+}
 
-		if sub.Path("roles").Data() != nil {
-			var roles []string
-			for _, role := range sub.Path("roles").Data().([]interface{}) {
-				roles = append(roles, role.(string))
-			}
+// SubscribeToRoomChanges calls the listener for every changed room of the logged in user.
+// The listener must not block or call methods of the client.
+//
+// https://developer.rocket.chat/reference/api/realtime-api/subscriptions/stream-notify-user
+func (c *Client) SubscribeToRoomChanges(listener func(change models.RoomChange)) error {
+	return c.subscribeToUserStream("rooms-changed", func(action string, document interface{}) {
+		change := models.RoomChange{Action: action}
+		if err := decodeDocument(document, &change.Room); err != nil {
+			log.Printf("room change is in an unexpected format: %v", err)
+			return
+		}
+		listener(change)
+	})
+}
 
-			channelSubscription.Roles = roles
+// SubscribeToSubscriptionChanges calls the listener for every changed subscription of the
+// logged in user. The listener must not block or call methods of the client.
+//
+// https://developer.rocket.chat/reference/api/realtime-api/subscriptions/stream-notify-user
+func (c *Client) SubscribeToSubscriptionChanges(listener func(change models.SubscriptionChange)) error {
+	return c.subscribeToUserStream("subscriptions-changed", func(action string, document interface{}) {
+		change := models.SubscriptionChange{Action: action}
+		if err := decodeDocument(document, &change.Subscription); err != nil {
+			log.Printf("subscription change is in an unexpected format: %v", err)
+			return
 		}
+		listener(change)
+	})
+}
 
-		channelSubscriptions = append(channelSubscriptions, channelSubscription)
+// subscribeToUserStream subscribes to an event of the logged in user, which is sent with an
+// action and a document.
+func (c *Client) subscribeToUserStream(event string, listener func(action string, document interface{})) error {
+	if c.Session == nil || !c.Session.HasToken() {
+		return fmt.Errorf("subscribing to %s: not logged in", event)
 	}
 
-	return channelSubscriptions, nil
+	userID := c.Session.Credentials().ID
+	return c.SubscribeToStream("stream-notify-user", userID+"/"+event, func(args []interface{}) {
+		if len(args) < 2 {
+			return
+		}
+		listener(stringOrZero(args[0]), args[1])
+	})
 }
 
-// GetChannelRoles returns room roles
+// GetChannelRoles returns the users having roles in the room, like owners and moderators
 //
 // https://rocket.chat/docs/developer-guides/realtime-api/method-calls/get-room-roles
-func (c *Client) GetChannelRoles(roomID string) error {
-	_, err := c.ddp.Call("getRoomRoles", roomID)
+func (c *Client) GetChannelRoles(roomID string) ([]models.RoomRoles, error) {
+	rawResponse, err := c.call("getRoomRoles", roomID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	document, _ := gabs.Consume(rawResponse)
+
+	rawRoles, err := document.Children()
+	if err != nil {
+		return nil, err
+	}
+
+	roomRoles := make([]models.RoomRoles, 0, len(rawRoles))
+	for _, roles := range rawRoles {
+		roomRoles = append(roomRoles, models.RoomRoles{
+			ID:     stringOrZero(roles.Path("_id").Data()),
+			RoomID: stringOrZero(roles.Path("rid").Data()),
+			User:   *getUserFromDocument(roles.Path("u")),
+			Roles:  stringsOrNil(roles.Path("roles").Data()),
+		})
+	}
+
+	return roomRoles, nil
 }
 
 // CreateChannel creates a channel
@@ -121,7 +179,7 @@ func (c *Client) GetChannelRoles(roomID string) error {
 //
 // https://rocket.chat/docs/developer-guides/realtime-api/method-calls/create-channels
 func (c *Client) CreateChannel(name string, users []string) error {
-	_, err := c.ddp.Call("createChannel", name, users)
+	_, err := c.call("createChannel", name, users)
 	if err != nil {
 		return err
 	}
@@ -134,7 +192,7 @@ func (c *Client) CreateChannel(name string, users []string) error {
 //
 // https://rocket.chat/docs/developer-guides/realtime-api/method-calls/create-private-groups
 func (c *Client) CreateGroup(name string, users []string) error {
-	_, err := c.ddp.Call("createPrivateGroup", name, users)
+	_, err := c.call("createPrivateGroup", name, users)
 	if err != nil {
 		return err
 	}
@@ -147,7 +205,7 @@ func (c *Client) CreateGroup(name string, users []string) error {
 //
 // https://rocket.chat/docs/developer-guides/realtime-api/method-calls/joining-channels
 func (c *Client) JoinChannel(roomID string) error {
-	_, err := c.ddp.Call("joinRoom", roomID)
+	_, err := c.call("joinRoom", roomID)
 	if err != nil {
 		return err
 	}
@@ -160,7 +218,7 @@ func (c *Client) JoinChannel(roomID string) error {
 //
 // https://rocket.chat/docs/developer-guides/realtime-api/method-calls/leaving-rooms
 func (c *Client) LeaveChannel(roomID string) error {
-	_, err := c.ddp.Call("leaveRoom", roomID)
+	_, err := c.call("leaveRoom", roomID)
 	if err != nil {
 		return err
 	}
@@ -173,7 +231,7 @@ func (c *Client) LeaveChannel(roomID string) error {
 //
 // https://rocket.chat/docs/developer-guides/realtime-api/method-calls/archive-rooms
 func (c *Client) ArchiveChannel(roomID string) error {
-	_, err := c.ddp.Call("archiveRoom", roomID)
+	_, err := c.call("archiveRoom", roomID)
 	if err != nil {
 		return err
 	}
@@ -186,7 +244,7 @@ func (c *Client) ArchiveChannel(roomID string) error {
 //
 // https://rocket.chat/docs/developer-guides/realtime-api/method-calls/unarchive-rooms
 func (c *Client) UnArchiveChannel(roomID string) error {
-	_, err := c.ddp.Call("unarchiveRoom", roomID)
+	_, err := c.call("unarchiveRoom", roomID)
 	if err != nil {
 		return err
 	}
@@ -199,7 +257,7 @@ func (c *Client) UnArchiveChannel(roomID string) error {
 //
 // https://rocket.chat/docs/developer-guides/realtime-api/method-calls/delete-rooms
 func (c *Client) DeleteChannel(roomID string) error {
-	_, err := c.ddp.Call("eraseRoom", roomID)
+	_, err := c.call("eraseRoom", roomID)
 	if err != nil {
 		return err
 	}
@@ -212,7 +270,7 @@ func (c *Client) DeleteChannel(roomID string) error {
 //
 // https://rocket.chat/docs/developer-guides/realtime-api/method-calls/save-room-settings
 func (c *Client) SetChannelTopic(roomID string, topic string) error {
-	_, err := c.ddp.Call("saveRoomSettings", roomID, "roomTopic", topic)
+	_, err := c.call("saveRoomSettings", roomID, "roomTopic", topic)
 	if err != nil {
 		return err
 	}
@@ -225,7 +283,7 @@ func (c *Client) SetChannelTopic(roomID string, topic string) error {
 //
 // https://rocket.chat/docs/developer-guides/realtime-api/method-calls/save-room-settings
 func (c *Client) SetChannelType(roomID string, roomType string) error {
-	_, err := c.ddp.Call("saveRoomSettings", roomID, "roomType", roomType)
+	_, err := c.call("saveRoomSettings", roomID, "roomType", roomType)
 	if err != nil {
 		return err
 	}
@@ -238,7 +296,7 @@ func (c *Client) SetChannelType(roomID string, roomType string) error {
 //
 // https://rocket.chat/docs/developer-guides/realtime-api/method-calls/save-room-settings
 func (c *Client) SetChannelJoinCode(roomID string, joinCode string) error {
-	_, err := c.ddp.Call("saveRoomSettings", roomID, "joinCode", joinCode)
+	_, err := c.call("saveRoomSettings", roomID, "joinCode", joinCode)
 	if err != nil {
 		return err
 	}
@@ -251,7 +309,7 @@ func (c *Client) SetChannelJoinCode(roomID string, joinCode string) error {
 //
 // https://rocket.chat/docs/developer-guides/realtime-api/method-calls/save-room-settings
 func (c *Client) SetChannelReadOnly(roomID string, readOnly bool) error {
-	_, err := c.ddp.Call("saveRoomSettings", roomID, "readOnly", readOnly)
+	_, err := c.call("saveRoomSettings", roomID, "readOnly", readOnly)
 	if err != nil {
 		return err
 	}
@@ -264,7 +322,7 @@ func (c *Client) SetChannelReadOnly(roomID string, readOnly bool) error {
 //
 // https://rocket.chat/docs/developer-guides/realtime-api/method-calls/save-room-settings
 func (c *Client) SetChannelDescription(roomID string, description string) error {
-	_, err := c.ddp.Call("saveRoomSettings", roomID, "roomDescription", description)
+	_, err := c.call("saveRoomSettings", roomID, "roomDescription", description)
 	if err != nil {
 		return err
 	}
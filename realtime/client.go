@@ -2,26 +2,58 @@
 package realtime
 
 import (
+	"crypto/rand"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"log"
 	"net/url"
 	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/gopackage/ddp"
 	"github.com/sony/sonyflake"
+	"github.com/yazver/Rocket.Chat.Go.SDK/auth"
+	"github.com/yazver/Rocket.Chat.Go.SDK/models"
 )
 
 type Client struct {
-	ddp *ddp.Client
-	sf  *sonyflake.Sonyflake
+	// TwoFactorCode is asked for a code when the login requires two-factor authentication.
+	TwoFactorCode models.TwoFactorCodeFunc
+
+	// Session keeps the token and the login data. It may be shared with a rest.Client.
+	// After a reconnect the session token is used to log in again.
+	Session *auth.Session
+
+	ddp       *ddp.Client
+	sf        *sonyflake.Sonyflake
+	serverURL *url.URL
+	debug     bool
+
+	methodsMu sync.Mutex
+	methods   MethodCaller
+
+	// loggedIn is set to 1 by the first login, it's accessed atomically since reconnects
+	// read it on the goroutine of the connection.
+	loggedIn int32
+
+	versionMu sync.Mutex
+	version   *models.Version
+	// versionErr is the failure of the last version request, it's returned until versionRetry.
+	versionErr   error
+	versionRetry time.Time
+
+	// The messages of all rooms go to the channel of the first SubscribeToMessageStream call.
+	messageListenerAdded bool
 }
 
 // NewClient creates a new instance and connects to the websocket.
 func NewClient(serverURL *url.URL, debug bool) (*Client, error) {
-	sf := sonyflake.NewSonyflake(sonyflake.Settings{})
-	if sf == nil {
-		return nil, errors.New("random id generator failed to be created")
+	sf, err := newSonyflake()
+	if err != nil {
+		return nil, err
 	}
 
 	wsURL := "ws"
@@ -43,11 +75,16 @@ func NewClient(serverURL *url.URL, debug bool) (*Client, error) {
 	c := new(Client)
 	c.ddp = ddp.NewClient(wsURL, serverURL.String())
 	c.sf = sf
+	c.serverURL = serverURL
+	c.debug = debug
+	c.methods = websocketCaller{c.ddp}
 
 	if debug {
 		c.ddp.SetSocketLogActive(true)
 	}
 
+	c.ddp.AddConnectionListener(reconnectListener{c})
+
 	if err := c.ddp.Connect(); err != nil {
 		return nil, err
 	}
@@ -64,16 +101,38 @@ func (s statusListener) Status(status int) {
 }
 
 func (c *Client) AddStatusListener(listener func(int)) {
+	if c.ddp == nil {
+		return
+	}
 	c.ddp.AddStatusListener(statusListener{listener: listener})
 }
 
+type reconnectListener struct {
+	c *Client
+}
+
+// Connected resumes the session, since a new DDP connection isn't logged in.
+func (l reconnectListener) Connected() {
+	if atomic.LoadInt32(&l.c.loggedIn) == 0 || l.c.Session == nil || !l.c.Session.HasToken() {
+		return
+	}
+
+	current := l.c.Session.Credentials()
+	if _, err := l.c.login(&models.UserCredentials{Token: current.Token}); err != nil {
+		log.Printf("resuming session after reconnect: %v", err)
+	}
+}
+
 func (c *Client) Reconnect() {
+	if c.ddp == nil {
+		return
+	}
 	c.ddp.Reconnect()
 }
 
 // ConnectionAway sets connection status to away.
 func (c *Client) ConnectionAway() error {
-	_, err := c.ddp.Call("UserPresence:away")
+	_, err := c.call("UserPresence:away")
 	if err != nil {
 		return err
 	}
@@ -83,7 +142,7 @@ func (c *Client) ConnectionAway() error {
 
 // ConnectionOnline sets connection status to online.
 func (c *Client) ConnectionOnline() error {
-	_, err := c.ddp.Call("UserPresence:online")
+	_, err := c.call("UserPresence:online")
 	if err != nil {
 		return err
 	}
@@ -93,9 +152,32 @@ func (c *Client) ConnectionOnline() error {
 
 // Close closes the ddp session.
 func (c *Client) Close() {
+	if c.ddp == nil {
+		return
+	}
 	c.ddp.Close()
 }
 
+func newSonyflake() (*sonyflake.Sonyflake, error) {
+	sf := sonyflake.NewSonyflake(sonyflake.Settings{})
+	if sf == nil {
+		// The machine ID is taken from the private IP address, hosts without one get a random ID.
+		sf = sonyflake.NewSonyflake(sonyflake.Settings{MachineID: randomMachineID})
+	}
+	if sf == nil {
+		return nil, errors.New("random id generator failed to be created")
+	}
+	return sf, nil
+}
+
+func randomMachineID() (uint16, error) {
+	var id [2]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint16(id[:]), nil
+}
+
 // Some of the rocketchat objects need unique IDs specified by the client.
 func (c *Client) newRandomID() string {
 	id, err := c.sf.NextID()
@@ -1,21 +1,35 @@
 package realtime
 
 import (
-	"net/url"
+	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/yazver/Rocket.Chat.Go.SDK/common_testing"
+	"github.com/yazver/Rocket.Chat.Go.SDK/fakeserver"
 	"github.com/yazver/Rocket.Chat.Go.SDK/models"
 )
 
 var (
 	client *Client
+
+	// testServer is the fake server the logged in client is connected to.
+	testServer *fakeserver.Server
 )
 
+func TestMain(m *testing.M) {
+	testServer = fakeserver.New()
+	code := m.Run()
+	if client != nil {
+		client.Close()
+	}
+	testServer.Close()
+	os.Exit(code)
+}
+
 func getLoggedInClient(t *testing.T) *Client {
 	if client == nil {
-		c, err := NewClient(&url.URL{Host: common_testing.Host + ":" + common_testing.Port}, true)
+		c, err := NewClient(testServer.URL(), false)
 		assert.Nil(t, err, "Couldn't create realtime client")
 
 		_, err = c.RegisterUser(&models.UserCredentials{
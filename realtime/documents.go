@@ -0,0 +1,45 @@
+package realtime
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// decodeDocument decodes a DDP result into a model like a REST response. The EJSON dates
+// {"$date": ms} become RFC 3339 times, so models with time.Time fields can be used.
+func decodeDocument(data interface{}, v interface{}) error {
+	encoded, err := json.Marshal(ejsonToJSON(data))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(encoded, v)
+}
+
+func ejsonToJSON(data interface{}) interface{} {
+	switch value := data.(type) {
+	case map[string]interface{}:
+		if ms, ok := value["$date"].(float64); ok && len(value) == 1 {
+			return time.Unix(0, int64(ms)*int64(time.Millisecond)).UTC().Format(time.RFC3339Nano)
+		}
+		converted := make(map[string]interface{}, len(value))
+		for key, v := range value {
+			converted[key] = ejsonToJSON(v)
+		}
+		return converted
+	case []interface{}:
+		converted := make([]interface{}, len(value))
+		for i, v := range value {
+			converted[i] = ejsonToJSON(v)
+		}
+		return converted
+	}
+	return data
+}
+
+// ejsonDate is the EJSON form of a time, the zero time is the epoch.
+func ejsonDate(t time.Time) map[string]int64 {
+	if t.IsZero() {
+		return map[string]int64{"$date": 0}
+	}
+	return map[string]int64{"$date": t.UnixNano() / int64(time.Millisecond)}
+}
@@ -0,0 +1,48 @@
+package realtime
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yazver/Rocket.Chat.Go.SDK/models"
+)
+
+func TestDecodeDocument(t *testing.T) {
+	document := map[string]interface{}{
+		"_id":          "GENERAL",
+		"t":            "c",
+		"name":         "general",
+		"topic":        "news",
+		"archived":     true,
+		"teamId":       "team",
+		"usersCount":   float64(3),
+		"muted":        []interface{}{"spammer"},
+		"customFields": map[string]interface{}{"owner": "ops"},
+		"ts":           map[string]interface{}{"$date": float64(1600000000123)},
+		"lm":           map[string]interface{}{"$date": float64(1600000001000)},
+		"lastMessage": map[string]interface{}{
+			"_id": "message",
+			"msg": "hello",
+			"ts":  map[string]interface{}{"$date": float64(1600000001000)},
+		},
+	}
+
+	var channel models.Channel
+	require.NoError(t, decodeDocument(document, &channel))
+
+	assert.Equal(t, "news", channel.Topic)
+	assert.True(t, channel.Archived)
+	assert.Equal(t, "team", channel.TeamID)
+	assert.Equal(t, 3, channel.UsersCount)
+	assert.Equal(t, []string{"spammer"}, channel.Muted)
+	assert.Equal(t, "ops", channel.CustomFields["owner"])
+	require.NotNil(t, channel.Timestamp)
+	assert.True(t, channel.Timestamp.Equal(time.Unix(1600000000, 123e6)))
+	require.NotNil(t, channel.LastMessageAt)
+	require.NotNil(t, channel.LastMessage)
+	assert.Equal(t, "hello", channel.LastMessage.Msg)
+	assert.True(t, channel.LastMessage.Timestamp.Equal(*channel.LastMessageAt))
+}
@@ -2,7 +2,7 @@ package realtime
 
 // TODO: currently unused
 // func (c *Client) getCustomEmoji() error {
-// 	_, err := c.ddp.Call("listEmojiCustom")
+// 	_, err := c.call("listEmojiCustom")
 // 	if err != nil {
 // 		return err
 // 	}
@@ -3,7 +3,7 @@ package realtime
 import "fmt"
 
 func (c *Client) StartTyping(roomID string, username string) error {
-	_, err := c.ddp.Call("stream-notify-room", fmt.Sprintf("%s/typing", roomID), username, true)
+	_, err := c.call("stream-notify-room", fmt.Sprintf("%s/typing", roomID), username, true)
 	if err != nil {
 		return err
 	}
@@ -12,7 +12,7 @@ func (c *Client) StartTyping(roomID string, username string) error {
 }
 
 func (c *Client) StopTyping(roomID string, username string) error {
-	_, err := c.ddp.Call("stream-notify-room", fmt.Sprintf("%s/typing", roomID), username, false)
+	_, err := c.call("stream-notify-room", fmt.Sprintf("%s/typing", roomID), username, false)
 	if err != nil {
 		return err
 	}
@@ -1,9 +1,9 @@
 package realtime
 
 import (
+	"encoding/json"
 	"fmt"
 	"log"
-	"strconv"
 	"time"
 
 	"github.com/Jeffail/gabs"
@@ -17,8 +17,6 @@ const (
 	defaultBufferSize = 100
 )
 
-var messageListenerAdded = false
-
 // NewMessage creates basic message with an ID, a RoomID, and a Msg
 // Takes channel and text.
 func (c *Client) NewMessage(channel *models.Channel, text string) *models.Message {
@@ -34,7 +32,70 @@ func (c *Client) NewMessage(channel *models.Channel, text string) *models.Messag
 //
 // https://rocket.chat/docs/developer-guides/realtime-api/method-calls/load-history
 func (c *Client) LoadHistory(roomID string) ([]models.Message, error) {
-	m, err := c.ddp.Call("loadHistory", roomID)
+	return c.loadHistory(roomID)
+}
+
+// LoadHistoryBefore loads up to limit messages of a room sent before the time, newest first.
+// The zero time loads the newest messages.
+//
+// https://rocket.chat/docs/developer-guides/realtime-api/method-calls/load-history
+func (c *Client) LoadHistoryBefore(roomID string, before time.Time, limit int) ([]models.Message, error) {
+	var end interface{}
+	if !before.IsZero() {
+		end = ejsonDate(before)
+	}
+	return c.loadHistory(roomID, end, limit)
+}
+
+// LoadMissedMessages loads the messages of a room sent after the time, e.g. while the client
+// was disconnected.
+func (c *Client) LoadMissedMessages(roomID string, after time.Time) ([]models.Message, error) {
+	m, err := c.call("loadMissedMessages", roomID, ejsonDate(after))
+	if err != nil {
+		return nil, err
+	}
+
+	document, _ := gabs.Consume(m)
+	msgs, err := document.Children()
+	if err != nil {
+		log.Printf("response is in an unexpected format: %v", err)
+		return make([]models.Message, 0), nil
+	}
+
+	messages := make([]models.Message, len(msgs))
+	for i, arg := range msgs {
+		messages[i] = *getMessageFromDocument(arg)
+	}
+	return messages, nil
+}
+
+// GetMessageChanges loads the messages of a room updated and deleted since lastUpdate, e.g.
+// to sync the edits and deletions missed while the client was disconnected.
+func (c *Client) GetMessageChanges(roomID string, lastUpdate time.Time) (*models.MessageChanges, error) {
+	rawResponse, err := c.call("messages/get", roomID, map[string]interface{}{"lastUpdate": ejsonDate(lastUpdate)})
+	if err != nil {
+		return nil, err
+	}
+
+	response, ok := rawResponse.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("message changes are in an unexpected format: %v", rawResponse)
+	}
+
+	changes := &models.MessageChanges{}
+	if err := decodeDocument(response["deleted"], &changes.Deleted); err != nil {
+		return nil, fmt.Errorf("decoding deleted messages: %w", err)
+	}
+	updated, _ := response["updated"].([]interface{})
+	changes.Updated = make([]models.Message, len(updated))
+	for i, message := range updated {
+		changes.Updated[i] = *getMessageFromData(message)
+	}
+	return changes, nil
+}
+
+func (c *Client) loadHistory(roomID string, params ...interface{}) ([]models.Message, error) {
+	m, err := c.call("loadHistory", append([]interface{}{roomID}, params...)...)
 	if err != nil {
 		return nil, err
 	}
@@ -61,11 +122,11 @@ func (c *Client) LoadHistory(roomID string) ([]models.Message, error) {
 }
 
 // SendMessage sends message to channel
-// takes message
+// takes message, UIKit blocks of the message are sent as well
 //
 // https://rocket.chat/docs/developer-guides/realtime-api/method-calls/send-message
 func (c *Client) SendMessage(message *models.Message) (*models.Message, error) {
-	rawResponse, err := c.ddp.Call("sendMessage", message)
+	rawResponse, err := c.call("sendMessage", message)
 	if err != nil {
 		return nil, err
 	}
@@ -78,7 +139,7 @@ func (c *Client) SendMessage(message *models.Message) (*models.Message, error) {
 //
 // https://rocket.chat/docs/developer-guides/realtime-api/method-calls/update-message
 func (c *Client) EditMessage(message *models.Message) error {
-	_, err := c.ddp.Call("updateMessage", message)
+	_, err := c.call("updateMessage", message)
 	if err != nil {
 		return err
 	}
@@ -91,7 +152,7 @@ func (c *Client) EditMessage(message *models.Message) error {
 //
 // https://rocket.chat/docs/developer-guides/realtime-api/method-calls/delete-message
 func (c *Client) DeleteMessage(message *models.Message) error {
-	_, err := c.ddp.Call("deleteMessage", map[string]string{
+	_, err := c.call("deleteMessage", map[string]string{
 		"_id": message.ID,
 	})
 	if err != nil {
@@ -106,7 +167,7 @@ func (c *Client) DeleteMessage(message *models.Message) error {
 //
 // https://rocket.chat/docs/developer-guides/realtime-api/method-calls/set-reaction
 func (c *Client) ReactToMessage(message *models.Message, reaction string) error {
-	_, err := c.ddp.Call("setReaction", reaction, message.ID)
+	_, err := c.call("setReaction", reaction, message.ID)
 	if err != nil {
 		return err
 	}
@@ -119,7 +180,7 @@ func (c *Client) ReactToMessage(message *models.Message, reaction string) error
 //
 // https://rocket.chat/docs/developer-guides/realtime-api/method-calls/star-message
 func (c *Client) StarMessage(message *models.Message) error {
-	_, err := c.ddp.Call("starMessage", map[string]interface{}{
+	_, err := c.call("starMessage", map[string]interface{}{
 		"_id":     message.ID,
 		"rid":     message.RoomID,
 		"starred": true,
@@ -137,7 +198,7 @@ func (c *Client) StarMessage(message *models.Message) error {
 //
 // https://rocket.chat/docs/developer-guides/realtime-api/method-calls/star-message
 func (c *Client) UnStarMessage(message *models.Message) error {
-	_, err := c.ddp.Call("starMessage", map[string]interface{}{
+	_, err := c.call("starMessage", map[string]interface{}{
 		"_id":     message.ID,
 		"rid":     message.RoomID,
 		"starred": false,
@@ -155,7 +216,7 @@ func (c *Client) UnStarMessage(message *models.Message) error {
 //
 // https://rocket.chat/docs/developer-guides/realtime-api/method-calls/pin-message
 func (c *Client) PinMessage(message *models.Message) error {
-	_, err := c.ddp.Call("pinMessage", message)
+	_, err := c.call("pinMessage", message)
 
 	if err != nil {
 		return err
@@ -169,7 +230,7 @@ func (c *Client) PinMessage(message *models.Message) error {
 //
 // https://rocket.chat/docs/developer-guides/realtime-api/method-calls/unpin-messages
 func (c *Client) UnPinMessage(message *models.Message) error {
-	_, err := c.ddp.Call("unpinMessage", message)
+	_, err := c.call("unpinMessage", message)
 
 	if err != nil {
 		return err
@@ -183,18 +244,39 @@ func (c *Client) UnPinMessage(message *models.Message) error {
 //
 // https://rocket.chat/docs/developer-guides/realtime-api/subscriptions/stream-room-messages/
 func (c *Client) SubscribeToMessageStream(channel *models.Channel, msgChannel chan models.Message) error {
+	if c.ddp == nil {
+		return ErrNoWebsocket
+	}
 	if err := c.ddp.Sub("stream-room-messages", channel.ID, sendAddedEvent); err != nil {
 		return err
 	}
 
-	if !messageListenerAdded {
+	if !c.messageListenerAdded {
 		c.ddp.CollectionByName("stream-room-messages").AddUpdateListener(messageExtractor{msgChannel, "update"})
-		messageListenerAdded = true
+		c.messageListenerAdded = true
 	}
 
 	return nil
 }
 
+// SubscribeToMessageDeletions calls the listener with the ID of every message deleted in the
+// room. The listener must not block or call methods of the client.
+//
+// https://developer.rocket.chat/reference/api/realtime-api/subscriptions/stream-notify-room
+func (c *Client) SubscribeToMessageDeletions(roomID string, listener func(messageID string)) error {
+	return c.SubscribeToStream("stream-notify-room", roomID+"/deleteMessage", func(args []interface{}) {
+		if len(args) < 1 {
+			return
+		}
+
+		document, err := gabs.Consume(args[0])
+		if err != nil {
+			return
+		}
+		listener(stringOrZero(document.Path("_id").Data()))
+	})
+}
+
 func getMessagesFromUpdateEvent(update ddp.Update) []models.Message {
 	document, _ := gabs.Consume(update["args"])
 	args, err := document.Children()
@@ -219,26 +301,88 @@ func getMessageFromData(data interface{}) *models.Message {
 	return getMessageFromDocument(document)
 }
 
+// getMessageFromDocument decodes all fields of a message, e.g. the attachments, so it can
+// replace a stored message. Messages which don't fit the model are read field by field.
 func getMessageFromDocument(arg *gabs.Container) *models.Message {
-	var ts *time.Time
-	date := stringOrZero(arg.Path("ts.$date").Data())
-	if len(date) > 0 {
-		if ti, err := strconv.ParseFloat(date, 64); err == nil {
-			t := time.Unix(int64(ti)/1e3, int64(ti)%1e3)
-			ts = &t
+	data := arg.Data()
+	// The editor is a user, older servers send only the username.
+	if document, ok := data.(map[string]interface{}); ok {
+		if editedBy, ok := document["editedBy"].(map[string]interface{}); ok {
+			copied := make(map[string]interface{}, len(document))
+			for key, value := range document {
+				copied[key] = value
+			}
+			copied["editedBy"] = editedBy["username"]
+			data = copied
 		}
 	}
-	return &models.Message{
+
+	message := &models.Message{}
+	if err := decodeDocument(data, message); err != nil {
+		log.Printf("message is in an unexpected format: %v", err)
+		return getMessageFieldsFromDocument(arg)
+	}
+	return message
+}
+
+func getMessageFieldsFromDocument(arg *gabs.Container) *models.Message {
+	message := &models.Message{
 		ID:        stringOrZero(arg.Path("_id").Data()),
 		RoomID:    stringOrZero(arg.Path("rid").Data()),
 		Msg:       stringOrZero(arg.Path("msg").Data()),
 		Type:      stringOrZero(arg.Path("t").Data()),
-		Timestamp: ts,
-		User: &models.User{
-			ID:       stringOrZero(arg.Path("u._id").Data()),
-			UserName: stringOrZero(arg.Path("u.username").Data()),
-		},
+		Timestamp: getTimeFromDocument(arg, "ts.$date"),
+		UpdatedAt: getTimeFromDocument(arg, "_updatedAt.$date"),
+		EditedAt:  getTimeFromDocument(arg, "editedAt.$date"),
+		User:      getUserFromDocument(arg.Path("u")),
+	}
+
+	// The editor is a user, older servers send only the username.
+	if editedBy, ok := arg.Path("editedBy").Data().(string); ok {
+		message.EditedBy = editedBy
+	} else {
+		message.EditedBy = stringOrZero(arg.Path("editedBy.username").Data())
+	}
+
+	if mentions, err := arg.Path("mentions").Children(); err == nil {
+		for _, mention := range mentions {
+			message.Mentions = append(message.Mentions, *getUserFromDocument(mention))
+		}
+	}
+
+	if blocks := arg.Path("blocks").Data(); blocks != nil {
+		if raw, err := json.Marshal(blocks); err == nil {
+			if err := json.Unmarshal(raw, &message.Blocks); err != nil {
+				log.Printf("message blocks are in an unexpected format: %v", err)
+			}
+		}
+	}
+
+	return message
+}
+
+// getTimeFromDocument reads an EJSON date given in milliseconds since epoch.
+func getTimeFromDocument(document *gabs.Container, path string) *time.Time {
+	ms, ok := document.Path(path).Data().(float64)
+	if !ok {
+		return nil
+	}
+
+	t := time.Unix(int64(ms)/1e3, (int64(ms)%1e3)*int64(time.Millisecond))
+	return &t
+}
+
+func stringsOrNil(i interface{}) []string {
+	values, ok := i.([]interface{})
+	if !ok {
+		return nil
+	}
+
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		result = append(result, stringOrZero(value))
 	}
+	return result
 }
 
 func stringOrZero(i interface{}) string {
@@ -1,7 +1,9 @@
 package realtime
 
 import (
+	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/yazver/Rocket.Chat.Go.SDK/models"
@@ -64,3 +66,27 @@ func TestClient_SubscribeToMessageStream_UnknownChannel(t *testing.T) {
 
 	assert.NotNil(t, err, "Function didn't return error")
 }
+
+func TestGetMessageFromData(t *testing.T) {
+	data := map[string]interface{}{}
+	raw := `{
+		"_id": "msg", "rid": "GENERAL", "msg": "hi @jane", "ts": {"$date": 1600000000000},
+		"u": {"_id": "uid", "username": "john", "name": "John"},
+		"mentions": [{"_id": "jid", "username": "jane"}],
+		"blocks": [{"type": "section", "text": {"type": "mrkdwn", "text": "*hi*"}}],
+		"editedAt": {"$date": 1600000060000}, "editedBy": {"_id": "uid", "username": "john"},
+		"alias": "Johnny", "attachments": [{"title": "report.txt", "title_link": "/file-upload/f/report.txt", "ts": {"$date": 1600000000000}}]
+	}`
+	assert.Nil(t, json.Unmarshal([]byte(raw), &data))
+
+	message := getMessageFromData(data)
+	assert.Equal(t, "msg", message.ID)
+	assert.Equal(t, "John", message.User.Name)
+	assert.Equal(t, "jane", message.Mentions[0].UserName)
+	assert.Equal(t, models.Blocks{models.SectionBlock{Text: models.NewMarkdownText("*hi*")}}, message.Blocks)
+	assert.Equal(t, time.Unix(1600000000, 0).UTC(), *message.Timestamp)
+	assert.Equal(t, time.Unix(1600000060, 0).UTC(), *message.EditedAt)
+	assert.Equal(t, "john", message.EditedBy)
+	assert.Equal(t, "Johnny", message.Alias)
+	assert.Equal(t, "/file-upload/f/report.txt", message.Attachments[0].TitleLink, "stream messages have to be complete")
+}
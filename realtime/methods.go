@@ -0,0 +1,82 @@
+package realtime
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/gopackage/ddp"
+
+	"github.com/yazver/Rocket.Chat.Go.SDK/models"
+)
+
+// ErrNoWebsocket is returned by subscriptions of a client which calls methods without websocket.
+var ErrNoWebsocket = errors.New("the client has no websocket")
+
+// MethodCaller calls DDP methods. The result is decoded JSON like a DDP result, on an error it's
+// the decoded error object. A rest.Client calls the methods over REST.
+type MethodCaller interface {
+	CallMethod(method string, params ...interface{}) (interface{}, error)
+}
+
+// websocketCaller calls the methods over the DDP websocket.
+type websocketCaller struct {
+	ddp *ddp.Client
+}
+
+func (w websocketCaller) CallMethod(method string, params ...interface{}) (interface{}, error) {
+	return w.ddp.Call(method, params...)
+}
+
+// NewMethodClient creates a client which calls all methods with the caller instead of a
+// websocket, usually a rest.Client using method.call. It works behind proxies which block
+// websockets, but subscriptions return ErrNoWebsocket.
+func NewMethodClient(caller MethodCaller) (*Client, error) {
+	sf, err := newSonyflake()
+	if err != nil {
+		return nil, err
+	}
+	return &Client{sf: sf, methods: caller}, nil
+}
+
+// SetMethodCaller chooses how the methods of the client are called, e.g. with a rest.Client over
+// REST while the websocket is kept for subscriptions. nil calls them over the websocket again.
+func (c *Client) SetMethodCaller(caller MethodCaller) {
+	c.methodsMu.Lock()
+	defer c.methodsMu.Unlock()
+
+	if caller == nil && c.ddp != nil {
+		caller = websocketCaller{c.ddp}
+	}
+	c.methods = caller
+}
+
+func (c *Client) call(method string, params ...interface{}) (interface{}, error) {
+	c.methodsMu.Lock()
+	caller := c.methods
+	c.methodsMu.Unlock()
+
+	// Other callers use method.call, which older servers don't have.
+	if _, ok := caller.(websocketCaller); !ok {
+		if err := c.require(models.CapabilityMethodCall); err != nil {
+			return nil, fmt.Errorf("call method %s: %w", method, err)
+		}
+	}
+	return caller.CallMethod(method, params...)
+}
+
+// versioner is a MethodCaller which knows the server version, e.g. a rest.Client.
+type versioner interface {
+	ServerVersion() (models.Version, error)
+}
+
+// serverInfoURL is the public endpoint telling the version, it's nil for method clients.
+func (c *Client) serverInfoURL() *url.URL {
+	if c.serverURL == nil {
+		return nil
+	}
+	u := *c.serverURL
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/api/info"
+	return &u
+}
@@ -0,0 +1,108 @@
+package realtime
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yazver/Rocket.Chat.Go.SDK/fakeserver"
+	"github.com/yazver/Rocket.Chat.Go.SDK/models"
+	"github.com/yazver/Rocket.Chat.Go.SDK/rest"
+)
+
+func TestMethodClient(t *testing.T) {
+	server := fakeserver.New()
+	defer server.Close()
+	server.AddUser("bot", "pass", "bot")
+	general := server.Room(fakeserver.GeneralRoomID)
+
+	client, err := NewMethodClient(rest.NewClient(server.URL(), false))
+	require.NoError(t, err)
+	defer client.Close()
+
+	user, err := client.Login(&models.UserCredentials{Username: "bot", Password: "pass"})
+	require.NoError(t, err)
+	assert.NotEmpty(t, user.Token)
+
+	sent, err := client.SendMessage(client.NewMessage(general, "over rest"))
+	require.NoError(t, err)
+	assert.Equal(t, "over rest", sent.Msg)
+
+	history, err := client.LoadHistory(general.ID)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, "over rest", history[0].Msg)
+	require.NotNil(t, history[0].Timestamp)
+
+	_, err = client.GetChannelRoles(general.ID)
+	assert.NoError(t, err)
+
+	version, err := client.ServerVersion()
+	require.NoError(t, err)
+	assert.Equal(t, fakeserver.Version, version.String())
+
+	err = client.SubscribeToMessageStream(general, make(chan models.Message))
+	assert.True(t, errors.Is(err, ErrNoWebsocket))
+
+	_, err = client.call("unknownMethod")
+	var methodErr *rest.MethodError
+	require.True(t, errors.As(err, &methodErr))
+	assert.Equal(t, float64(404), methodErr.Code)
+}
+
+func TestMethodClient_Session(t *testing.T) {
+	server := fakeserver.New()
+	defer server.Close()
+	bot := server.AddUser("bot", "pass", "bot")
+	server.AddUser("alice", "pass")
+
+	restClient := rest.NewClient(server.URL(), false)
+	_, err := restClient.Login(&models.UserCredentials{Username: "alice", Password: "pass"})
+	require.NoError(t, err)
+
+	client, err := NewMethodClient(restClient)
+	require.NoError(t, err)
+	defer client.Close()
+	user, err := client.Login(&models.UserCredentials{Username: "bot", Password: "pass"})
+	require.NoError(t, err)
+
+	// The session of the rest client has the token of the method login, not the one of alice.
+	current := restClient.Session.Credentials()
+	assert.Equal(t, bot.ID, current.ID)
+	assert.Equal(t, user.Token, current.Token)
+	assert.Equal(t, "bot", current.Username)
+	me, err := restClient.Me()
+	require.NoError(t, err)
+	assert.Equal(t, "bot", me.UserName)
+
+	// A shared session keeps the login data, so it can log in again.
+	client.Session = restClient.Session
+	_, err = client.Login(&models.UserCredentials{Username: "bot", Password: "pass"})
+	require.NoError(t, err)
+	assert.True(t, restClient.Session.CanLogin())
+}
+
+func TestClient_SetMethodCaller(t *testing.T) {
+	server := fakeserver.New()
+	defer server.Close()
+	server.AddUser("bot", "pass", "bot")
+
+	restClient := rest.NewClient(server.URL(), false)
+	_, err := restClient.Login(&models.UserCredentials{Username: "bot", Password: "pass"})
+	require.NoError(t, err)
+
+	client, err := NewClient(server.URL(), false)
+	require.NoError(t, err)
+	defer client.Close()
+
+	client.SetMethodCaller(restClient)
+	id, err := client.GetChannelID("general")
+	require.NoError(t, err)
+	assert.Equal(t, fakeserver.GeneralRoomID, id)
+
+	client.SetMethodCaller(nil)
+	_, err = client.GetChannelID("general")
+	assert.Error(t, err, "the websocket isn't logged in")
+}
@@ -1,8 +1,6 @@
 package realtime
 
 import (
-	"log"
-
 	"github.com/Jeffail/gabs"
 	"github.com/yazver/Rocket.Chat.Go.SDK/models"
 )
@@ -11,7 +9,7 @@ import (
 //
 // https://rocket.chat/docs/developer-guides/realtime-api/method-calls/get-permissions
 func (c *Client) GetPermissions() ([]models.Permission, error) {
-	rawResponse, err := c.ddp.Call("permissions/get")
+	rawResponse, err := c.call("permissions/get")
 	if err != nil {
 		return nil, err
 	}
@@ -23,38 +21,89 @@ func (c *Client) GetPermissions() ([]models.Permission, error) {
 	permissions := make([]models.Permission, 0, len(perms))
 
 	for _, permission := range perms {
-		var roles []string
-		for _, role := range permission.Path("roles").Data().([]interface{}) {
-			roles = append(roles, role.(string))
-		}
-
-		permissions = append(permissions, models.Permission{
-			ID:    stringOrZero(permission.Path("_id").Data()),
-			Roles: roles,
-		})
+		permissions = append(permissions, *getPermissionFromDocument(permission))
 	}
 
 	return permissions, nil
 }
 
-// GetUserRoles gets current users roles
+func getPermissionFromDocument(document *gabs.Container) *models.Permission {
+	permission := &models.Permission{
+		ID:        stringOrZero(document.Path("_id").Data()),
+		UpdatedAt: getTimeFromDocument(document, "_updatedAt.$date"),
+		Roles:     stringsOrNil(document.Path("roles").Data()),
+		Group:     stringOrZero(document.Path("group").Data()),
+		Level:     stringOrZero(document.Path("level").Data()),
+		SettingID: stringOrZero(document.Path("settingId").Data()),
+	}
+	return permission
+}
+
+// SubscribeToPermissionChanges calls the listener for every changed permission.
+// The listener must not block or call methods of the client.
+//
+// https://developer.rocket.chat/reference/api/realtime-api/subscriptions/stream-notify-logged
+func (c *Client) SubscribeToPermissionChanges(listener func(change models.PermissionChange)) error {
+	return c.SubscribeToStream("stream-notify-logged", "permissions-changed", func(args []interface{}) {
+		if len(args) < 2 {
+			return
+		}
+
+		document, err := gabs.Consume(args[1])
+		if err != nil {
+			return
+		}
+		listener(models.PermissionChange{Action: stringOrZero(args[0]), Permission: *getPermissionFromDocument(document)})
+	})
+}
+
+// SubscribeToRoleChanges calls the listener when a role was given to a user or taken away.
+// The listener must not block or call methods of the client.
+//
+// https://developer.rocket.chat/reference/api/realtime-api/subscriptions/stream-notify-logged
+func (c *Client) SubscribeToRoleChanges(listener func(change models.RoleChange)) error {
+	return c.SubscribeToStream("stream-notify-logged", "roles-change", func(args []interface{}) {
+		if len(args) < 1 {
+			return
+		}
+
+		document, err := gabs.Consume(args[0])
+		if err != nil {
+			return
+		}
+
+		change := models.RoleChange{
+			Type:     stringOrZero(document.Path("type").Data()),
+			RoleName: stringOrZero(document.Path("_id").Data()),
+			Scope:    stringOrZero(document.Path("scope").Data()),
+		}
+		if document.Exists("u") {
+			change.User = getUserFromDocument(document.Path("u"))
+		}
+		listener(change)
+	})
+}
+
+// GetUserRoles gets the users having roles besides the default ones, with their roles.
 //
 // https://rocket.chat/docs/developer-guides/realtime-api/method-calls/get-user-roles
-func (c *Client) GetUserRoles() error {
-	rawResponse, err := c.ddp.Call("getUserRoles")
+func (c *Client) GetUserRoles() ([]models.User, error) {
+	rawResponse, err := c.call("getUserRoles")
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	document, _ := gabs.Consume(rawResponse)
 
-	roles, err := document.Children()
+	rawUsers, err := document.Children()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// TODO: Figure out if this function is even useful if so return it
-	log.Println(roles)
+	users := make([]models.User, 0, len(rawUsers))
+	for _, user := range rawUsers {
+		users = append(users, *getUserFromDocument(user))
+	}
 
-	return nil
+	return users, nil
 }
@@ -1,9 +1,8 @@
 package realtime
 
 import (
-	"log"
+	"fmt"
 
-	"github.com/Jeffail/gabs"
 	"github.com/yazver/Rocket.Chat.Go.SDK/models"
 )
 
@@ -11,44 +10,15 @@ import (
 //
 // https://rocket.chat/docs/developer-guides/realtime-api/method-calls/get-public-settings
 func (c *Client) GetPublicSettings() ([]models.Setting, error) {
-	rawResponse, err := c.ddp.Call("public-settings/get")
+	rawResponse, err := c.call("public-settings/get")
 	if err != nil {
 		return nil, err
 	}
 
-	document, _ := gabs.Consume(rawResponse)
-
-	sett, _ := document.Children()
-
-	settings := make([]models.Setting, 0, len(sett))
-
-	for _, rawSetting := range sett {
-		setting := models.Setting{
-			ID:   stringOrZero(rawSetting.Path("_id").Data()),
-			Type: stringOrZero(rawSetting.Path("type").Data()),
-		}
-
-		switch setting.Type {
-		case "boolean":
-			setting.ValueBool = rawSetting.Path("value").Data().(bool)
-		case "string":
-			setting.Value = stringOrZero(rawSetting.Path("value").Data())
-		case "code":
-			setting.Value = stringOrZero(rawSetting.Path("value").Data())
-		case "color":
-			setting.Value = stringOrZero(rawSetting.Path("value").Data())
-		case "int":
-			setting.ValueInt = rawSetting.Path("value").Data().(float64)
-		case "asset":
-			setting.ValueAsset = models.Asset{
-				DefaultUrl: stringOrZero(rawSetting.Path("value").Data().(map[string]interface{})["defaultUrl"]),
-			}
-
-		default:
-			log.Println(setting.Type, rawSetting.Path("value").Data())
-		}
-
-		settings = append(settings, setting)
+	// The settings are decoded like the rest ones, so values of every type are kept.
+	var settings []models.Setting
+	if err := decodeDocument(rawResponse, &settings); err != nil {
+		return nil, fmt.Errorf("decoding public settings: %w", err)
 	}
 
 	return settings, nil
@@ -12,6 +12,9 @@ import (
 //
 // https://rocket.chat/docs/developer-guides/realtime-api/subscriptions/stream-room-messages/
 func (c *Client) Sub(name string, args ...interface{}) (chan string, error) {
+	if c.ddp == nil {
+		return nil, ErrNoWebsocket
+	}
 	if args == nil {
 		log.Println("no args passed")
 		if err := c.ddp.Sub(name); err != nil {
@@ -37,3 +40,32 @@ func (u genericExtractor) CollectionUpdate(collection, operation, id string, doc
 		u.messageChannel <- fmt.Sprintf("%s -> update", collection)
 	}
 }
+
+// SubscribeToStream subscribes to an event of a notification stream, e.g. "permissions-changed" of
+// "stream-notify-logged". The listener gets the arguments of every event. It's called by the
+// connection's message loop, so it must not block or call methods of the client.
+func (c *Client) SubscribeToStream(stream, event string, listener func(args []interface{})) error {
+	if c.ddp == nil {
+		return ErrNoWebsocket
+	}
+	if err := c.ddp.Sub(stream, event, sendAddedEvent); err != nil {
+		return err
+	}
+
+	c.ddp.CollectionByName(stream).AddUpdateListener(streamListener{event: event, listener: listener})
+	return nil
+}
+
+type streamListener struct {
+	event    string
+	listener func(args []interface{})
+}
+
+func (l streamListener) CollectionUpdate(collection, operation, id string, doc ddp.Update) {
+	if operation != "update" || doc["eventName"] != l.event {
+		return
+	}
+
+	args, _ := doc["args"].([]interface{})
+	l.listener(args)
+}
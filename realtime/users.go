@@ -3,9 +3,12 @@ package realtime
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"fmt"
 	"strconv"
+	"sync/atomic"
 
 	"github.com/Jeffail/gabs"
+	"github.com/yazver/Rocket.Chat.Go.SDK/auth"
 	"github.com/yazver/Rocket.Chat.Go.SDK/models"
 )
 
@@ -18,8 +21,37 @@ type ddpTokenLoginRequest struct {
 	Token string `json:"resume"`
 }
 
+type ddpLDAPLoginRequest struct {
+	LDAP        bool                   `json:"ldap"`
+	Username    string                 `json:"username"`
+	Password    string                 `json:"ldapPass"`
+	LDAPOptions map[string]interface{} `json:"ldapOptions"`
+}
+
+type ddpOAuthLoginRequest struct {
+	ServiceName string `json:"serviceName"`
+	AccessToken string `json:"accessToken"`
+	Secret      string `json:"secret,omitempty"`
+	ExpiresIn   int    `json:"expiresIn,omitempty"`
+}
+
+type ddpSAMLLoginRequest struct {
+	SAML            bool   `json:"saml"`
+	CredentialToken string `json:"credentialToken"`
+}
+
+type ddpTwoFactorLoginRequest struct {
+	TOTP ddpTOTP `json:"totp"`
+}
+
+type ddpTOTP struct {
+	Login interface{} `json:"login"`
+	Code  string      `json:"code"`
+}
+
 type ddpUser struct {
-	Email string `json:"email"`
+	Email    string `json:"email,omitempty"`
+	Username string `json:"username,omitempty"`
 }
 
 type ddpPassword struct {
@@ -30,7 +62,7 @@ type ddpPassword struct {
 // RegisterUser a new user on the server. This function does not need a logged in user. The registered user gets logged in
 // to set its username.
 func (c *Client) RegisterUser(credentials *models.UserCredentials) (*models.User, error) {
-	if _, err := c.ddp.Call("registerUser", credentials); err != nil {
+	if _, err := c.call("registerUser", credentials); err != nil {
 		return nil, err
 	}
 
@@ -39,7 +71,7 @@ func (c *Client) RegisterUser(credentials *models.UserCredentials) (*models.User
 		return nil, err
 	}
 
-	if _, err := c.ddp.Call("setUsername", credentials.Name); err != nil {
+	if _, err := c.call("setUsername", credentials.Name); err != nil {
 		return nil, err
 	}
 
@@ -47,39 +79,128 @@ func (c *Client) RegisterUser(credentials *models.UserCredentials) (*models.User
 }
 
 // Login a user.
-// token shouldn't be nil, otherwise the password and the email are not allowed to be nil.
+// token shouldn't be nil, otherwise the password and the email or the username are not allowed to be nil
+// unless LDAP, OAuth or SAML credentials are set. credentials.LoginMethod may force a method.
+// If the account uses two-factor authentication, Client.TwoFactorCode is asked for the code.
+// The token is stored in the Client Session and a token already known by the Session is resumed.
+// Unlike rest.Client.Login, the returned user only carries the ID, the token and its expiry,
+// the login method doesn't send the profile. Use rest.Client.Me to get it.
 //
 // https://rocket.chat/docs/developer-guides/realtime-api/method-calls/login/
 func (c *Client) Login(credentials *models.UserCredentials) (*models.User, error) {
-	var request interface{}
-	if credentials.Token != "" {
-		request = ddpTokenLoginRequest{
-			Token: credentials.Token,
+	if c.Session == nil {
+		session, err := auth.NewSession(nil, credentials)
+		if err != nil {
+			return nil, err
 		}
+		c.Session = session
 	} else {
-		digest := sha256.Sum256([]byte(credentials.Password))
-		request = ddpLoginRequest{
-			User: ddpUser{Email: credentials.Email},
-			Password: ddpPassword{
-				Digest:    hex.EncodeToString(digest[:]),
-				Algorithm: "sha-256",
-			},
+		c.Session.SetLogin(credentials)
+	}
+
+	// Prefer a token of the session, but fall back to the login data if it was rejected.
+	if current := c.Session.Credentials(); credentials.Token == "" && current.Token != "" {
+		user, err := c.login(&models.UserCredentials{Token: current.Token})
+		if err == nil {
+			credentials.ID, credentials.Token = user.ID, user.Token
+			return user, nil
+		}
+		if !c.Session.CanLogin() {
+			return nil, err
+		}
+		if err := c.Session.ClearToken(current.Token); err != nil {
+			return nil, err
 		}
 	}
 
-	rawResponse, err := c.ddp.Call("login", request)
+	user, err := c.login(credentials)
 	if err != nil {
 		return nil, err
 	}
 
-	user := getUserFromData(rawResponse.(map[string]interface{}))
 	if credentials.Token == "" {
 		credentials.ID, credentials.Token = user.ID, user.Token
 	}
+	if err := c.Session.SetToken(user.ID, user.Token); err != nil {
+		return nil, err
+	}
 
 	return user, nil
 }
 
+func (c *Client) login(credentials *models.UserCredentials) (*models.User, error) {
+	request := loginRequest(credentials)
+
+	rawResponse, err := c.call("login", request)
+	if method, required := twoFactorRequired(rawResponse); err != nil && required && c.TwoFactorCode != nil {
+		code, codeErr := c.TwoFactorCode(method)
+		if codeErr != nil {
+			return nil, fmt.Errorf("getting two-factor code: %w", codeErr)
+		}
+		rawResponse, err = c.call("login", ddpTwoFactorLoginRequest{TOTP: ddpTOTP{Login: request, Code: code}})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	response, ok := rawResponse.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("login response is in an unexpected format: %v", rawResponse)
+	}
+
+	atomic.StoreInt32(&c.loggedIn, 1)
+	return getUserFromData(response), nil
+}
+
+func loginRequest(credentials *models.UserCredentials) interface{} {
+	switch credentials.Method() {
+	case models.LoginMethodToken:
+		return ddpTokenLoginRequest{Token: credentials.Token}
+	case models.LoginMethodLDAP:
+		return ddpLDAPLoginRequest{
+			LDAP:        true,
+			Username:    credentials.Login(),
+			Password:    credentials.Password,
+			LDAPOptions: map[string]interface{}{},
+		}
+	case models.LoginMethodOAuth:
+		return ddpOAuthLoginRequest{
+			ServiceName: credentials.ServiceName,
+			AccessToken: credentials.AccessToken,
+			Secret:      credentials.AccessTokenSecret,
+			ExpiresIn:   credentials.ExpiresIn,
+		}
+	case models.LoginMethodSAML:
+		return ddpSAMLLoginRequest{SAML: true, CredentialToken: credentials.SAMLCredentialToken}
+	case models.LoginMethodUsername:
+		return ddpLoginRequest{User: ddpUser{Username: credentials.Username}, Password: ddpPasswordDigest(credentials.Password)}
+	default:
+		return ddpLoginRequest{User: ddpUser{Email: credentials.Email}, Password: ddpPasswordDigest(credentials.Password)}
+	}
+}
+
+func ddpPasswordDigest(password string) ddpPassword {
+	digest := sha256.Sum256([]byte(password))
+	return ddpPassword{
+		Digest:    hex.EncodeToString(digest[:]),
+		Algorithm: "sha-256",
+	}
+}
+
+// twoFactorRequired checks if the reply of a failed call is a totp-required error.
+func twoFactorRequired(reply interface{}) (models.TwoFactorMethod, bool) {
+	document, err := gabs.Consume(reply)
+	if err != nil || stringOrZero(document.Path("error").Data()) != "totp-required" {
+		return "", false
+	}
+
+	method := models.TwoFactorMethod(stringOrZero(document.Path("details.method").Data()))
+	if method == "" {
+		method = models.TwoFactorMethodTOTP
+	}
+	return method, true
+}
+
 func getUserFromData(data interface{}) *models.User {
 	document, _ := gabs.Consume(data)
 
@@ -91,9 +212,47 @@ func getUserFromData(data interface{}) *models.User {
 	}
 }
 
+// getUserFromDocument reads a user document or the user reference of a message or a subscription.
+func getUserFromDocument(document *gabs.Container) *models.User {
+	user := &models.User{
+		ID:         stringOrZero(document.Path("_id").Data()),
+		Name:       stringOrZero(document.Path("name").Data()),
+		UserName:   stringOrZero(document.Path("username").Data()),
+		Status:     stringOrZero(document.Path("status").Data()),
+		StatusText: stringOrZero(document.Path("statusText").Data()),
+		Type:       stringOrZero(document.Path("type").Data()),
+		AvatarETag: stringOrZero(document.Path("avatarETag").Data()),
+	}
+
+	if active, ok := document.Path("active").Data().(bool); ok {
+		user.Active = active
+	}
+	if offset, ok := document.Path("utcOffset").Data().(float64); ok {
+		user.UTCOffset = offset
+	}
+	user.Roles = stringsOrNil(document.Path("roles").Data())
+	if emails, err := document.Path("emails").Children(); err == nil {
+		for _, email := range emails {
+			verified, _ := email.Path("verified").Data().(bool)
+			user.Emails = append(user.Emails, models.UserEmail{
+				Address:  stringOrZero(email.Path("address").Data()),
+				Verified: verified,
+			})
+		}
+	}
+	if fields, err := document.Path("customFields").ChildrenMap(); err == nil && len(fields) > 0 {
+		user.CustomFields = make(map[string]interface{}, len(fields))
+		for key, value := range fields {
+			user.CustomFields[key] = value.Data()
+		}
+	}
+
+	return user
+}
+
 // SetPresence set user presence.
 func (c *Client) SetPresence(status string) error {
-	_, err := c.ddp.Call("UserPresence:setDefaultStatus", status)
+	_, err := c.call("UserPresence:setDefaultStatus", status)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,31 @@
+package realtime
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yazver/Rocket.Chat.Go.SDK/fakeserver"
+	"github.com/yazver/Rocket.Chat.Go.SDK/models"
+)
+
+func TestClient_Login(t *testing.T) {
+	server := fakeserver.New()
+	defer server.Close()
+	bot := server.AddUser("bot", "pass")
+
+	c, err := NewClient(server.URL(), false)
+	require.NoError(t, err)
+	defer c.Close()
+
+	user, err := c.Login(&models.UserCredentials{Username: "bot", Password: "pass"})
+	require.NoError(t, err)
+	assert.Equal(t, bot.ID, user.ID)
+	assert.NotEmpty(t, user.Token)
+
+	server.HandleMethod("login", func(userID string, params []interface{}) (interface{}, error) {
+		return "logged in", nil
+	})
+	_, err = c.Login(&models.UserCredentials{Username: "bot", Password: "pass"})
+	assert.Error(t, err, "an unexpected response isn't a login")
+}
@@ -0,0 +1,104 @@
+package realtime
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/yazver/Rocket.Chat.Go.SDK/models"
+)
+
+// versionClient requests the version, a server which doesn't answer mustn't block the calls
+// waiting for the version.
+var versionClient = &http.Client{Timeout: 10 * time.Second}
+
+// versionRetryDelay is how long a failed version request is remembered, so the calls checking
+// the version don't all wait for a server which doesn't answer.
+var versionRetryDelay = time.Minute
+
+// ServerVersion returns the version of the server. DDP doesn't tell it, so it's requested once
+// from the public REST endpoint /api/info, or from the method caller, and then remembered, a
+// failure for a minute.
+func (c *Client) ServerVersion() (models.Version, error) {
+	c.versionMu.Lock()
+	defer c.versionMu.Unlock()
+
+	if c.version != nil {
+		return *c.version, nil
+	}
+	if c.versionErr != nil && time.Now().Before(c.versionRetry) {
+		return models.Version{}, c.versionErr
+	}
+
+	version, err := c.requestServerVersion()
+	if err != nil {
+		c.versionErr, c.versionRetry = err, time.Now().Add(versionRetryDelay)
+		return models.Version{}, err
+	}
+	c.version, c.versionErr = &version, nil
+	return version, nil
+}
+
+func (c *Client) requestServerVersion() (models.Version, error) {
+	infoURL := c.serverInfoURL()
+	if infoURL == nil {
+		c.methodsMu.Lock()
+		v, ok := c.methods.(versioner)
+		c.methodsMu.Unlock()
+		if !ok {
+			return models.Version{}, errors.New("getting server version: unknown server")
+		}
+
+		return v.ServerVersion()
+	}
+
+	resp, err := versionClient.Get(infoURL.String())
+	if err != nil {
+		return models.Version{}, fmt.Errorf("getting server version: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return models.Version{}, errors.New("getting server version: request error: " + resp.Status)
+	}
+
+	var info struct {
+		Version string `json:"version"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return models.Version{}, fmt.Errorf("getting server version: %w", err)
+	}
+	version, err := models.ParseVersion(info.Version)
+	if err != nil {
+		return models.Version{}, fmt.Errorf("getting server version: %w", err)
+	}
+	return version, nil
+}
+
+// SetServerVersion sets the version of the server instead of requesting it, e.g. if a rest.Client
+// knows it already.
+func (c *Client) SetServerVersion(version models.Version) {
+	c.versionMu.Lock()
+	defer c.versionMu.Unlock()
+	c.version, c.versionErr = &version, nil
+}
+
+// Supports tells if the server has the capability. If the version can't be determined,
+// the capability is assumed to be there.
+func (c *Client) Supports(capability models.Capability) bool {
+	return c.require(capability) == nil
+}
+
+// require returns a models.UnsupportedError if the server lacks the capability.
+func (c *Client) require(capability models.Capability) error {
+	version, err := c.ServerVersion()
+	if err != nil {
+		if c.debug {
+			log.Println(err)
+		}
+		return nil
+	}
+	return version.Require(capability)
+}
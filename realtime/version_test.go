@@ -0,0 +1,76 @@
+package realtime
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yazver/Rocket.Chat.Go.SDK/fakeserver"
+	"github.com/yazver/Rocket.Chat.Go.SDK/models"
+	"github.com/yazver/Rocket.Chat.Go.SDK/rest"
+)
+
+func TestClient_ServerVersion(t *testing.T) {
+	status, requests := http.StatusOK, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		assert.Equal(t, "/chat/api/info", r.URL.Path)
+		w.WriteHeader(status)
+		fmt.Fprint(w, `{"success":true,"version":"3.18.2"}`)
+	}))
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL + "/chat/")
+	client := &Client{serverURL: serverURL}
+
+	status = http.StatusServiceUnavailable
+	_, err := client.ServerVersion()
+	assert.Error(t, err, "the status has to be checked")
+
+	status = http.StatusOK
+	_, err = client.ServerVersion()
+	assert.Error(t, err, "the failure is remembered")
+	assert.Equal(t, 1, requests)
+
+	client.versionRetry = time.Now()
+	version, err := client.ServerVersion()
+	require.NoError(t, err)
+	assert.Equal(t, "3.18.2", version.String())
+	assert.Equal(t, 2, requests)
+}
+
+func TestClient_Supports(t *testing.T) {
+	server := fakeserver.New()
+	defer server.Close()
+	server.AddUser("bot", "pass", "bot")
+
+	restClient := rest.NewClient(server.URL(), false)
+	client, err := NewMethodClient(restClient)
+	require.NoError(t, err)
+	_, err = client.Login(&models.UserCredentials{Username: "bot", Password: "pass"})
+	require.NoError(t, err)
+
+	rooms, err := client.GetTeamRooms("team")
+	require.NoError(t, err)
+	assert.Empty(t, rooms)
+
+	old, err := models.ParseVersion("3.12.0")
+	require.NoError(t, err)
+	client.SetServerVersion(old)
+	assert.False(t, client.Supports(models.CapabilityTeams))
+	_, err = client.GetTeamRooms("team")
+	assert.True(t, errors.Is(err, models.ErrUnsupported))
+
+	older, err := models.ParseVersion("2.3.0")
+	require.NoError(t, err)
+	client.SetServerVersion(older)
+	_, err = client.GetChannelID("general")
+	assert.True(t, errors.Is(err, models.ErrUnsupported), "method.call needs 2.4")
+}
@@ -0,0 +1,168 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strings"
+
+	"github.com/yazver/Rocket.Chat.Go.SDK/models"
+)
+
+var (
+	ErrNotImage = errors.New("data is not an image")
+)
+
+// detectImageType returns the MIME type of the image data.
+func detectImageType(data []byte) (string, error) {
+	contentType := http.DetectContentType(data)
+	if !strings.HasPrefix(contentType, "image/") {
+		// SVG is detected as XML or text.
+		head := data
+		if len(head) > 1024 {
+			head = head[:1024]
+		}
+		if bytes.Contains(head, []byte("<svg")) {
+			return "image/svg+xml", nil
+		}
+		return "", fmt.Errorf("%w: %s", ErrNotImage, contentType)
+	}
+	return contentType, nil
+}
+
+// UploadUserAvatar uploads image data as avatar of a user. If user is nil the avatar of the
+// logged in user is changed. The image type is detected from the data.
+//
+// https://developer.rocket.chat/reference/api/rest-api/endpoints/core-endpoints/users-endpoints/set-avatar
+func (c *Client) UploadUserAvatar(user *models.User, image io.Reader) error {
+	data, err := ioutil.ReadAll(image)
+	if err != nil {
+		return fmt.Errorf("reading avatar: %w", err)
+	}
+	contentType, err := detectImageType(data)
+	if err != nil {
+		return err
+	}
+
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+
+	if user != nil {
+		key, value, err := userIdentity(user)
+		if err != nil {
+			return err
+		}
+		if err := writer.WriteField(key, value); err != nil {
+			return fmt.Errorf("writing avatar form: %w", err)
+		}
+	}
+
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", `form-data; name="image"; filename="avatar"`)
+	header.Set("Content-Type", contentType)
+	part, err := writer.CreatePart(header)
+	if err != nil {
+		return fmt.Errorf("writing avatar form: %w", err)
+	}
+	if _, err := part.Write(data); err != nil {
+		return fmt.Errorf("writing avatar form: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("writing avatar form: %w", err)
+	}
+
+	if err := c.PostMultipart("users.setAvatar", body, writer.FormDataContentType(), new(Status)); err != nil {
+		return fmt.Errorf("upload user avatar: %w", err)
+	}
+	return nil
+}
+
+// SetRoomAvatar sets image data as avatar of a room. The image type is detected from the data.
+//
+// https://developer.rocket.chat/reference/api/rest-api/endpoints/core-endpoints/rooms-endpoints/save-room-settings
+func (c *Client) SetRoomAvatar(roomID string, image io.Reader) error {
+	data, err := ioutil.ReadAll(image)
+	if err != nil {
+		return fmt.Errorf("reading avatar: %w", err)
+	}
+	contentType, err := detectImageType(data)
+	if err != nil {
+		return err
+	}
+
+	err = c.SaveRoomSettings(roomID, map[string]interface{}{
+		"roomAvatar": "data:" + contentType + ";base64," + base64.StdEncoding.EncodeToString(data),
+	})
+	if err != nil {
+		return fmt.Errorf("set room avatar: %w", err)
+	}
+	return nil
+}
+
+// GetUserAvatar downloads the avatar of the user with the username.
+// Avatars are cached by the client and only downloaded again if their ETag changed.
+func (c *Client) GetUserAvatar(username string) (*models.Avatar, error) {
+	return c.getAvatar("/avatar/" + url.PathEscape(username))
+}
+
+// GetRoomAvatar downloads the avatar of the room.
+// Avatars are cached by the client and only downloaded again if their ETag changed.
+func (c *Client) GetRoomAvatar(roomID string) (*models.Avatar, error) {
+	return c.getAvatar("/avatar/room/" + url.PathEscape(roomID))
+}
+
+func (c *Client) getAvatar(path string) (*models.Avatar, error) {
+	c.avatarsMu.Lock()
+	cached := c.avatars[path]
+	c.avatarsMu.Unlock()
+
+	request, err := http.NewRequest(http.MethodGet, c.getBaseURL()+path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("new request: %w", err)
+	}
+	c.setAuthHeaders(request)
+	if cached != nil && cached.ETag != "" {
+		request.Header.Set("If-None-Match", cached.ETag)
+	}
+
+	if c.Debug {
+		log.Println(request)
+	}
+
+	resp, err := c.httpClient().Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("get avatar: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached != nil:
+		return cached, nil
+	case resp.StatusCode != http.StatusOK:
+		return nil, errors.New("get avatar: request error: " + resp.Status)
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading avatar: %w", err)
+	}
+
+	avatar := &models.Avatar{Data: data, ContentType: resp.Header.Get("Content-Type"), ETag: resp.Header.Get("ETag")}
+	if avatar.ETag != "" {
+		c.avatarsMu.Lock()
+		if c.avatars == nil {
+			c.avatars = make(map[string]*models.Avatar)
+		}
+		c.avatars[path] = avatar
+		c.avatarsMu.Unlock()
+	}
+	return avatar, nil
+}
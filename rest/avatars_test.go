@@ -0,0 +1,64 @@
+package rest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yazver/Rocket.Chat.Go.SDK/models"
+)
+
+var pngHeader = []byte("\x89PNG\x0D\x0A\x1A\x0A\x00\x00\x00\x0DIHDR")
+
+func TestRocket_UploadUserAvatar(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/users.setAvatar", r.URL.Path)
+		file, header, err := r.FormFile("image")
+		assert.Nil(t, err)
+		defer file.Close()
+		assert.Equal(t, "image/png", header.Header.Get("Content-Type"))
+		assert.Equal(t, "bot", r.FormValue("username"))
+		fmt.Fprint(w, `{"success":true}`)
+	}))
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+	client := NewClient(serverURL, false)
+
+	assert.Nil(t, client.UploadUserAvatar(&models.User{UserName: "bot"}, bytes.NewReader(pngHeader)))
+
+	err := client.UploadUserAvatar(nil, bytes.NewBufferString("no image"))
+	assert.True(t, errors.Is(err, ErrNotImage))
+}
+
+func TestRocket_GetUserAvatar(t *testing.T) {
+	downloads := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/avatar/bot", r.URL.Path)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		downloads++
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(pngHeader)
+	}))
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+	client := NewClient(serverURL, false)
+
+	for i := 0; i < 2; i++ {
+		avatar, err := client.GetUserAvatar("bot")
+		assert.Nil(t, err)
+		assert.Equal(t, pngHeader, avatar.Data)
+		assert.Equal(t, "image/png", avatar.ContentType)
+	}
+	assert.Equal(t, 1, downloads)
+}
@@ -2,6 +2,7 @@ package rest
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"net/url"
 
@@ -81,3 +82,62 @@ func (c *Client) GetChannelInfo(channel *models.Channel) (*models.Channel, error
 
 	return &response.Channel, nil
 }
+
+type RoomRolesResponse struct {
+	Status
+	Roles []models.RoomRoles `json:"roles"`
+}
+
+// GetChannelRoles gets the users having roles in the channel, like owners and moderators.
+//
+// https://developer.rocket.chat/reference/api/rest-api/endpoints/core-endpoints/channels-endpoints/roles
+func (c *Client) GetChannelRoles(roomID string) ([]models.RoomRoles, error) {
+	response := new(RoomRolesResponse)
+	if err := c.Get("channels.roles", url.Values{"roomId": []string{roomID}}, response); err != nil {
+		return nil, fmt.Errorf("channel roles: %w", err)
+	}
+	return response.Roles, nil
+}
+
+// CreateChannel creates a public channel, optionally with members.
+//
+// https://developer.rocket.chat/reference/api/rest-api/endpoints/core-endpoints/channels-endpoints/create
+func (c *Client) CreateChannel(req *models.CreateChannelRequest) (*models.Channel, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling channel request data: %w", err)
+	}
+
+	response := new(ChannelResponse)
+	if err := c.Post("channels.create", bytes.NewBuffer(body), response); err != nil {
+		return nil, fmt.Errorf("creating channel: %w", err)
+	}
+	return &response.Channel, nil
+}
+
+// GetChannelMembers lists the users of a channel. It supports the offset, count and sort parameters.
+//
+// https://developer.rocket.chat/reference/api/rest-api/endpoints/core-endpoints/channels-endpoints/members
+func (c *Client) GetChannelMembers(roomID string, params url.Values) (*ChannelMembersResponse, error) {
+	query := url.Values{"roomId": []string{roomID}}
+	for key, values := range params {
+		query[key] = values
+	}
+
+	response := new(ChannelMembersResponse)
+	if err := c.Get("channels.members", query, response); err != nil {
+		return nil, fmt.Errorf("channel members: %w", err)
+	}
+	return response, nil
+}
+
+// ArchiveChannel archives a channel, it becomes read only.
+//
+// https://developer.rocket.chat/reference/api/rest-api/endpoints/core-endpoints/channels-endpoints/archive
+func (c *Client) ArchiveChannel(roomID string) error {
+	var body = fmt.Sprintf(`{ "roomId": "%s"}`, roomID)
+	if err := c.Post("channels.archive", bytes.NewBufferString(body), new(Status)); err != nil {
+		return fmt.Errorf("archiving channel: %w", err)
+	}
+	return nil
+}
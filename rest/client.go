@@ -11,10 +11,17 @@ import (
 	"log"
 	"net/http"
 	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yazver/Rocket.Chat.Go.SDK/auth"
+	"github.com/yazver/Rocket.Chat.Go.SDK/models"
 )
 
 var (
-	ErrResponse = fmt.Errorf("got false response")
+	ErrResponse     = fmt.Errorf("got false response")
+	ErrUnauthorized = errors.New("unauthorized")
 )
 
 type Response interface {
@@ -31,7 +38,33 @@ type Client struct {
 	// Use this switch to see all network communication.
 	Debug bool
 
-	auth *authInfo
+	// HTTPClient makes the requests, http.DefaultClient is used if it's not set.
+	HTTPClient *http.Client
+
+	// TwoFactorCode is asked for a code when the server requires two-factor authentication,
+	// e.g. for logins or sensitive calls. The call is repeated once with the code.
+	TwoFactorCode models.TwoFactorCodeFunc
+
+	// Session keeps the token and the login data. It may be shared with a realtime.Client.
+	// If it's not set, Login creates an in-memory session. When the token is rejected,
+	// the client logs in again once and repeats the call.
+	Session *auth.Session
+
+	// auth is guarded by authMu, loginMu serializes logins after rejected tokens.
+	authMu  sync.RWMutex
+	auth    *authInfo
+	loginMu sync.Mutex
+
+	avatarsMu sync.Mutex
+	avatars   map[string]*models.Avatar
+
+	versionMu sync.Mutex
+	version   *models.Version
+	// versionErr is the failure of the last version request, it's returned until versionRetry.
+	versionErr   error
+	versionRetry time.Time
+
+	methodID uint32
 }
 
 type Status struct {
@@ -88,6 +121,57 @@ func NewClient(serverURL *url.URL, debug bool) *Client {
 	return &Client{Host: serverURL.Hostname(), Path: serverURL.Path, Port: port, Protocol: protocol, Version: "v1", Debug: debug}
 }
 
+// Credentials returns the user ID and the token of the logged in user or nil.
+func (c *Client) Credentials() *models.UserCredentials {
+	auth := c.getAuth()
+	if auth == nil {
+		return nil
+	}
+	return &models.UserCredentials{ID: auth.id, Token: auth.token}
+}
+
+func (c *Client) getAuth() *authInfo {
+	c.authMu.RLock()
+	defer c.authMu.RUnlock()
+	return c.auth
+}
+
+func (c *Client) setAuth(auth *authInfo) {
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
+	c.auth = auth
+}
+
+// relogin replaces a rejected token, either by a token another client of the session
+// obtained meanwhile or by logging in again. The requests of the login don't relogin
+// themselves, so a rejected login can't wait for itself.
+func (c *Client) relogin(rejected *authInfo) error {
+	c.loginMu.Lock()
+	defer c.loginMu.Unlock()
+
+	if c.Session == nil || rejected == nil {
+		return ErrUnauthorized
+	}
+
+	if current := c.Session.Credentials(); current.Token != "" && current.Token != rejected.token {
+		c.setAuth(&authInfo{id: current.ID, token: current.Token})
+		return nil
+	}
+
+	if !c.Session.CanLogin() {
+		return ErrUnauthorized
+	}
+	if err := c.Session.ClearToken(rejected.token); err != nil {
+		return err
+	}
+
+	login := c.Session.Credentials()
+	login.ID, login.Token = "", ""
+	c.setAuth(nil)
+	_, err := c.login(&login, false)
+	return err
+}
+
 func (c *Client) getURL() string {
 	if len(c.Version) == 0 {
 		c.Version = "v1"
@@ -95,32 +179,105 @@ func (c *Client) getURL() string {
 	return fmt.Sprintf("%v://%v:%v%s/api/%s", c.Protocol, c.Host, c.Port, c.Path, c.Version)
 }
 
+func (c *Client) getBaseURL() string {
+	return fmt.Sprintf("%v://%v:%v%s", c.Protocol, c.Host, c.Port, c.Path)
+}
+
 // Get call Get.
 func (c *Client) Get(api string, params url.Values, response Response) error {
-	return c.doRequest(http.MethodGet, api, params, nil, response)
+	return c.doRequest(http.MethodGet, api, params, nil, "", response)
 }
 
 // Post call as JSON.
 func (c *Client) Post(api string, body io.Reader, response Response) error {
-	return c.doRequest(http.MethodPost, api, nil, body, response)
+	return c.doRequest(http.MethodPost, api, nil, body, "", response)
 }
 
 // PostForm call as Form Data.
 func (c *Client) PostForm(api string, params url.Values, response Response) error {
-	return c.doRequest(http.MethodPost, api, params, nil, response)
+	return c.doRequest(http.MethodPost, api, params, nil, "", response)
 }
 
-func (c *Client) doRequest(method, api string, params url.Values, body io.Reader, response Response) error {
-	contentType := "application/x-www-form-urlencoded"
+// PostMultipart call as Multipart Form Data, e.g. to upload files.
+func (c *Client) PostMultipart(api string, body io.Reader, contentType string, response Response) error {
+	return c.doRequest(http.MethodPost, api, nil, body, contentType, response)
+}
+
+func (c *Client) doRequest(method, api string, params url.Values, body io.Reader, contentType string, response Response) error {
+	return c.request(method, api, params, body, contentType, response, true)
+}
+
+// request calls the API, with relogin it logs in again once if the token was rejected.
+func (c *Client) request(method, api string, params url.Values, body io.Reader, contentType string, response Response, relogin bool) error {
+	// The body is kept to be able to repeat the request with a two-factor code.
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = ioutil.ReadAll(body); err != nil {
+			return fmt.Errorf("reading request body: %w", err)
+		}
+	}
+
+	usedAuth := c.getAuth()
+	err := c.do(method, api, params, payload, contentType, response, nil)
+	if relogin && errors.Is(err, ErrUnauthorized) && api != "login" && c.relogin(usedAuth) == nil {
+		err = c.do(method, api, params, payload, contentType, response, nil)
+	}
+
+	var twoFactorErr *TwoFactorError
+	if c.TwoFactorCode == nil || !errors.As(err, &twoFactorErr) || !errors.Is(err, ErrTwoFactorRequired) {
+		return err
+	}
+
+	code, err := c.TwoFactorCode(twoFactorErr.Method)
+	if err != nil {
+		return fmt.Errorf("getting two-factor code: %w", err)
+	}
+	return c.do(method, api, params, payload, contentType, response, &twoFactor{method: twoFactorErr.Method, code: code})
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+func (c *Client) setAuthHeaders(request *http.Request) {
+	if auth := c.getAuth(); auth != nil {
+		request.Header.Set("X-Auth-Token", auth.token)
+		request.Header.Set("X-User-Id", auth.id)
+	}
+}
+
+type twoFactor struct {
+	method models.TwoFactorMethod
+	code   string
+}
+
+func (c *Client) do(method, api string, params url.Values, payload []byte, contentType string, response Response, tf *twoFactor) error {
+	var body io.Reader
 	if method == http.MethodPost {
-		if body != nil {
-			contentType = "application/json"
+		if payload != nil {
+			body = bytes.NewReader(payload)
+			if contentType == "" {
+				contentType = "application/json"
+			}
 		} else if len(params) > 0 {
 			body = bytes.NewBufferString(params.Encode())
 		}
 	}
+	if contentType == "" {
+		contentType = "application/x-www-form-urlencoded"
+	}
+
+	// Endpoints starting with a slash aren't versioned, e.g. /info for /api/info.
+	endpoint := c.getURL() + "/" + api
+	if strings.HasPrefix(api, "/") {
+		endpoint = c.getBaseURL() + "/api" + api
+	}
 
-	request, err := http.NewRequest(method, c.getURL()+"/"+api, body)
+	request, err := http.NewRequest(method, endpoint, body)
 	if err != nil {
 		return fmt.Errorf("new request: %w", err)
 	}
@@ -133,16 +290,18 @@ func (c *Client) doRequest(method, api string, params url.Values, body io.Reader
 		request.Header.Set("Content-Type", contentType)
 	}
 
-	if c.auth != nil {
-		request.Header.Set("X-Auth-Token", c.auth.token)
-		request.Header.Set("X-User-Id", c.auth.id)
+	c.setAuthHeaders(request)
+
+	if tf != nil {
+		request.Header.Set("X-2fa-Code", tf.code)
+		request.Header.Set("X-2fa-Method", string(tf.method))
 	}
 
 	if c.Debug {
 		log.Println(request)
 	}
 
-	resp, err := http.DefaultClient.Do(request)
+	resp, err := c.httpClient().Do(request)
 
 	if err != nil {
 		return fmt.Errorf("do request: %w", err)
@@ -155,12 +314,24 @@ func (c *Client) doRequest(method, api string, params url.Values, body io.Reader
 		log.Println(string(bodyBytes))
 	}
 
+	if resp.StatusCode != http.StatusOK && err == nil {
+		if twoFactorErr := twoFactorErrorFrom(bodyBytes); twoFactorErr != nil {
+			return twoFactorErr
+		}
+	}
+
 	var parse bool
 	if err == nil {
 		if e := json.Unmarshal(bodyBytes, response); e == nil {
 			parse = true
 		}
 	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		if parse {
+			return fmt.Errorf("%w: %v", ErrUnauthorized, response.OK())
+		}
+		return ErrUnauthorized
+	}
 	if resp.StatusCode != http.StatusOK {
 		if parse {
 			return response.OK()
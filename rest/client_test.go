@@ -1,11 +1,12 @@
 package rest
 
 import (
-	"net/url"
+	"os"
 	"testing"
 
-	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/yazver/Rocket.Chat.Go.SDK/common_testing"
+	"github.com/yazver/Rocket.Chat.Go.SDK/fakeserver"
 	"github.com/yazver/Rocket.Chat.Go.SDK/models"
 	"github.com/yazver/Rocket.Chat.Go.SDK/realtime"
 )
@@ -15,8 +16,18 @@ var (
 	testUserEmail string
 	testPassword  = "test"
 	rocketClient  *Client
+
+	// testServer is the fake server of the tests which need a server with users and rooms.
+	testServer *fakeserver.Server
 )
 
+func TestMain(m *testing.M) {
+	testServer = fakeserver.New()
+	code := m.Run()
+	testServer.Close()
+	os.Exit(code)
+}
+
 func getDefaultClient(t *testing.T) *Client {
 	if rocketClient == nil {
 		testUserEmail = common_testing.GetRandomEmail()
@@ -28,18 +39,30 @@ func getDefaultClient(t *testing.T) *Client {
 }
 
 func getAuthenticatedClient(t *testing.T, name, email, password string) *Client {
-	client := Client{Protocol: common_testing.Protocol, Host: common_testing.Host, Port: common_testing.Port}
+	client := NewClient(testServer.URL(), false)
 	credentials := &models.UserCredentials{Name: name, Email: email, Password: password}
 
-	rtClient, err := realtime.NewClient(&url.URL{Host: common_testing.Host + ":" + common_testing.Port}, true)
-	assert.Nil(t, err)
-	_, regErr := rtClient.RegisterUser(credentials)
-	assert.Nil(t, regErr)
+	rtClient, err := realtime.NewClient(testServer.URL(), false)
+	require.NoError(t, err)
+	defer rtClient.Close()
+	_, err = rtClient.RegisterUser(credentials)
+	require.NoError(t, err)
+
+	_, err = client.Login(credentials)
+	require.NoError(t, err)
+
+	return client
+}
 
-	loginErr := client.Login(credentials)
-	assert.Nil(t, loginErr)
+// getAdminClient logs in a new user with the admin role.
+func getAdminClient(t *testing.T) *Client {
+	username := common_testing.GetRandomString()
+	testServer.AddUser(username, testPassword, "admin")
 
-	return &client
+	client := NewClient(testServer.URL(), false)
+	_, err := client.Login(&models.UserCredentials{Username: username, Password: testPassword})
+	require.NoError(t, err)
+	return client
 }
 
 func findMessage(messages []models.Message, user string, msg string) *models.Message {
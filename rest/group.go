@@ -213,3 +213,25 @@ func (c *Client) MessagesGroup(group *models.Group) ([]models.Message, error) {
 	}
 	return response.Messages, nil
 }
+
+// GetGroupRoles gets the users having roles in the private group, like owners and moderators.
+//
+// https://developer.rocket.chat/reference/api/rest-api/endpoints/core-endpoints/groups-endpoints/roles
+func (c *Client) GetGroupRoles(roomID string) ([]models.RoomRoles, error) {
+	response := new(RoomRolesResponse)
+	if err := c.Get("groups.roles", url.Values{"roomId": []string{roomID}}, response); err != nil {
+		return nil, fmt.Errorf("group roles: %w", err)
+	}
+	return response.Roles, nil
+}
+
+// ArchiveGroup archives a private group, it becomes read only.
+//
+// https://developer.rocket.chat/reference/api/rest-api/endpoints/core-endpoints/groups-endpoints/archive
+func (c *Client) ArchiveGroup(roomID string) error {
+	var body = fmt.Sprintf(`{ "roomId": "%s"}`, roomID)
+	if err := c.Post("groups.archive", bytes.NewBufferString(body), new(Status)); err != nil {
+		return fmt.Errorf("archiving group: %w", err)
+	}
+	return nil
+}
@@ -1,29 +1,101 @@
 package rest
 
 import (
+	"fmt"
+	"log"
 	"net/url"
+	"time"
 
 	"github.com/yazver/Rocket.Chat.Go.SDK/models"
 )
 
 type InfoResponse struct {
 	Status
-	Info models.Info `json:"info"`
+	Version string      `json:"version"`
+	Info    models.Info `json:"info"`
 }
 
 // GetServerInfo a simple method, requires no authentication,
 // that returns information about the server including version information.
+// The build and commit details are only sent to users allowed to see them.
 //
-// https://rocket.chat/docs/developer-guides/rest-api/miscellaneous/info
+// https://developer.rocket.chat/reference/api/rest-api/endpoints/other-important-endpoints/info-endpoints/info
 func (c *Client) GetServerInfo() (*models.Info, error) {
 	response := new(InfoResponse)
-	if err := c.Get("info", nil, response); err != nil {
+	if err := c.Get("/info", nil, response); err != nil {
 		return nil, err
 	}
 
+	if response.Info.Version == "" {
+		response.Info.Version = response.Version
+	}
 	return &response.Info, nil
 }
 
+// versionRetryDelay is how long a failed version request is remembered, so the calls checking
+// the version don't all wait for a server which doesn't answer.
+var versionRetryDelay = time.Minute
+
+// ServerVersion returns the version of the server. It's requested once and then remembered,
+// a failure for a minute.
+func (c *Client) ServerVersion() (models.Version, error) {
+	c.versionMu.Lock()
+	defer c.versionMu.Unlock()
+
+	if c.version != nil {
+		return *c.version, nil
+	}
+	if c.versionErr != nil && time.Now().Before(c.versionRetry) {
+		return models.Version{}, c.versionErr
+	}
+
+	version, err := c.requestServerVersion()
+	if err != nil {
+		c.versionErr, c.versionRetry = err, time.Now().Add(versionRetryDelay)
+		return models.Version{}, err
+	}
+	c.version, c.versionErr = &version, nil
+	return version, nil
+}
+
+func (c *Client) requestServerVersion() (models.Version, error) {
+	info, err := c.GetServerInfo()
+	if err != nil {
+		return models.Version{}, fmt.Errorf("getting server version: %w", err)
+	}
+	version, err := models.ParseVersion(info.Version)
+	if err != nil {
+		return models.Version{}, fmt.Errorf("getting server version: %w", err)
+	}
+	return version, nil
+}
+
+// SetServerVersion sets the version of the server instead of requesting it, e.g. if it's
+// already known.
+func (c *Client) SetServerVersion(version models.Version) {
+	c.versionMu.Lock()
+	defer c.versionMu.Unlock()
+	c.version, c.versionErr = &version, nil
+}
+
+// Supports tells if the server has the capability. If the version can't be determined,
+// the capability is assumed to be there.
+func (c *Client) Supports(capability models.Capability) bool {
+	return c.require(capability) == nil
+}
+
+// require returns a models.UnsupportedError if the server lacks the capability.
+func (c *Client) require(capability models.Capability) error {
+	version, err := c.ServerVersion()
+	if err != nil {
+		if c.Debug {
+			log.Println(err)
+		}
+		return nil
+	}
+	return version.Require(capability)
+}
+
 type DirectoryResponse struct {
 	Status
 	models.Directory
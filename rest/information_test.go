@@ -1,15 +1,21 @@
 package rest
 
 import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
-	"github.com/yazver/Rocket.Chat.Go.SDK/common_testing"
+	"github.com/stretchr/testify/require"
+	"github.com/yazver/Rocket.Chat.Go.SDK/models"
 )
 
 func TestRocket_GetServerInfo(t *testing.T) {
-	rocket := Client{Protocol: common_testing.Protocol, Host: common_testing.Host, Port: common_testing.Port}
+	rocket := NewClient(testServer.URL(), false)
 
 	info, err := rocket.GetServerInfo()
 
@@ -18,6 +24,78 @@ func TestRocket_GetServerInfo(t *testing.T) {
 	assert.NotEmpty(t, info.Version)
 }
 
+func TestRocket_ServerVersion(t *testing.T) {
+	var infoRequests int
+	version := "0.72.3"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/info":
+			infoRequests++
+			fmt.Fprintf(w, `{"success":true,"version":%q}`, version)
+		case "/api/v1/permissions.list":
+			fmt.Fprint(w, `{"success":true,"permissions":[{"_id":"view-history","roles":["user"]}]}`)
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+	client := NewClient(serverURL, false)
+
+	v, err := client.ServerVersion()
+	require.NoError(t, err)
+	assert.Equal(t, models.Version{Major: 0, Minor: 72, Patch: 3}, v)
+
+	permissions, err := client.ListAllPermissions(nil)
+	require.NoError(t, err, "older servers get permissions.list")
+	require.Len(t, permissions.Update, 1)
+	assert.Equal(t, "view-history", permissions.Update[0].ID)
+
+	err = client.DeleteRole("role")
+	assert.True(t, errors.Is(err, models.ErrUnsupported))
+	var unsupported *models.UnsupportedError
+	require.True(t, errors.As(err, &unsupported))
+	assert.Equal(t, models.CapabilityRoleIDs, unsupported.Capability)
+	assert.Equal(t, 1, infoRequests, "the version is remembered")
+
+	v, err = models.ParseVersion("v4.0.0-rc.2+build")
+	require.NoError(t, err)
+	client.SetServerVersion(v)
+	assert.True(t, client.Supports(models.CapabilityRoleIDs), "pre-releases have the features of the release")
+	assert.True(t, v.Compare(models.Version{Major: 4}) < 0)
+}
+
+func TestRocket_ServerVersionFailure(t *testing.T) {
+	status, infoRequests := http.StatusServiceUnavailable, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		infoRequests++
+		w.WriteHeader(status)
+		if status != http.StatusOK {
+			fmt.Fprint(w, `{"success":false,"error":"unavailable"}`)
+			return
+		}
+		fmt.Fprint(w, `{"success":true,"version":"3.18.2"}`)
+	}))
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+	client := NewClient(serverURL, false)
+
+	_, err := client.ServerVersion()
+	assert.Error(t, err)
+	assert.True(t, client.Supports(models.CapabilityRoleIDs), "an unknown version supports everything")
+	assert.Equal(t, 1, infoRequests, "the failure is remembered")
+
+	status = http.StatusOK
+	client.versionRetry = time.Now()
+	v, err := client.ServerVersion()
+	require.NoError(t, err)
+	assert.Equal(t, "3.18.2", v.String())
+	assert.Equal(t, 2, infoRequests)
+}
+
 func TestRocket_GetDirectory(t *testing.T) {
 	rocket := getDefaultClient(t)
 
@@ -37,9 +115,7 @@ func TestRocket_GetSpotlight(t *testing.T) {
 }
 
 func TestRocket_GetStatistics(t *testing.T) {
-	rocket := Client{Protocol: common_testing.Protocol, Host: common_testing.Host, Port: common_testing.Port}
-	// TODO admin user
-	rocket.auth = &authInfo{id: "4SicoW2wDjcAaRh4M", token: "4nI24JkcHTsqTtOUeJYbbrhum5T_Y6IdjAwyj72qDBu"}
+	rocket := getAdminClient(t)
 
 	statistics, err := rocket.GetStatistics()
 	assert.Nil(t, err)
@@ -47,9 +123,7 @@ func TestRocket_GetStatistics(t *testing.T) {
 }
 
 func TestRocket_GetStatisticsList(t *testing.T) {
-	rocket := Client{Protocol: common_testing.Protocol, Host: common_testing.Host, Port: common_testing.Port}
-	// TODO admin user
-	rocket.auth = &authInfo{id: "4SicoW2wDjcAaRh4M", token: "4nI24JkcHTsqTtOUeJYbbrhum5T_Y6IdjAwyj72qDBu"}
+	rocket := getAdminClient(t)
 
 	statistics, err := rocket.GetStatisticsList(url.Values{"query": []string{`{"_id" : "zT26ye8RAM7MaEN7S"}`}})
 	assert.Nil(t, err)
@@ -4,9 +4,9 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
-	"html"
 	"net/url"
 	"strconv"
+	"time"
 
 	"github.com/yazver/Rocket.Chat.Go.SDK/models"
 )
@@ -21,17 +21,25 @@ type MessageResponse struct {
 	Message models.Message `json:"message"`
 }
 
+type SyncMessagesResponse struct {
+	Status
+	Result models.MessageChanges `json:"result"`
+}
+
 // Sends a message to a channel. The name of the channel has to be not nil.
-// The message will be html escaped.
+// The message is sent as Rocket.Chat markdown, use the format package to escape user content.
 //
 // https://rocket.chat/docs/developer-guides/rest-api/chat/postmessage
 func (c *Client) Send(channel *models.Channel, msg string) error {
-	body := fmt.Sprintf(`{ "channel": "%s", "text": "%s"}`, channel.Name, html.EscapeString(msg))
-	return c.Post("chat.postMessage", bytes.NewBufferString(body), new(MessageResponse))
+	body, err := json.Marshal(models.PostMessage{Channel: channel.Name, Text: msg})
+	if err != nil {
+		return fmt.Errorf("marshaling message: %w", err)
+	}
+	return c.Post("chat.postMessage", bytes.NewBuffer(body), new(MessageResponse))
 }
 
 // PostMessage send a message to a channel. The channel or roomID has to be not nil.
-// The message will be json encode.
+// The message will be json encode. Besides attachments it may carry UIKit blocks.
 //
 // https://rocket.chat/docs/developer-guides/rest-api/chat/postmessage
 func (c *Client) PostMessage(msg *models.PostMessage) (*MessageResponse, error) {
@@ -41,8 +49,10 @@ func (c *Client) PostMessage(msg *models.PostMessage) (*MessageResponse, error)
 	}
 
 	response := new(MessageResponse)
-	err = c.Post("chat.postMessage", bytes.NewBuffer(body), response)
-	return response, fmt.Errorf("post message: %w", err)
+	if err := c.Post("chat.postMessage", bytes.NewBuffer(body), response); err != nil {
+		return nil, fmt.Errorf("post message: %w", err)
+	}
+	return response, nil
 }
 
 // Get messages from a channel. The channel id has to be not nil. Optionally a
@@ -65,3 +75,19 @@ func (c *Client) GetMessages(channel *models.Channel, page *models.Pagination) (
 
 	return response.Messages, nil
 }
+
+// SyncMessages gets the messages of a room updated and deleted since lastUpdate.
+//
+// https://developer.rocket.chat/reference/api/rest-api/endpoints/core-endpoints/chat-endpoints/syncmessages
+func (c *Client) SyncMessages(roomID string, lastUpdate time.Time) (*models.MessageChanges, error) {
+	params := url.Values{
+		"roomId":     []string{roomID},
+		"lastUpdate": []string{lastUpdate.UTC().Format(time.RFC3339Nano)},
+	}
+
+	response := new(SyncMessagesResponse)
+	if err := c.Get("chat.syncMessages", params, response); err != nil {
+		return nil, fmt.Errorf("sync messages: %w", err)
+	}
+	return &response.Result, nil
+}
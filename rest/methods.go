@@ -0,0 +1,157 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/yazver/Rocket.Chat.Go.SDK/auth"
+	"github.com/yazver/Rocket.Chat.Go.SDK/models"
+)
+
+type methodCallRequest struct {
+	Message string `json:"message"`
+}
+
+type MethodCallResponse struct {
+	Status
+}
+
+// methodMessage is a DDP message sent and received as string by method.call.
+type methodMessage struct {
+	Msg    string        `json:"msg"`
+	ID     string        `json:"id"`
+	Method string        `json:"method,omitempty"`
+	Params []interface{} `json:"params,omitempty"`
+
+	Result interface{}     `json:"result,omitempty"`
+	Error  json.RawMessage `json:"error,omitempty"`
+}
+
+// MethodError is the error of a DDP method called by CallMethod.
+type MethodError struct {
+	// Code is the error code, a string like "error-not-allowed" or a number like 403.
+	Code      interface{}            `json:"error"`
+	Reason    string                 `json:"reason"`
+	Message   string                 `json:"message"`
+	ErrorType string                 `json:"errorType"`
+	Details   map[string]interface{} `json:"details"`
+}
+
+func (e *MethodError) Error() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	return fmt.Sprintf("%s [%v]", e.Reason, e.Code)
+}
+
+// CallMethod calls a DDP method over REST, with method.callAnon before the login. It works
+// behind proxies which block websockets and makes the client a realtime.MethodCaller.
+// The result is decoded like a result of the websocket, on an error it's the decoded error
+// object besides a *MethodError. A successful login method logs the client in and keeps the
+// token in its Session like Login.
+//
+// https://developer.rocket.chat/reference/api/rest-api/endpoints/core-endpoints/miscellaneous-endpoints/method-call
+func (c *Client) CallMethod(method string, params ...interface{}) (interface{}, error) {
+	if err := c.require(models.CapabilityMethodCall); err != nil {
+		return nil, fmt.Errorf("call method %s: %w", method, err)
+	}
+
+	if params == nil {
+		params = []interface{}{}
+	}
+	id := strconv.FormatUint(uint64(atomic.AddUint32(&c.methodID, 1)), 10)
+	message, err := json.Marshal(methodMessage{Msg: "method", ID: id, Method: method, Params: params})
+	if err != nil {
+		return nil, fmt.Errorf("marshaling method %s call: %w", method, err)
+	}
+	body, err := json.Marshal(methodCallRequest{Message: string(message)})
+	if err != nil {
+		return nil, fmt.Errorf("marshaling method %s call: %w", method, err)
+	}
+
+	endpoint := "method.call/"
+	if c.getAuth() == nil {
+		endpoint = "method.callAnon/"
+	}
+
+	response := new(MethodCallResponse)
+	postErr := c.Post(endpoint+url.PathEscape(method), bytes.NewBuffer(body), response)
+
+	// Failed methods are answered with an error status, their error is in the message.
+	var result methodMessage
+	if response.Message == "" || json.Unmarshal([]byte(response.Message), &result) != nil {
+		if postErr != nil {
+			return nil, fmt.Errorf("call method %s: %w", method, postErr)
+		}
+		return nil, fmt.Errorf("call method %s: invalid response", method)
+	}
+
+	if len(result.Error) > 0 {
+		var reply interface{}
+		methodErr := new(MethodError)
+		if err := json.Unmarshal(result.Error, &reply); err != nil {
+			return nil, fmt.Errorf("call method %s: decoding error: %w", method, err)
+		}
+		_ = json.Unmarshal(result.Error, methodErr)
+		return reply, methodErr
+	}
+
+	if method == "login" {
+		if err := c.setLogin(params, result.Result); err != nil {
+			return nil, err
+		}
+	}
+	return result.Result, nil
+}
+
+// setLogin takes the credentials of a login method result. Like Login, it keeps the token in
+// the session, which drops the login data of another user than the one logged in.
+func (c *Client) setLogin(params []interface{}, result interface{}) error {
+	login, _ := result.(map[string]interface{})
+	id, _ := login["id"].(string)
+	token, _ := login["token"].(string)
+	if id == "" || token == "" {
+		return nil
+	}
+
+	credentials := methodLoginCredentials(params)
+	if c.Session == nil {
+		session, err := auth.NewSession(nil, credentials)
+		if err != nil {
+			return err
+		}
+		c.Session = session
+	} else if current := c.Session.Credentials(); credentials.Login() != "" && credentials.Login() != current.Login() {
+		c.Session.SetLogin(credentials)
+	}
+
+	c.setAuth(&authInfo{id: id, token: token})
+	return c.Session.SetToken(id, token)
+}
+
+// methodLoginCredentials reads the user of the parameters of a login method, the password is
+// only sent as digest, so it can't be used to log in again.
+func methodLoginCredentials(params []interface{}) *models.UserCredentials {
+	var request struct {
+		User struct {
+			Username string `json:"username"`
+			Email    string `json:"email"`
+		} `json:"user"`
+		LDAP     bool   `json:"ldap"`
+		Username string `json:"username"`
+	}
+	if len(params) > 0 {
+		if data, err := json.Marshal(params[0]); err == nil {
+			_ = json.Unmarshal(data, &request)
+		}
+	}
+
+	if request.LDAP {
+		return &models.UserCredentials{Username: request.Username, LoginMethod: models.LoginMethodLDAP}
+	}
+	return &models.UserCredentials{Username: request.User.Username, Email: request.User.Email}
+}
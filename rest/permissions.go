@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"net/url"
+	"time"
 
 	"github.com/yazver/Rocket.Chat.Go.SDK/models"
 )
@@ -32,3 +34,40 @@ func (c *Client) UpdatePermissions(req *UpdatePermissionsRequest) (*UpdatePermis
 	}
 	return response, nil
 }
+
+type PermissionsListAllResponse struct {
+	Status
+	Update []models.Permission `json:"update"`
+	Remove []models.Permission `json:"remove"`
+}
+
+type PermissionsListResponse struct {
+	Status
+	Permissions []models.Permission `json:"permissions"`
+}
+
+// ListAllPermissions gets all permissions with their roles. If updatedSince is set, only the
+// permissions changed or removed since then are returned. Servers older than 0.73 have no
+// permissions.listAll, there permissions.list is used and always all permissions are returned.
+//
+// https://developer.rocket.chat/reference/api/rest-api/endpoints/core-endpoints/permissions-endpoints/list-all
+func (c *Client) ListAllPermissions(updatedSince *time.Time) (*PermissionsListAllResponse, error) {
+	if !c.Supports(models.CapabilityPermissionsListAll) {
+		response := new(PermissionsListResponse)
+		if err := c.Get("permissions.list", nil, response); err != nil {
+			return nil, fmt.Errorf("list permissions: %w", err)
+		}
+		return &PermissionsListAllResponse{Status: response.Status, Update: response.Permissions}, nil
+	}
+
+	params := url.Values{}
+	if updatedSince != nil {
+		params.Set("updatedSince", updatedSince.UTC().Format(time.RFC3339Nano))
+	}
+
+	response := new(PermissionsListAllResponse)
+	if err := c.Get("permissions.listAll", params, response); err != nil {
+		return nil, fmt.Errorf("list all permissions: %w", err)
+	}
+	return response, nil
+}
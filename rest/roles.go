@@ -0,0 +1,144 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/yazver/Rocket.Chat.Go.SDK/models"
+)
+
+type RolesResponse struct {
+	Status
+	Roles []models.Role `json:"roles"`
+}
+
+type RoleResponse struct {
+	Status
+	Role models.Role `json:"role"`
+}
+
+type UsersInRoleResponse struct {
+	Status
+	models.Pagination
+	Users []models.User `json:"users"`
+}
+
+// ListRoles gets all roles of the server.
+//
+// https://developer.rocket.chat/reference/api/rest-api/endpoints/core-endpoints/roles-endpoints/list
+func (c *Client) ListRoles() ([]models.Role, error) {
+	response := new(RolesResponse)
+	if err := c.Get("roles.list", nil, response); err != nil {
+		return nil, fmt.Errorf("roles list: %w", err)
+	}
+	return response.Roles, nil
+}
+
+// CreateRole creates a role.
+//
+// https://developer.rocket.chat/reference/api/rest-api/endpoints/core-endpoints/roles-endpoints/create
+func (c *Client) CreateRole(req *models.CreateRoleRequest) (*models.Role, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling create role request data: %w", err)
+	}
+
+	response := new(RoleResponse)
+	if err := c.Post("roles.create", bytes.NewBuffer(body), response); err != nil {
+		return nil, fmt.Errorf("create role: %w", err)
+	}
+	return &response.Role, nil
+}
+
+// UpdateRole changes a role which isn't protected.
+//
+// https://developer.rocket.chat/reference/api/rest-api/endpoints/core-endpoints/roles-endpoints/update
+func (c *Client) UpdateRole(req *models.UpdateRoleRequest) (*models.Role, error) {
+	if err := c.require(models.CapabilityRoleIDs); err != nil {
+		return nil, fmt.Errorf("update role: %w", err)
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling update role request data: %w", err)
+	}
+
+	response := new(RoleResponse)
+	if err := c.Post("roles.update", bytes.NewBuffer(body), response); err != nil {
+		return nil, fmt.Errorf("update role: %w", err)
+	}
+	return &response.Role, nil
+}
+
+// DeleteRole deletes a role which isn't protected and not assigned to users.
+//
+// https://developer.rocket.chat/reference/api/rest-api/endpoints/core-endpoints/roles-endpoints/delete
+func (c *Client) DeleteRole(roleID string) error {
+	if err := c.require(models.CapabilityRoleIDs); err != nil {
+		return fmt.Errorf("delete role: %w", err)
+	}
+
+	body, err := json.Marshal(map[string]string{"roleId": roleID})
+	if err != nil {
+		return fmt.Errorf("marshaling delete role request data: %w", err)
+	}
+
+	if err := c.Post("roles.delete", bytes.NewBuffer(body), new(Status)); err != nil {
+		return fmt.Errorf("delete role: %w", err)
+	}
+	return nil
+}
+
+// AddUserToRole assigns a role to a user, in the room for roles with the subscriptions scope.
+//
+// https://developer.rocket.chat/reference/api/rest-api/endpoints/core-endpoints/roles-endpoints/add-user-to-role
+func (c *Client) AddUserToRole(req *models.UserRoleRequest) (*models.Role, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling user role request data: %w", err)
+	}
+
+	response := new(RoleResponse)
+	if err := c.Post("roles.addUserToRole", bytes.NewBuffer(body), response); err != nil {
+		return nil, fmt.Errorf("add user to role: %w", err)
+	}
+	return &response.Role, nil
+}
+
+// RemoveUserFromRole removes a role from a user.
+//
+// https://developer.rocket.chat/reference/api/rest-api/endpoints/core-endpoints/roles-endpoints/remove-user-from-role
+func (c *Client) RemoveUserFromRole(req *models.UserRoleRequest) (*models.Role, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling user role request data: %w", err)
+	}
+
+	response := new(RoleResponse)
+	if err := c.Post("roles.removeUserFromRole", bytes.NewBuffer(body), response); err != nil {
+		return nil, fmt.Errorf("remove user from role: %w", err)
+	}
+	return &response.Role, nil
+}
+
+// GetUsersInRole lists the users having the role, optionally only in a room.
+// It supports the Offset and Count Query Parameters.
+//
+// https://developer.rocket.chat/reference/api/rest-api/endpoints/core-endpoints/roles-endpoints/get-users-in-role
+func (c *Client) GetUsersInRole(role, roomID string, params url.Values) (*UsersInRoleResponse, error) {
+	if params == nil {
+		params = url.Values{}
+	}
+	params.Set("role", role)
+	if roomID != "" {
+		params.Set("roomId", roomID)
+	}
+
+	response := new(UsersInRoleResponse)
+	if err := c.Get("roles.getUsersInRole", params, response); err != nil {
+		return nil, fmt.Errorf("get users in role: %w", err)
+	}
+	return response, nil
+}
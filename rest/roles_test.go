@@ -0,0 +1,29 @@
+package rest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRocket_ListRoles(t *testing.T) {
+	rocket := getDefaultClient(t)
+
+	roles, err := rocket.ListRoles()
+	assert.Nil(t, err)
+
+	names := make([]string, 0, len(roles))
+	for _, role := range roles {
+		names = append(names, role.Name)
+	}
+	assert.Contains(t, names, "admin")
+	assert.Contains(t, names, "user")
+}
+
+func TestRocket_ListAllPermissions(t *testing.T) {
+	rocket := getDefaultClient(t)
+
+	permissions, err := rocket.ListAllPermissions(nil)
+	assert.Nil(t, err)
+	assert.NotEmpty(t, permissions.Update)
+}
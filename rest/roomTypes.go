@@ -0,0 +1,163 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/yazver/Rocket.Chat.Go.SDK/models"
+)
+
+// ErrRoomType is wrapped by the errors of operations the type of the room doesn't have,
+// like inviting users to direct messages.
+var ErrRoomType = errors.New("unsupported by the room type")
+
+// roomEndpoints are the endpoints of the operations for each room type. Leaving direct
+// messages closes them, since they can't be left.
+var roomEndpoints = map[string]map[string]string{
+	models.RoomTypeChannel: {
+		"history":  "channels.history",
+		"members":  "channels.members",
+		"invite":   "channels.invite",
+		"kick":     "channels.kick",
+		"leave":    "channels.leave",
+		"setTopic": "channels.setTopic",
+	},
+	models.RoomTypeGroup: {
+		"history":  "groups.history",
+		"members":  "groups.members",
+		"invite":   "groups.invite",
+		"kick":     "groups.kick",
+		"leave":    "groups.leave",
+		"setTopic": "groups.setTopic",
+	},
+	models.RoomTypeDirect: {
+		"history":  "im.history",
+		"members":  "im.members",
+		"leave":    "im.close",
+		"setTopic": "im.setTopic",
+	},
+}
+
+type roomRequest struct {
+	RoomID string `json:"roomId"`
+	UserID string `json:"userId,omitempty"`
+	// Topic is a pointer, since an empty topic clears it.
+	Topic *string `json:"topic,omitempty"`
+}
+
+// roomEndpoint returns the endpoint of the operation for the type of the room and the room
+// ID. Rooms without an ID or a type are looked up with rooms.info.
+func (c *Client) roomEndpoint(room *models.Channel, operation string) (string, string, error) {
+	if room.ID == "" || room.Type == "" {
+		info, err := c.GetRoomInfo(room)
+		if err != nil {
+			return "", "", err
+		}
+		room = info
+	}
+
+	endpoint, ok := roomEndpoints[room.Type][operation]
+	if !ok {
+		return "", "", fmt.Errorf("%s in room %s of type %q: %w", operation, room.ID, room.Type, ErrRoomType)
+	}
+	return endpoint, room.ID, nil
+}
+
+// getRoom gets an operation of the room with the query params.
+func (c *Client) getRoom(room *models.Channel, operation string, params url.Values, response Response) error {
+	endpoint, roomID, err := c.roomEndpoint(room, operation)
+	if err != nil {
+		return err
+	}
+
+	query := url.Values{"roomId": []string{roomID}}
+	for key, values := range params {
+		query[key] = values
+	}
+	return c.Get(endpoint, query, response)
+}
+
+// postRoom posts an operation of the room, the room ID of the request is set.
+func (c *Client) postRoom(room *models.Channel, operation string, request roomRequest, response Response) error {
+	endpoint, roomID, err := c.roomEndpoint(room, operation)
+	if err != nil {
+		return err
+	}
+
+	request.RoomID = roomID
+	body, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("marshaling %s request data: %w", endpoint, err)
+	}
+	return c.Post(endpoint, bytes.NewBuffer(body), response)
+}
+
+// RoomHistory gets the messages of a channel, private group or direct messages, newest first.
+// The room is given by its ID or name. It supports the latest, oldest, inclusive, offset and
+// count parameters.
+//
+// https://developer.rocket.chat/reference/api/rest-api/endpoints/core-endpoints/channels-endpoints/history
+func (c *Client) RoomHistory(room *models.Channel, params url.Values) (*ChannelMessagesResponse, error) {
+	response := new(ChannelMessagesResponse)
+	if err := c.getRoom(room, "history", params, response); err != nil {
+		return nil, fmt.Errorf("room history: %w", err)
+	}
+	return response, nil
+}
+
+// RoomMembers lists the users of a channel, private group or direct messages. The room is
+// given by its ID or name. It supports the offset, count and sort parameters.
+//
+// https://developer.rocket.chat/reference/api/rest-api/endpoints/core-endpoints/channels-endpoints/members
+func (c *Client) RoomMembers(room *models.Channel, params url.Values) (*ChannelMembersResponse, error) {
+	response := new(ChannelMembersResponse)
+	if err := c.getRoom(room, "members", params, response); err != nil {
+		return nil, fmt.Errorf("room members: %w", err)
+	}
+	return response, nil
+}
+
+// InviteToRoom adds a user to a channel or private group given by its ID or name.
+//
+// https://developer.rocket.chat/reference/api/rest-api/endpoints/core-endpoints/channels-endpoints/invite
+func (c *Client) InviteToRoom(room *models.Channel, userID string) error {
+	if err := c.postRoom(room, "invite", roomRequest{UserID: userID}, new(Status)); err != nil {
+		return fmt.Errorf("inviting to room: %w", err)
+	}
+	return nil
+}
+
+// KickFromRoom removes a user from a channel or private group given by its ID or name.
+//
+// https://developer.rocket.chat/reference/api/rest-api/endpoints/core-endpoints/channels-endpoints/kick
+func (c *Client) KickFromRoom(room *models.Channel, userID string) error {
+	if err := c.postRoom(room, "kick", roomRequest{UserID: userID}, new(Status)); err != nil {
+		return fmt.Errorf("kicking from room: %w", err)
+	}
+	return nil
+}
+
+// LeaveRoom leaves a channel or private group, direct messages are closed. The room is given
+// by its ID or name.
+//
+// https://developer.rocket.chat/reference/api/rest-api/endpoints/core-endpoints/channels-endpoints/leave
+func (c *Client) LeaveRoom(room *models.Channel) error {
+	if err := c.postRoom(room, "leave", roomRequest{}, new(Status)); err != nil {
+		return fmt.Errorf("leaving room: %w", err)
+	}
+	return nil
+}
+
+// SetRoomTopic sets the topic of a channel, private group or direct messages given by its ID
+// or name.
+//
+// https://developer.rocket.chat/reference/api/rest-api/endpoints/core-endpoints/channels-endpoints/settopic
+func (c *Client) SetRoomTopic(room *models.Channel, topic string) error {
+	if err := c.postRoom(room, "setTopic", roomRequest{Topic: &topic}, new(Status)); err != nil {
+		return fmt.Errorf("setting room topic: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,320 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/yazver/Rocket.Chat.Go.SDK/models"
+)
+
+type RoomResponse struct {
+	Status
+	Room models.Channel `json:"room"`
+}
+
+type RoomsResponse struct {
+	Status
+	Update []models.Channel `json:"update"`
+	Remove []models.Channel `json:"remove"`
+}
+
+type AdminRoomsResponse struct {
+	Status
+	models.Pagination
+	Rooms []models.Channel `json:"rooms"`
+}
+
+type CleanHistoryResponse struct {
+	Status
+	// Count is the number of deleted messages.
+	Count int `json:"count"`
+}
+
+type UploadResponse struct {
+	Status
+	Message models.Message `json:"message"`
+}
+
+// GetRoomInfo gets a room of any type by its ID or, without an ID, by its name.
+//
+// https://developer.rocket.chat/reference/api/rest-api/endpoints/core-endpoints/rooms-endpoints/info
+func (c *Client) GetRoomInfo(room *models.Channel) (*models.Channel, error) {
+	params := url.Values{}
+	switch {
+	case room.ID != "":
+		params.Set("roomId", room.ID)
+	case room.Name != "":
+		params.Set("roomName", room.Name)
+	default:
+		return nil, errors.New("room.ID or room.Name must be set")
+	}
+
+	response := new(RoomResponse)
+	if err := c.Get("rooms.info", params, response); err != nil {
+		return nil, fmt.Errorf("room info: %w", err)
+	}
+	return &response.Room, nil
+}
+
+// GetRooms gets the rooms of the user. If updatedSince is set, only the rooms changed or
+// removed since then are returned.
+//
+// https://developer.rocket.chat/reference/api/rest-api/endpoints/core-endpoints/rooms-endpoints/get-rooms
+func (c *Client) GetRooms(updatedSince *time.Time) (*RoomsResponse, error) {
+	params := url.Values{}
+	if updatedSince != nil {
+		params.Set("updatedSince", updatedSince.UTC().Format(time.RFC3339Nano))
+	}
+
+	response := new(RoomsResponse)
+	if err := c.Get("rooms.get", params, response); err != nil {
+		return nil, fmt.Errorf("get rooms: %w", err)
+	}
+	return response, nil
+}
+
+// GetAdminRooms lists all rooms of the server for administrators. The rooms may be filtered
+// by their name and types, params support offset, count and sort.
+//
+// https://developer.rocket.chat/reference/api/rest-api/endpoints/core-endpoints/rooms-endpoints/admin-rooms
+func (c *Client) GetAdminRooms(filter string, types []string, params url.Values) (*AdminRoomsResponse, error) {
+	query := url.Values{}
+	for key, values := range params {
+		query[key] = values
+	}
+	if filter != "" {
+		query.Set("filter", filter)
+	}
+	for _, roomType := range types {
+		query.Add("types[]", roomType)
+	}
+
+	response := new(AdminRoomsResponse)
+	if err := c.Get("rooms.adminRooms", query, response); err != nil {
+		return nil, fmt.Errorf("admin rooms: %w", err)
+	}
+	return response, nil
+}
+
+// CleanRoomHistory deletes messages of a room and returns how many were deleted.
+//
+// https://developer.rocket.chat/reference/api/rest-api/endpoints/core-endpoints/rooms-endpoints/clean-history
+func (c *Client) CleanRoomHistory(req *models.CleanHistoryRequest) (int, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return 0, fmt.Errorf("marshaling clean history request data: %w", err)
+	}
+
+	response := new(CleanHistoryResponse)
+	if err := c.Post("rooms.cleanHistory", bytes.NewBuffer(body), response); err != nil {
+		return 0, fmt.Errorf("clean room history: %w", err)
+	}
+	return response.Count, nil
+}
+
+// FavoriteRoom marks a room as favorite of the user or unmarks it.
+//
+// https://developer.rocket.chat/reference/api/rest-api/endpoints/core-endpoints/rooms-endpoints/favorite
+func (c *Client) FavoriteRoom(roomID string, favorite bool) error {
+	body, err := json.Marshal(map[string]interface{}{"roomId": roomID, "favorite": favorite})
+	if err != nil {
+		return fmt.Errorf("marshaling favorite request data: %w", err)
+	}
+
+	if err := c.Post("rooms.favorite", bytes.NewBuffer(body), new(Status)); err != nil {
+		return fmt.Errorf("favorite room: %w", err)
+	}
+	return nil
+}
+
+// LeaveRoomByID leaves a channel or private group by its ID with rooms.leave. Unlike
+// LeaveRoom it doesn't need the type of the room.
+//
+// https://developer.rocket.chat/reference/api/rest-api/endpoints/core-endpoints/rooms-endpoints/leave
+func (c *Client) LeaveRoomByID(roomID string) error {
+	body, err := json.Marshal(map[string]string{"roomId": roomID})
+	if err != nil {
+		return fmt.Errorf("marshaling leave room request data: %w", err)
+	}
+
+	if err := c.Post("rooms.leave", bytes.NewBuffer(body), new(Status)); err != nil {
+		return fmt.Errorf("leave room: %w", err)
+	}
+	return nil
+}
+
+// SaveRoomNotification changes the notification preferences of the user for a room.
+//
+// https://developer.rocket.chat/reference/api/rest-api/endpoints/core-endpoints/rooms-endpoints/save-notification
+func (c *Client) SaveRoomNotification(roomID string, notifications *models.RoomNotifications) error {
+	body, err := json.Marshal(map[string]interface{}{"roomId": roomID, "notifications": notifications})
+	if err != nil {
+		return fmt.Errorf("marshaling room notification request data: %w", err)
+	}
+
+	if err := c.Post("rooms.saveNotification", bytes.NewBuffer(body), new(Status)); err != nil {
+		return fmt.Errorf("save room notification: %w", err)
+	}
+	return nil
+}
+
+// ExportRoom exports the messages of a room by email or to a file, the server sends or
+// prepares the export in the background.
+//
+// https://developer.rocket.chat/reference/api/rest-api/endpoints/core-endpoints/rooms-endpoints/export
+func (c *Client) ExportRoom(req *models.ExportRoomRequest) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("marshaling export room request data: %w", err)
+	}
+
+	if err := c.Post("rooms.export", bytes.NewBuffer(body), new(Status)); err != nil {
+		return fmt.Errorf("export room: %w", err)
+	}
+	return nil
+}
+
+// SaveRoomSettings changes settings of a room, e.g. "default", "roomTopic" or "readOnly".
+//
+// https://developer.rocket.chat/reference/api/rest-api/endpoints/core-endpoints/rooms-endpoints/save-room-settings
+func (c *Client) SaveRoomSettings(roomID string, settings map[string]interface{}) error {
+	data := make(map[string]interface{}, len(settings)+1)
+	for key, value := range settings {
+		data[key] = value
+	}
+	data["rid"] = roomID
+
+	body, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("marshaling room settings request data: %w", err)
+	}
+
+	if err := c.Post("rooms.saveRoomSettings", bytes.NewBuffer(body), new(Status)); err != nil {
+		return fmt.Errorf("save room settings: %w", err)
+	}
+	return nil
+}
+
+// UploadFile posts a file to a room, with an optional message.
+//
+// https://developer.rocket.chat/reference/api/rest-api/endpoints/core-endpoints/rooms-endpoints/upload-file-to-a-room
+func (c *Client) UploadFile(roomID, fileName string, file io.Reader, msg string) (*models.Message, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	part, err := writer.CreateFormFile("file", fileName)
+	if err != nil {
+		return nil, fmt.Errorf("creating upload form: %w", err)
+	}
+	if _, err := io.Copy(part, file); err != nil {
+		return nil, fmt.Errorf("reading file: %w", err)
+	}
+	if msg != "" {
+		if err := writer.WriteField("msg", msg); err != nil {
+			return nil, fmt.Errorf("creating upload form: %w", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("creating upload form: %w", err)
+	}
+
+	response := new(UploadResponse)
+	if err := c.PostMultipart("rooms.upload/"+url.PathEscape(roomID), &body, writer.FormDataContentType(), response); err != nil {
+		return nil, fmt.Errorf("upload file: %w", err)
+	}
+	return &response.Message, nil
+}
+
+// DownloadFile downloads a file of the server, like the title link of a file attachment, e.g.
+// /file-upload/ID/name.png. The path may be a URL as well, the token is only sent to the
+// server of the client though, other URLs are downloaded anonymously.
+func (c *Client) DownloadFile(path string, w io.Writer) error {
+	target, err := url.Parse(path)
+	if err != nil {
+		return fmt.Errorf("download file: %w", err)
+	}
+	if !target.IsAbs() {
+		if target, err = url.Parse(c.getBaseURL() + "/" + strings.TrimPrefix(path, "/")); err != nil {
+			return fmt.Errorf("download file: %w", err)
+		}
+	}
+
+	usedAuth := c.getAuth()
+	err = c.download(target, w)
+	if errors.Is(err, ErrUnauthorized) && c.isServerURL(target) && c.relogin(usedAuth) == nil {
+		err = c.download(target, w)
+	}
+	if err != nil {
+		return fmt.Errorf("download file: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) download(target *url.URL, w io.Writer) error {
+	request, err := http.NewRequest(http.MethodGet, target.String(), nil)
+	if err != nil {
+		return fmt.Errorf("new request: %w", err)
+	}
+	if c.isServerURL(target) {
+		c.setAuthHeaders(request)
+	}
+
+	if c.Debug {
+		log.Println(request)
+	}
+
+	// Redirects keep the headers of the request, the token mustn't follow them to other hosts.
+	client := *c.httpClient()
+	checkRedirect := client.CheckRedirect
+	client.CheckRedirect = func(request *http.Request, via []*http.Request) error {
+		if !c.isServerURL(request.URL) {
+			request.Header.Del("X-Auth-Token")
+			request.Header.Del("X-User-Id")
+		}
+		if checkRedirect != nil {
+			return checkRedirect(request, via)
+		}
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		return nil
+	}
+
+	resp, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		return ErrUnauthorized
+	case resp.StatusCode != http.StatusOK:
+		return errors.New("request error: " + resp.Status)
+	}
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+// isServerURL reports whether the URL has the scheme, the host and the port of the server.
+func (c *Client) isServerURL(target *url.URL) bool {
+	port := target.Port()
+	if port == "" {
+		switch target.Scheme {
+		case "http":
+			port = "80"
+		case "https":
+			port = "443"
+		}
+	}
+	return target.Scheme == c.Protocol && strings.EqualFold(target.Hostname(), c.Host) && port == c.Port
+}
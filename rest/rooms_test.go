@@ -0,0 +1,211 @@
+package rest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yazver/Rocket.Chat.Go.SDK/auth"
+	"github.com/yazver/Rocket.Chat.Go.SDK/fakeserver"
+	"github.com/yazver/Rocket.Chat.Go.SDK/models"
+)
+
+func TestRocket_UploadFile(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/rooms.upload/GENERAL", r.URL.Path)
+		file, header, err := r.FormFile("file")
+		require.NoError(t, err)
+		defer file.Close()
+		data, _ := ioutil.ReadAll(file)
+		assert.Equal(t, "report.txt", header.Filename)
+		assert.Equal(t, "content", string(data))
+		assert.Equal(t, "the report", r.FormValue("msg"))
+		fmt.Fprint(w, `{"success":true,"message":{"_id":"msg1","rid":"GENERAL","msg":"the report"}}`)
+	}))
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+	client := NewClient(serverURL, false)
+
+	message, err := client.UploadFile("GENERAL", "report.txt", bytes.NewBufferString("content"), "the report")
+	require.NoError(t, err)
+	assert.Equal(t, "msg1", message.ID)
+}
+
+func TestRocket_DownloadFile(t *testing.T) {
+	foreign := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get("X-Auth-Token"), "the token was sent to another host")
+		fmt.Fprint(w, "foreign")
+	}))
+	defer foreign.Close()
+
+	logins := 0
+	var tokens []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/login":
+			logins++
+			fmt.Fprintf(w, `{"status":"success","data":{"authToken":"token%d","userId":"id"}}`, logins)
+		case "/api/v1/me":
+			fmt.Fprint(w, `{"success":true,"_id":"id","username":"bot"}`)
+		case "/file-upload/file1/report.txt":
+			// The first token expires before the download.
+			tokens = append(tokens, r.Header.Get("X-Auth-Token"))
+			if r.Header.Get("X-Auth-Token") != "token2" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			fmt.Fprint(w, "content")
+		case "/redirect":
+			http.Redirect(w, r, foreign.URL+"/file", http.StatusFound)
+		}
+	}))
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+	client := NewClient(serverURL, false)
+	session, err := auth.NewSession(auth.NewMemoryStore(), nil)
+	require.NoError(t, err)
+	client.Session = session
+	_, err = client.Login(&models.UserCredentials{Email: "bot@localhost.com", Password: "pass"})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, client.DownloadFile("/file-upload/file1/report.txt", &buf))
+	assert.Equal(t, "content", buf.String())
+	assert.Equal(t, []string{"token1", "token2"}, tokens, "the download wasn't repeated after the relogin")
+
+	buf.Reset()
+	require.NoError(t, client.DownloadFile(foreign.URL+"/file", &buf))
+	assert.Equal(t, "foreign", buf.String())
+
+	buf.Reset()
+	require.NoError(t, client.DownloadFile("/redirect", &buf))
+	assert.Equal(t, "foreign", buf.String())
+}
+
+func TestRocket_RoomTypes(t *testing.T) {
+	server := fakeserver.New()
+	defer server.Close()
+	server.AddUser("bot", "pass", "bot")
+	alice := server.AddUser("alice", "pass")
+	group := server.AddRoom("secret", models.RoomTypeGroup, "bot")
+	direct := server.AddRoom("alice-bot", models.RoomTypeDirect, "alice", "bot")
+	server.AddMessage(direct.ID, "alice", "hi")
+
+	client := NewClient(server.URL(), false)
+	_, err := client.Login(&models.UserCredentials{Username: "bot", Password: "pass"})
+	require.NoError(t, err)
+
+	info, err := client.GetRoomInfo(&models.Channel{Name: "secret"})
+	require.NoError(t, err)
+	assert.Equal(t, group.ID, info.ID)
+	assert.Equal(t, models.RoomTypeGroup, info.Type)
+
+	require.NoError(t, client.InviteToRoom(&models.Channel{Name: "secret"}, alice.ID))
+	require.NoError(t, client.SetRoomTopic(&models.Channel{ID: group.ID}, "plans"))
+	members, err := client.RoomMembers(&models.Channel{ID: group.ID}, nil)
+	require.NoError(t, err)
+	assert.Len(t, members.Members, 2)
+	assert.Equal(t, "plans", server.Room(group.ID).Topic)
+
+	require.NoError(t, client.KickFromRoom(&models.Channel{ID: group.ID}, alice.ID))
+	assert.Equal(t, []string{"bot"}, server.Room(group.ID).Users)
+
+	history, err := client.RoomHistory(&models.Channel{ID: direct.ID}, nil)
+	require.NoError(t, err)
+	require.Len(t, history.Messages, 1)
+	assert.Equal(t, "hi", history.Messages[0].Msg)
+	require.NoError(t, client.LeaveRoom(&models.Channel{ID: direct.ID}))
+
+	err = client.InviteToRoom(&models.Channel{ID: direct.ID, Type: models.RoomTypeDirect}, alice.ID)
+	assert.True(t, errors.Is(err, ErrRoomType))
+
+	require.NoError(t, client.LeaveRoom(&models.Channel{Name: "general"}))
+	assert.NotContains(t, server.Room(fakeserver.GeneralRoomID).Users, "bot")
+}
+
+func TestRocket_Rooms(t *testing.T) {
+	server := fakeserver.New()
+	defer server.Close()
+	now := time.Now().UTC().Add(time.Hour).Truncate(time.Second)
+	server.Now = func() time.Time { return now }
+	server.AddUser("bot", "pass", "bot")
+	news := server.AddRoom("news", models.RoomTypeChannel, "bot")
+
+	client := NewClient(server.URL(), false)
+	_, err := client.Login(&models.UserCredentials{Username: "bot", Password: "pass"})
+	require.NoError(t, err)
+
+	rooms, err := client.GetRooms(nil)
+	require.NoError(t, err)
+	assert.Len(t, rooms.Update, 2)
+
+	since := now
+	now = now.Add(time.Minute)
+	first := server.AddMessage(news.ID, "bot", "first")
+	now = now.Add(time.Minute)
+	server.AddMessage(news.ID, "bot", "second")
+
+	rooms, err = client.GetRooms(&since)
+	require.NoError(t, err)
+	require.Len(t, rooms.Update, 1)
+	assert.Equal(t, news.ID, rooms.Update[0].ID)
+
+	require.NoError(t, client.FavoriteRoom(news.ID, true))
+
+	count, err := client.CleanRoomHistory(&models.CleanHistoryRequest{
+		RoomID:    news.ID,
+		Oldest:    *first.Timestamp,
+		Latest:    *first.Timestamp,
+		Inclusive: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	messages := server.Messages(news.ID)
+	require.Len(t, messages, 1)
+	assert.Equal(t, "second", messages[0].Msg)
+
+	require.NoError(t, client.LeaveRoomByID(news.ID))
+	assert.NotContains(t, server.Room(news.ID).Users, "bot")
+}
+
+func TestRocket_RoomRequests(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		requests = append(requests, r.URL.Path+"?"+r.URL.RawQuery+" "+string(body))
+		fmt.Fprint(w, `{"success":true,"rooms":[{"_id":"ROOM1","t":"p"}],"count":1,"offset":0,"total":1}`)
+	}))
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+	client := NewClient(serverURL, false)
+
+	err := client.SaveRoomNotification("ROOM1", &models.RoomNotifications{DesktopNotifications: models.String("mentions")})
+	require.NoError(t, err)
+
+	rooms, err := client.GetAdminRooms("room", []string{models.RoomTypeChannel, models.RoomTypeGroup}, url.Values{"count": []string{"10"}})
+	require.NoError(t, err)
+	require.Len(t, rooms.Rooms, 1)
+	assert.Equal(t, 1, rooms.Total)
+
+	from := time.Date(2021, 5, 1, 0, 0, 0, 0, time.UTC)
+	err = client.ExportRoom(&models.ExportRoomRequest{RoomID: "ROOM1", Type: models.RoomExportFile, DateFrom: &from, Format: "json"})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		`/api/v1/rooms.saveNotification? {"notifications":{"desktopNotifications":"mentions"},"roomId":"ROOM1"}`,
+		`/api/v1/rooms.adminRooms?count=10&filter=room&types%5B%5D=c&types%5B%5D=p `,
+		`/api/v1/rooms.export? {"rid":"ROOM1","type":"file","dateFrom":"2021-05-01T00:00:00Z","format":"json"}`,
+	}, requests)
+}
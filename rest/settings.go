@@ -0,0 +1,100 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/yazver/Rocket.Chat.Go.SDK/models"
+)
+
+type SettingsResponse struct {
+	Status
+	models.Pagination
+	Settings []models.Setting `json:"settings"`
+}
+
+type OAuthSettingsResponse struct {
+	Status
+	Services []models.OAuthService `json:"services"`
+}
+
+// settingResponse has no type, so the value's type is guessed.
+type settingResponse struct {
+	Status
+	ID    string              `json:"_id"`
+	Value models.SettingValue `json:"value"`
+}
+
+// settingFields are requested by ListSettings when no fields are given, the type is needed
+// to decode the values.
+const settingFields = `{"type":1,"group":1,"section":1,"i18nLabel":1,"public":1,"hidden":1,"blocked":1,"packageValue":1,"values":1,"editor":1}`
+
+// ListSettings lists the settings, the user needs the view-privileged-setting permission.
+// It supports the offset, count, sort, query and fields parameters.
+//
+// https://developer.rocket.chat/reference/api/rest-api/endpoints/core-endpoints/settings-endpoints/get-private-settings
+func (c *Client) ListSettings(params url.Values) (*SettingsResponse, error) {
+	query := url.Values{}
+	for key, values := range params {
+		query[key] = values
+	}
+	if query.Get("fields") == "" {
+		query.Set("fields", settingFields)
+	}
+
+	response := new(SettingsResponse)
+	if err := c.Get("settings", query, response); err != nil {
+		return nil, fmt.Errorf("list settings: %w", err)
+	}
+	return response, nil
+}
+
+// GetPublicSettings lists the public settings. It doesn't need to be logged in.
+//
+// https://developer.rocket.chat/reference/api/rest-api/endpoints/core-endpoints/settings-endpoints/get-public-settings
+func (c *Client) GetPublicSettings(params url.Values) (*SettingsResponse, error) {
+	response := new(SettingsResponse)
+	if err := c.Get("settings.public", params, response); err != nil {
+		return nil, fmt.Errorf("public settings: %w", err)
+	}
+	return response, nil
+}
+
+// GetOAuthSettings lists the configured OAuth login services.
+//
+// https://developer.rocket.chat/reference/api/rest-api/endpoints/core-endpoints/settings-endpoints/get-oauth-settings
+func (c *Client) GetOAuthSettings() ([]models.OAuthService, error) {
+	response := new(OAuthSettingsResponse)
+	if err := c.Get("settings.oauth", nil, response); err != nil {
+		return nil, fmt.Errorf("oauth settings: %w", err)
+	}
+	return response.Services, nil
+}
+
+// GetSetting gets a setting by its id. The server doesn't send the type, so it's guessed from the value.
+//
+// https://developer.rocket.chat/reference/api/rest-api/endpoints/core-endpoints/settings-endpoints/get-setting
+func (c *Client) GetSetting(id string) (*models.Setting, error) {
+	response := new(settingResponse)
+	if err := c.Get("settings/"+url.PathEscape(id), nil, response); err != nil {
+		return nil, fmt.Errorf("get setting %s: %w", id, err)
+	}
+	return &models.Setting{ID: response.ID, Type: response.Value.Type, Value: response.Value}, nil
+}
+
+// UpdateSetting changes the value of a setting, the user needs the edit-privileged-setting permission.
+//
+// https://developer.rocket.chat/reference/api/rest-api/endpoints/core-endpoints/settings-endpoints/update-setting
+func (c *Client) UpdateSetting(id string, req *models.UpdateSettingRequest) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("marshaling setting request data: %w", err)
+	}
+
+	if err := c.Post("settings/"+url.PathEscape(id), bytes.NewBuffer(body), new(Status)); err != nil {
+		return fmt.Errorf("update setting %s: %w", id, err)
+	}
+	return nil
+}
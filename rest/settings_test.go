@@ -0,0 +1,99 @@
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yazver/Rocket.Chat.Go.SDK/models"
+)
+
+func TestRocket_ListSettings(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/settings", r.URL.Path)
+		assert.Equal(t, "10", r.URL.Query().Get("count"))
+		assert.Contains(t, r.URL.Query().Get("fields"), `"type":1`)
+		fmt.Fprint(w, `{"success":true,"count":10,"offset":0,"total":10,"settings":[
+			{"_id":"Accounts_AllowRegistration","type":"boolean","value":true},
+			{"_id":"Site_Name","type":"string","value":"Rocket.Chat"},
+			{"_id":"Message_MaxAllowedSize","type":"int","value":5000},
+			{"_id":"Accounts_RegistrationForm","type":"select","value":"Public","values":[{"key":"Public"},{"key":"Disabled"}]},
+			{"_id":"Accounts_OAuth_Custom_roles","type":"multiSelect","value":["admin","user"]},
+			{"_id":"Assets_logo","type":"asset","value":{"defaultUrl":"images/logo/logo.svg"}},
+			{"_id":"theme-color-primary","type":"color","value":"#1d74f5","editor":"color"},
+			{"_id":"Unknown","type":"font","value":12.5},
+			{"_id":"Message_Format","type":"select","value":0,"values":[{"key":0,"i18nLabel":"Plain"},{"key":1}]},
+			{"_id":"Mistyped","type":"boolean","value":"yes","packageValue":false}
+		]}`)
+	}))
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+	client := NewClient(serverURL, false)
+
+	response, err := client.ListSettings(url.Values{"count": []string{"10"}})
+	require.NoError(t, err)
+	require.Len(t, response.Settings, 10)
+
+	settings := response.Settings
+	assert.True(t, settings[0].Value.Bool)
+	assert.Equal(t, "Rocket.Chat", settings[1].Value.String)
+	assert.Equal(t, int64(5000), settings[2].Value.Int)
+	assert.Equal(t, "Public", settings[3].Value.String)
+	assert.Len(t, settings[3].Values, 2)
+	assert.Equal(t, []string{"admin", "user"}, settings[4].Value.Strings)
+	assert.Equal(t, "images/logo/logo.svg", settings[5].Value.Asset.DefaultUrl)
+	assert.Equal(t, "#1d74f5", settings[6].Value.String)
+	assert.Equal(t, "color", settings[6].Editor)
+	assert.Equal(t, models.SettingType("font"), settings[7].Value.Type)
+	assert.JSONEq(t, "12.5", string(settings[7].Value.Raw))
+	assert.Equal(t, []models.SettingOption{{Key: "0", I18nLabel: "Plain"}, {Key: "1"}}, settings[8].Values)
+	assert.Equal(t, int64(0), settings[8].Value.Int, "a value not fitting the type is decoded by its kind")
+	assert.Equal(t, models.SettingTypeBoolean, settings[9].Type)
+	assert.Equal(t, models.SettingTypeString, settings[9].Value.Type)
+	assert.Equal(t, "yes", settings[9].Value.String)
+	assert.False(t, settings[9].PackageValue.Bool)
+}
+
+func TestRocket_GetAndUpdateSetting(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/settings/Message_MaxAllowedSize", r.URL.Path)
+		if r.Method == http.MethodGet {
+			fmt.Fprint(w, `{"success":true,"_id":"Message_MaxAllowedSize","value":5000}`)
+			return
+		}
+
+		body, _ := ioutil.ReadAll(r.Body)
+		assert.JSONEq(t, `{"value":6000}`, string(body))
+		fmt.Fprint(w, `{"success":true}`)
+	}))
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+	client := NewClient(serverURL, false)
+
+	setting, err := client.GetSetting("Message_MaxAllowedSize")
+	require.NoError(t, err)
+	assert.Equal(t, models.SettingTypeInt, setting.Type)
+	assert.Equal(t, int64(5000), setting.Value.Int)
+
+	value := models.NewIntValue(6000)
+	assert.NoError(t, client.UpdateSetting("Message_MaxAllowedSize", &models.UpdateSettingRequest{Value: &value}))
+}
+
+func TestSettingValue_Marshal(t *testing.T) {
+	data, err := json.Marshal(models.UpdateSettingRequest{Execute: true})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"execute":true}`, string(data))
+
+	value := models.NewMultiSelectValue("a", "b")
+	data, err = json.Marshal(models.UpdateSettingRequest{Value: &value})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"value":["a","b"]}`, string(data))
+}
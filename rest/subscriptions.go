@@ -0,0 +1,32 @@
+package rest
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/yazver/Rocket.Chat.Go.SDK/models"
+)
+
+type SubscriptionsResponse struct {
+	Status
+	Update []models.Subscription `json:"update"`
+	Remove []models.Subscription `json:"remove"`
+}
+
+// GetSubscriptions gets the subscriptions of the user. If updatedSince is set, only the
+// subscriptions changed or removed since then are returned.
+//
+// https://developer.rocket.chat/reference/api/rest-api/endpoints/core-endpoints/subscriptions-endpoints/get
+func (c *Client) GetSubscriptions(updatedSince *time.Time) (*SubscriptionsResponse, error) {
+	params := url.Values{}
+	if updatedSince != nil {
+		params.Set("updatedSince", updatedSince.UTC().Format(time.RFC3339Nano))
+	}
+
+	response := new(SubscriptionsResponse)
+	if err := c.Get("subscriptions.get", params, response); err != nil {
+		return nil, fmt.Errorf("get subscriptions: %w", err)
+	}
+	return response, nil
+}
@@ -0,0 +1,71 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/yazver/Rocket.Chat.Go.SDK/models"
+)
+
+const (
+	twoFactorRequired = "totp-required"
+	twoFactorInvalid  = "totp-invalid"
+)
+
+var (
+	ErrTwoFactorRequired = errors.New("two-factor authentication required")
+	ErrTwoFactorInvalid  = errors.New("invalid two-factor authentication code")
+)
+
+// TwoFactorError is returned when a call needs a two-factor authentication code and
+// Client.TwoFactorCode isn't set or the code was refused.
+type TwoFactorError struct {
+	Method  models.TwoFactorMethod
+	Message string
+
+	err error
+}
+
+func (e *TwoFactorError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("%v (%s): %s", e.err, e.Method, e.Message)
+	}
+	return fmt.Sprintf("%v (%s)", e.err, e.Method)
+}
+
+func (e *TwoFactorError) Unwrap() error {
+	return e.err
+}
+
+type twoFactorStatus struct {
+	Error     string `json:"error"`
+	ErrorType string `json:"errorType"`
+	Message   string `json:"message"`
+	Details   struct {
+		Method models.TwoFactorMethod `json:"method"`
+	} `json:"details"`
+}
+
+// twoFactorErrorFrom returns a *TwoFactorError if the response body is a two-factor error.
+func twoFactorErrorFrom(body []byte) *TwoFactorError {
+	status := new(twoFactorStatus)
+	if err := json.Unmarshal(body, status); err != nil {
+		return nil
+	}
+
+	e := &TwoFactorError{Method: status.Details.Method, Message: status.Message}
+	switch {
+	case status.ErrorType == twoFactorRequired || status.Error == twoFactorRequired:
+		e.err = ErrTwoFactorRequired
+	case status.ErrorType == twoFactorInvalid || status.Error == twoFactorInvalid:
+		e.err = ErrTwoFactorInvalid
+	default:
+		return nil
+	}
+
+	if e.Method == "" {
+		e.Method = models.TwoFactorMethodTOTP
+	}
+	return e
+}
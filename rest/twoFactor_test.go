@@ -0,0 +1,45 @@
+package rest
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yazver/Rocket.Chat.Go.SDK/models"
+)
+
+func TestRocket_LoginTwoFactor(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-2fa-Code") != "123456" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"status":"error","error":"totp-required","message":"TOTP Required [totp-required]","details":{"method":"email"}}`)
+			return
+		}
+		fmt.Fprint(w, `{"status":"success","data":{"authToken":"token","userId":"id","me":{"_id":"id","username":"user"}}}`)
+	}))
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+
+	client := NewClient(serverURL, false)
+	_, err := client.Login(&models.UserCredentials{Email: "user@localhost.com", Password: "pass"})
+	assert.True(t, errors.Is(err, ErrTwoFactorRequired))
+
+	var twoFactorErr *TwoFactorError
+	assert.True(t, errors.As(err, &twoFactorErr))
+	assert.Equal(t, models.TwoFactorMethodEmail, twoFactorErr.Method)
+
+	client = NewClient(serverURL, false)
+	client.TwoFactorCode = func(method models.TwoFactorMethod) (string, error) {
+		assert.Equal(t, models.TwoFactorMethodEmail, method)
+		return "123456", nil
+	}
+	credentials := &models.UserCredentials{Email: "user@localhost.com", Password: "pass"}
+	_, err = client.Login(credentials)
+	assert.Nil(t, err)
+	assert.Equal(t, "token", credentials.Token)
+}
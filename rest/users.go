@@ -2,12 +2,16 @@ package rest
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
 	"net/url"
-	"time"
+	"strconv"
 
+	"github.com/yazver/Rocket.Chat.Go.SDK/auth"
 	"github.com/yazver/Rocket.Chat.Go.SDK/models"
 )
 
@@ -21,8 +25,9 @@ type logoutResponse struct {
 type logonResponse struct {
 	Status
 	Data struct {
-		Token  string `json:"authToken"`
-		UserID string `json:"userID"`
+		Token  string       `json:"authToken"`
+		UserID string       `json:"userID"`
+		Me     *models.User `json:"me"`
 	} `json:"data"`
 }
 
@@ -32,51 +37,132 @@ type UserResponse struct {
 }
 type CreateUserResponse struct {
 	Status
-	User struct {
-		ID        string    `json:"_id"`
-		CreatedAt time.Time `json:"createdAt"`
-		Services  struct {
-			Password struct {
-				Bcrypt string `json:"bcrypt"`
-			} `json:"password"`
-		} `json:"services"`
-		Username string `json:"username"`
-		Emails   []struct {
-			Address  string `json:"address"`
-			Verified bool   `json:"verified"`
-		} `json:"emails"`
-		Type         string            `json:"type"`
-		Status       string            `json:"status"`
-		Active       bool              `json:"active"`
-		Roles        []string          `json:"roles"`
-		UpdatedAt    time.Time         `json:"_updatedAt"`
-		Name         string            `json:"name"`
-		CustomFields map[string]string `json:"customFields"`
-	} `json:"user"`
+	User models.User `json:"user"`
 }
 
-// Login a user. The Email and the Password are mandatory. The auth token of the user is stored in the Client instance.
+// Login a user. The Email or the Username and the Password are mandatory unless LDAP, OAuth or SAML
+// credentials are set, credentials.LoginMethod may force a method.
+// The auth token of the user is stored in the Client instance and its Session. A token already
+// known by the Session is used without logging in again.
+// If the account uses two-factor authentication, Client.TwoFactorCode is asked for the code.
+// The returned user is filled with the profile of the /me endpoint.
 //
 // https://rocket.chat/docs/developer-guides/rest-api/authentication/login
-func (c *Client) Login(credentials *models.UserCredentials) error {
-	if c.auth != nil {
-		return nil
+func (c *Client) Login(credentials *models.UserCredentials) (*models.User, error) {
+	return c.login(credentials, true)
+}
+
+// login logs in, with relogin a rejected token of the session is replaced by a new login.
+func (c *Client) login(credentials *models.UserCredentials, relogin bool) (*models.User, error) {
+	if c.Session == nil {
+		session, err := auth.NewSession(nil, credentials)
+		if err != nil {
+			return nil, err
+		}
+		c.Session = session
+	} else {
+		c.Session.SetLogin(credentials)
+	}
+
+	// The session drops the token when logging in as another user.
+	if current := c.getAuth(); current != nil {
+		if c.Session.Credentials().Token == current.token {
+			return c.me(relogin)
+		}
+		c.setAuth(nil)
 	}
 
-	if credentials.ID != "" && credentials.Token != "" {
-		c.auth = &authInfo{id: credentials.ID, token: credentials.Token}
-		return nil
+	if c.Session.HasToken() {
+		current := c.Session.Credentials()
+		c.setAuth(&authInfo{id: current.ID, token: current.Token})
+		credentials.ID, credentials.Token = current.ID, current.Token
+		return c.me(relogin)
+	}
+
+	request, err := loginRequest(credentials)
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling login request data: %w", err)
 	}
 
 	response := new(logonResponse)
-	data := url.Values{"user": {credentials.Email}, "password": {credentials.Password}}
-	if err := c.PostForm("login", data, response); err != nil {
-		return err
+	if err := c.Post("login", bytes.NewBuffer(body), response); err != nil {
+		return nil, err
 	}
 
-	c.auth = &authInfo{id: response.Data.UserID, token: response.Data.Token}
+	c.setAuth(&authInfo{id: response.Data.UserID, token: response.Data.Token})
 	credentials.ID, credentials.Token = response.Data.UserID, response.Data.Token
-	return nil
+	if err := c.Session.SetToken(response.Data.UserID, response.Data.Token); err != nil {
+		return nil, err
+	}
+
+	if response.Data.Me != nil {
+		return response.Data.Me, nil
+	}
+	return c.me(relogin)
+}
+
+func loginRequest(credentials *models.UserCredentials) (map[string]interface{}, error) {
+	switch method := credentials.Method(); method {
+	case models.LoginMethodEmail:
+		return map[string]interface{}{"user": credentials.Email, "password": credentials.Password}, nil
+	case models.LoginMethodUsername:
+		return map[string]interface{}{"username": credentials.Username, "password": credentials.Password}, nil
+	case models.LoginMethodLDAP:
+		return map[string]interface{}{
+			"ldap":        true,
+			"username":    credentials.Login(),
+			"ldapPass":    credentials.Password,
+			"ldapOptions": map[string]interface{}{},
+		}, nil
+	case models.LoginMethodOAuth:
+		return map[string]interface{}{
+			"serviceName": credentials.ServiceName,
+			"accessToken": credentials.AccessToken,
+			"secret":      credentials.AccessTokenSecret,
+			"expiresIn":   credentials.ExpiresIn,
+		}, nil
+	case models.LoginMethodSAML:
+		return map[string]interface{}{
+			"saml":            true,
+			"credentialToken": credentials.SAMLCredentialToken,
+		}, nil
+	case models.LoginMethodToken:
+		return map[string]interface{}{"resume": credentials.Token}, nil
+	default:
+		return nil, fmt.Errorf("unknown login method %q", method)
+	}
+}
+
+type meResponse struct {
+	Status
+	User models.User `json:"-"`
+}
+
+// UnmarshalJSON decodes the status and the user, which share the top level of the response.
+func (r *meResponse) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &r.Status); err != nil {
+		return err
+	}
+	return json.Unmarshal(data, &r.User)
+}
+
+// Me returns the profile of the logged in user.
+//
+// https://developer.rocket.chat/reference/api/rest-api/endpoints/core-endpoints/authentication-endpoints/me
+func (c *Client) Me() (*models.User, error) {
+	return c.me(true)
+}
+
+func (c *Client) me(relogin bool) (*models.User, error) {
+	response := new(meResponse)
+	if err := c.request(http.MethodGet, "me", nil, nil, "", response, relogin); err != nil {
+		return nil, fmt.Errorf("me: %w", err)
+	}
+	return &response.User, nil
 }
 
 // CreateToken creates an access token for a user
@@ -97,7 +183,8 @@ func (c *Client) CreateToken(userID, username string) (*models.UserCredentials,
 //
 // https://rocket.chat/docs/developer-guides/rest-api/authentication/logout
 func (c *Client) Logout() (string, error) {
-	if c.auth == nil {
+	auth := c.getAuth()
+	if auth == nil {
 		return "Was not logged in", nil
 	}
 
@@ -106,6 +193,13 @@ func (c *Client) Logout() (string, error) {
 		return "", err
 	}
 
+	if c.Session != nil {
+		if err := c.Session.ClearToken(auth.token); err != nil {
+			return "", err
+		}
+	}
+	c.setAuth(nil)
+
 	return response.Data.Message, nil
 }
 
@@ -127,6 +221,7 @@ func (c *Client) CreateUser(req *models.CreateUserRequest) (*CreateUserResponse,
 }
 
 // UpdateUser updates a user's data being logged in with a user that has permission to do so.
+// Only the fields set in req.Data are changed.
 //
 // https://rocket.chat/docs/developer-guides/rest-api/users/update/
 func (c *Client) UpdateUser(req *models.UpdateUserRequest) (*CreateUserResponse, error) {
@@ -136,12 +231,37 @@ func (c *Client) UpdateUser(req *models.UpdateUserRequest) (*CreateUserResponse,
 	}
 
 	response := new(CreateUserResponse)
-	err = c.Post("users.update", bytes.NewBuffer(body), response)
-	return response, err
+	if err := c.Post("users.update", bytes.NewBuffer(body), response); err != nil {
+		return nil, fmt.Errorf("update user: %w", err)
+	}
+	return response, nil
+}
+
+// UpdateOwnBasicInfo updates the profile of the logged in user. Only the set fields are changed.
+// The passwords are passed in plain text, the current one is sent as SHA-256 digest.
+//
+// https://developer.rocket.chat/reference/api/rest-api/endpoints/core-endpoints/users-endpoints/update-own-basic-information
+func (c *Client) UpdateOwnBasicInfo(req *models.UpdateOwnBasicInfoRequest) (*models.User, error) {
+	data := *req
+	if data.CurrentPassword != "" {
+		digest := sha256.Sum256([]byte(data.CurrentPassword))
+		data.CurrentPassword = hex.EncodeToString(digest[:])
+	}
+
+	body, err := json.Marshal(map[string]interface{}{"data": data})
+	if err != nil {
+		return nil, fmt.Errorf("marshaling update own basic info request data: %w", err)
+	}
+
+	response := new(UserResponse)
+	if err := c.Post("users.updateOwnBasicInfo", bytes.NewBuffer(body), response); err != nil {
+		return nil, fmt.Errorf("update own basic info: %w", err)
+	}
+	return &response.User, nil
 }
 
 // SetUserAvatar updates a user's avatar being logged in with a user that has permission to do so.
-// Currently only passing an URL is possible.
+// The avatar is loaded from the URL, use UploadUserAvatar to upload image data.
 //
 // https://rocket.chat/docs/developer-guides/rest-api/users/setavatar/
 func (c *Client) SetUserAvatar(userID, username, avatarURL string) (*Status, error) {
@@ -173,3 +293,355 @@ func (c *Client) GetUserInfo(user *models.User) (*models.User, error) {
 
 	return &response.User, nil
 }
+
+type personalAccessTokenResponse struct {
+	Status
+	Token string `json:"token"`
+}
+
+type PersonalAccessTokensResponse struct {
+	Status
+	Tokens []models.PersonalAccessToken `json:"tokens"`
+}
+
+// GeneratePersonalAccessToken generates a personal access token for the logged in user.
+// The token is used together with the user ID, e.g. in models.UserCredentials.
+//
+// https://developer.rocket.chat/reference/api/rest-api/endpoints/core-endpoints/users-endpoints/generate-personal-access-token
+func (c *Client) GeneratePersonalAccessToken(req *models.GeneratePersonalAccessTokenRequest) (string, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return "", fmt.Errorf("marshaling personal access token request data: %w", err)
+	}
+
+	response := new(personalAccessTokenResponse)
+	if err := c.Post("users.generatePersonalAccessToken", bytes.NewBuffer(body), response); err != nil {
+		return "", fmt.Errorf("generate personal access token: %w", err)
+	}
+	return response.Token, nil
+}
+
+// RegeneratePersonalAccessToken replaces the token with the name by a new one.
+//
+// https://developer.rocket.chat/reference/api/rest-api/endpoints/core-endpoints/users-endpoints/regenerate-personal-access-token
+func (c *Client) RegeneratePersonalAccessToken(tokenName string) (string, error) {
+	body, err := json.Marshal(map[string]string{"tokenName": tokenName})
+	if err != nil {
+		return "", fmt.Errorf("marshaling personal access token request data: %w", err)
+	}
+
+	response := new(personalAccessTokenResponse)
+	if err := c.Post("users.regeneratePersonalAccessToken", bytes.NewBuffer(body), response); err != nil {
+		return "", fmt.Errorf("regenerate personal access token: %w", err)
+	}
+	return response.Token, nil
+}
+
+// GetPersonalAccessTokens lists the personal access tokens of the logged in user.
+//
+// https://developer.rocket.chat/reference/api/rest-api/endpoints/core-endpoints/users-endpoints/get-personal-access-tokens
+func (c *Client) GetPersonalAccessTokens() ([]models.PersonalAccessToken, error) {
+	response := new(PersonalAccessTokensResponse)
+	if err := c.Get("users.getPersonalAccessTokens", nil, response); err != nil {
+		return nil, fmt.Errorf("get personal access tokens: %w", err)
+	}
+	return response.Tokens, nil
+}
+
+// RemovePersonalAccessToken removes the token with the name.
+//
+// https://developer.rocket.chat/reference/api/rest-api/endpoints/core-endpoints/users-endpoints/remove-personal-access-token
+func (c *Client) RemovePersonalAccessToken(tokenName string) error {
+	body, err := json.Marshal(map[string]string{"tokenName": tokenName})
+	if err != nil {
+		return fmt.Errorf("marshaling personal access token request data: %w", err)
+	}
+
+	if err := c.Post("users.removePersonalAccessToken", bytes.NewBuffer(body), new(Status)); err != nil {
+		return fmt.Errorf("remove personal access token: %w", err)
+	}
+	return nil
+}
+
+type UsersResponse struct {
+	Status
+	models.Pagination
+	Users []models.User `json:"users"`
+}
+
+type UserPresenceResponse struct {
+	Status
+	models.UserPresence
+}
+
+type UserPreferencesResponse struct {
+	Status
+	Preferences models.UserPreferences `json:"preferences"`
+}
+
+type setUserPreferencesResponse struct {
+	Status
+	User struct {
+		ID       string `json:"_id"`
+		Settings struct {
+			Preferences models.UserPreferences `json:"preferences"`
+		} `json:"settings"`
+	} `json:"user"`
+}
+
+type deactivateIdleResponse struct {
+	Status
+	Count int `json:"count"`
+}
+
+type DataDownloadResponse struct {
+	Status
+	Requested       bool                   `json:"requested"`
+	ExportOperation models.ExportOperation `json:"exportOperation"`
+}
+
+// userIdentity returns the userId or the username of the user, the ID is preferred.
+func userIdentity(user *models.User) (string, string, error) {
+	switch {
+	case user.ID != "":
+		return "userId", user.ID, nil
+	case user.UserName != "":
+		return "username", user.UserName, nil
+	default:
+		return "", "", errors.New("user.UserName or user.ID must be set")
+	}
+}
+
+func (c *Client) postUser(api string, user *models.User, data map[string]interface{}, response Response) error {
+	key, value, err := userIdentity(user)
+	if err != nil {
+		return err
+	}
+	if data == nil {
+		data = map[string]interface{}{}
+	}
+	data[key] = value
+
+	body, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("marshaling %s request data: %w", api, err)
+	}
+	return c.Post(api, bytes.NewBuffer(body), response)
+}
+
+// ListUsers gets all of the users in the system and their information.
+// It supports the Offset, Count, and Sort Query Parameters along with Query and Fields Query Parameters.
+//
+// https://developer.rocket.chat/reference/api/rest-api/endpoints/core-endpoints/users-endpoints/get-users-list
+func (c *Client) ListUsers(params url.Values) (*UsersResponse, error) {
+	response := new(UsersResponse)
+	if err := c.Get("users.list", params, response); err != nil {
+		return nil, fmt.Errorf("users list: %w", err)
+	}
+	return response, nil
+}
+
+// DeleteUser deletes an existing user. If confirmRelinquish is set, rooms owned only by the user
+// are given up instead of failing.
+//
+// https://developer.rocket.chat/reference/api/rest-api/endpoints/core-endpoints/users-endpoints/delete-user
+func (c *Client) DeleteUser(user *models.User, confirmRelinquish bool) error {
+	data := map[string]interface{}{"confirmRelinquish": confirmRelinquish}
+	if err := c.postUser("users.delete", user, data, new(Status)); err != nil {
+		return fmt.Errorf("delete user: %w", err)
+	}
+	return nil
+}
+
+// SetUserActiveStatus activates or deactivates a user.
+//
+// https://developer.rocket.chat/reference/api/rest-api/endpoints/core-endpoints/users-endpoints/set-user-active-status
+func (c *Client) SetUserActiveStatus(userID string, active, confirmRelinquish bool) (*models.User, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"userId":            userID,
+		"activeStatus":      active,
+		"confirmRelinquish": confirmRelinquish,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marshaling active status request data: %w", err)
+	}
+
+	response := new(UserResponse)
+	if err := c.Post("users.setActiveStatus", bytes.NewBuffer(body), response); err != nil {
+		return nil, fmt.Errorf("set user active status: %w", err)
+	}
+	return &response.User, nil
+}
+
+// DeactivateIdleUsers deactivates the users idle for the number of days, optionally only of a role.
+// It returns the number of deactivated users.
+//
+// https://developer.rocket.chat/reference/api/rest-api/endpoints/core-endpoints/users-endpoints/deactivate-idle-users
+func (c *Client) DeactivateIdleUsers(daysIdle int, role string) (int, error) {
+	data := map[string]interface{}{"daysIdle": daysIdle}
+	if role != "" {
+		data["role"] = role
+	}
+	body, err := json.Marshal(data)
+	if err != nil {
+		return 0, fmt.Errorf("marshaling deactivate idle request data: %w", err)
+	}
+
+	response := new(deactivateIdleResponse)
+	if err := c.Post("users.deactivateIdle", bytes.NewBuffer(body), response); err != nil {
+		return 0, fmt.Errorf("deactivate idle users: %w", err)
+	}
+	return response.Count, nil
+}
+
+// ResetUserAvatar resets the avatar of a user to the default one.
+//
+// https://developer.rocket.chat/reference/api/rest-api/endpoints/core-endpoints/users-endpoints/reset-avatar
+func (c *Client) ResetUserAvatar(user *models.User) error {
+	if err := c.postUser("users.resetAvatar", user, nil, new(Status)); err != nil {
+		return fmt.Errorf("reset user avatar: %w", err)
+	}
+	return nil
+}
+
+// GetUserAvatarURL returns the URL of the avatar of a user, users.getAvatar redirects to it.
+//
+// https://developer.rocket.chat/reference/api/rest-api/endpoints/core-endpoints/users-endpoints/get-avatar
+func (c *Client) GetUserAvatarURL(user *models.User) (string, error) {
+	key, value, err := userIdentity(user)
+	if err != nil {
+		return "", err
+	}
+
+	request, err := http.NewRequest(http.MethodGet, c.getURL()+"/users.getAvatar?"+url.Values{key: {value}}.Encode(), nil)
+	if err != nil {
+		return "", fmt.Errorf("new request: %w", err)
+	}
+	c.setAuthHeaders(request)
+
+	client := *c.httpClient()
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	resp, err := client.Do(request)
+	if err != nil {
+		return "", fmt.Errorf("get user avatar: %w", err)
+	}
+	defer resp.Body.Close()
+
+	location, err := resp.Location()
+	if err != nil {
+		return "", fmt.Errorf("get user avatar: %s: %w", resp.Status, err)
+	}
+	return location.String(), nil
+}
+
+// ForgotPassword sends an email to reset the password of the user with the email.
+//
+// https://developer.rocket.chat/reference/api/rest-api/endpoints/core-endpoints/users-endpoints/forgot-password
+func (c *Client) ForgotPassword(email string) error {
+	body, err := json.Marshal(map[string]string{"email": email})
+	if err != nil {
+		return fmt.Errorf("marshaling forgot password request data: %w", err)
+	}
+
+	if err := c.Post("users.forgotPassword", bytes.NewBuffer(body), new(Status)); err != nil {
+		return fmt.Errorf("forgot password: %w", err)
+	}
+	return nil
+}
+
+// GetUserPresence gets the online presence of a user, of the logged in user if user is nil.
+//
+// https://developer.rocket.chat/reference/api/rest-api/endpoints/core-endpoints/users-endpoints/get-presence
+func (c *Client) GetUserPresence(user *models.User) (*models.UserPresence, error) {
+	params := url.Values{}
+	if user != nil {
+		key, value, err := userIdentity(user)
+		if err != nil {
+			return nil, err
+		}
+		params.Add(key, value)
+	}
+
+	response := new(UserPresenceResponse)
+	if err := c.Get("users.getPresence", params, response); err != nil {
+		return nil, fmt.Errorf("get user presence: %w", err)
+	}
+	return &response.UserPresence, nil
+}
+
+// SetUserStatus sets the status and the status message of the logged in user or of req.UserID.
+//
+// https://developer.rocket.chat/reference/api/rest-api/endpoints/core-endpoints/users-endpoints/set-status
+func (c *Client) SetUserStatus(req *models.SetUserStatusRequest) error {
+	if err := c.require(models.CapabilityCustomStatus); err != nil {
+		return fmt.Errorf("set user status: %w", err)
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("marshaling user status request data: %w", err)
+	}
+
+	if err := c.Post("users.setStatus", bytes.NewBuffer(body), new(Status)); err != nil {
+		return fmt.Errorf("set user status: %w", err)
+	}
+	return nil
+}
+
+// GetUserPreferences gets the preferences of the logged in user.
+//
+// https://developer.rocket.chat/reference/api/rest-api/endpoints/core-endpoints/users-endpoints/get-preferences
+func (c *Client) GetUserPreferences() (*models.UserPreferences, error) {
+	response := new(UserPreferencesResponse)
+	if err := c.Get("users.getPreferences", nil, response); err != nil {
+		return nil, fmt.Errorf("get user preferences: %w", err)
+	}
+	return &response.Preferences, nil
+}
+
+// SetUserPreferences changes the set preferences of a user and returns the resulting preferences.
+//
+// https://developer.rocket.chat/reference/api/rest-api/endpoints/core-endpoints/users-endpoints/set-preferences
+func (c *Client) SetUserPreferences(userID string, preferences *models.UserPreferences) (*models.UserPreferences, error) {
+	body, err := json.Marshal(map[string]interface{}{"userId": userID, "data": preferences})
+	if err != nil {
+		return nil, fmt.Errorf("marshaling user preferences request data: %w", err)
+	}
+
+	response := new(setUserPreferencesResponse)
+	if err := c.Post("users.setPreferences", bytes.NewBuffer(body), response); err != nil {
+		return nil, fmt.Errorf("set user preferences: %w", err)
+	}
+	return &response.User.Settings.Preferences, nil
+}
+
+// RegisterUser registers a new user, no login is needed. SecretURL is needed if registration is only allowed by secret URL.
+//
+// https://developer.rocket.chat/reference/api/rest-api/endpoints/core-endpoints/users-endpoints/register-user
+func (c *Client) RegisterUser(req *models.RegisterUserRequest) (*models.User, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling register user request data: %w", err)
+	}
+
+	response := new(UserResponse)
+	if err := c.Post("users.register", bytes.NewBuffer(body), response); err != nil {
+		return nil, fmt.Errorf("register user: %w", err)
+	}
+	return &response.User, nil
+}
+
+// RequestDataDownload requests a download of the personal data of the logged in user,
+// fullExport includes the messages and files of the user.
+//
+// https://developer.rocket.chat/reference/api/rest-api/endpoints/core-endpoints/users-endpoints/request-data-download
+func (c *Client) RequestDataDownload(fullExport bool) (*DataDownloadResponse, error) {
+	response := new(DataDownloadResponse)
+	params := url.Values{"fullExport": {strconv.FormatBool(fullExport)}}
+	if err := c.Get("users.requestDataDownload", params, response); err != nil {
+		return nil, fmt.Errorf("request data download: %w", err)
+	}
+	return response, nil
+}
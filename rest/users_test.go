@@ -1,10 +1,22 @@
 package rest
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yazver/Rocket.Chat.Go.SDK/auth"
 	"github.com/yazver/Rocket.Chat.Go.SDK/common_testing"
+	"github.com/yazver/Rocket.Chat.Go.SDK/models"
+	"github.com/yazver/Rocket.Chat.Go.SDK/realtime"
 )
 
 func TestRocket_LoginLogout(t *testing.T) {
@@ -16,3 +28,217 @@ func TestRocket_LoginLogout(t *testing.T) {
 	// assert.Nil(t, channels)
 	// assert.NotNil(t, err)
 }
+
+// you have to set create-personal-access-tokens permissions on role "user" to run this test successfully!
+func TestRocket_PersonalAccessTokens(t *testing.T) {
+	client := getAuthenticatedClient(t, common_testing.GetRandomString(), common_testing.GetRandomEmail(), common_testing.GetRandomString())
+
+	token, err := client.GeneratePersonalAccessToken(&models.GeneratePersonalAccessTokenRequest{TokenName: "bot", BypassTwoFactor: true})
+	assert.Nil(t, err)
+	assert.NotEmpty(t, token)
+
+	tokens, err := client.GetPersonalAccessTokens()
+	assert.Nil(t, err)
+	assert.Len(t, tokens, 1)
+	assert.Equal(t, "bot", tokens[0].Name)
+
+	regenerated, err := client.RegeneratePersonalAccessToken("bot")
+	assert.Nil(t, err)
+	assert.NotEqual(t, token, regenerated)
+
+	assert.Nil(t, client.RemovePersonalAccessToken("bot"))
+}
+
+func TestRocket_Relogin(t *testing.T) {
+	logins := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/v1/login":
+			logins++
+			fmt.Fprintf(w, `{"status":"success","data":{"authToken":"token%d","userId":"id","me":{"_id":"id","username":"bot"}}}`, logins)
+		case r.Header.Get("X-Auth-Token") != "token2":
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"status":"error","message":"You must be logged in to do this."}`)
+		default:
+			fmt.Fprint(w, `{"success":true,"user":{"_id":"id","username":"bot"}}`)
+		}
+	}))
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+	client := NewClient(serverURL, false)
+	session, err := auth.NewSession(auth.NewMemoryStore(), nil)
+	assert.Nil(t, err)
+	client.Session = session
+
+	me, err := client.Login(&models.UserCredentials{Email: "bot@localhost.com", Password: "pass"})
+	assert.Nil(t, err)
+	assert.Equal(t, "bot", me.UserName)
+	assert.Equal(t, "token1", client.Credentials().Token)
+
+	user, err := client.GetUserInfo(&models.User{ID: "id"})
+	assert.Nil(t, err)
+	assert.Equal(t, "bot", user.UserName)
+	assert.Equal(t, 2, logins)
+	assert.Equal(t, "token2", session.Credentials().Token)
+}
+
+func TestRocket_LoginAnotherUser(t *testing.T) {
+	alice := &models.UserCredentials{Name: common_testing.GetRandomString(), Email: common_testing.GetRandomEmail(), Password: testPassword}
+	bob := &models.UserCredentials{Name: common_testing.GetRandomString(), Email: common_testing.GetRandomEmail(), Password: testPassword}
+	rtClient, err := realtime.NewClient(testServer.URL(), false)
+	require.NoError(t, err)
+	defer rtClient.Close()
+	for _, credentials := range []*models.UserCredentials{alice, bob} {
+		_, err = rtClient.RegisterUser(credentials)
+		require.NoError(t, err)
+	}
+
+	session, err := auth.NewSession(auth.NewMemoryStore(), nil)
+	require.NoError(t, err)
+	client := NewClient(testServer.URL(), false)
+	client.Session = session
+
+	me, err := client.Login(&models.UserCredentials{Email: alice.Email, Password: alice.Password})
+	require.NoError(t, err)
+	assert.Equal(t, alice.Name, me.UserName)
+
+	// The token of alice isn't resumed for bob, neither by the client nor by another one.
+	me, err = client.Login(&models.UserCredentials{Email: bob.Email, Password: bob.Password})
+	require.NoError(t, err)
+	assert.Equal(t, bob.Name, me.UserName)
+
+	other := NewClient(testServer.URL(), false)
+	other.Session = session
+	me, err = other.Login(&models.UserCredentials{Email: alice.Email, Password: alice.Password})
+	require.NoError(t, err)
+	assert.Equal(t, alice.Name, me.UserName)
+}
+
+func TestRocket_ReloginRejectedLogin(t *testing.T) {
+	var mu sync.Mutex
+	logins := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.URL.Path == "/api/v1/login" {
+			logins++
+			fmt.Fprintf(w, `{"status":"success","data":{"authToken":"token%d","userId":"id"}}`, logins)
+			return
+		}
+		// Only the first token works, the tokens of new logins are rejected too.
+		if r.Header.Get("X-Auth-Token") != "token1" || logins > 1 {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"status":"error","message":"You must be logged in to do this."}`)
+			return
+		}
+		fmt.Fprint(w, `{"success":true,"_id":"id","username":"bot"}`)
+	}))
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+	client := NewClient(serverURL, false)
+	_, err := client.Login(&models.UserCredentials{Email: "bot@localhost.com", Password: "pass"})
+	require.NoError(t, err)
+
+	mu.Lock()
+	logins++
+	mu.Unlock()
+
+	// The requests run concurrently to let the race detector check the token handling.
+	var wg sync.WaitGroup
+	errs := make(chan error, 4)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := client.Me()
+			errs <- err
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the relogin is stuck")
+	}
+
+	close(errs)
+	for err := range errs {
+		assert.True(t, errors.Is(err, ErrUnauthorized))
+	}
+}
+
+func TestRocket_LoginRequest(t *testing.T) {
+	request, err := loginRequest(&models.UserCredentials{Username: "bot", Password: "pass"})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"username": "bot", "password": "pass"}, request)
+
+	request, err = loginRequest(&models.UserCredentials{Email: "bot@localhost.com", Username: "bot", Password: "pass"})
+	assert.Nil(t, err)
+	assert.Equal(t, "bot@localhost.com", request["user"])
+
+	request, err = loginRequest(&models.UserCredentials{Email: "bot@localhost.com", Username: "bot", Password: "pass", LoginMethod: models.LoginMethodUsername})
+	assert.Nil(t, err)
+	assert.Equal(t, "bot", request["username"])
+
+	request, err = loginRequest(&models.UserCredentials{Username: "bot", Password: "pass", LDAP: true})
+	assert.Nil(t, err)
+	assert.Equal(t, "bot", request["username"])
+	assert.Equal(t, true, request["ldap"])
+
+	_, err = loginRequest(&models.UserCredentials{LoginMethod: "kerberos"})
+	assert.NotNil(t, err)
+}
+
+func TestRocket_UserAdministration(t *testing.T) {
+	rocket := getDefaultClient(t)
+
+	users, err := rocket.ListUsers(url.Values{"count": {"10"}})
+	assert.Nil(t, err)
+	assert.NotEmpty(t, users.Users)
+
+	presence, err := rocket.GetUserPresence(nil)
+	assert.Nil(t, err)
+	assert.NotEmpty(t, presence.Presence)
+
+	assert.Nil(t, rocket.SetUserStatus(&models.SetUserStatusRequest{Status: "away", Message: "testing"}))
+
+	preferences, err := rocket.GetUserPreferences()
+	assert.Nil(t, err)
+	assert.NotNil(t, preferences)
+
+	avatarURL, err := rocket.GetUserAvatarURL(&models.User{UserName: testUserName})
+	assert.Nil(t, err)
+	assert.Contains(t, avatarURL, testUserName)
+}
+
+func TestRocket_UpdateUserPatch(t *testing.T) {
+	var received map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Nil(t, json.NewDecoder(r.Body).Decode(&received))
+		fmt.Fprint(w, `{"success":true,"user":{"_id":"id","username":"bot","active":false}}`)
+	}))
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+	client := NewClient(serverURL, false)
+
+	request := &models.UpdateUserRequest{UserID: "id"}
+	request.Data.Active = models.Bool(false)
+	request.Data.Roles = models.Strings("user", "bot")
+	request.Data.Name = models.String("")
+
+	response, err := client.UpdateUser(request)
+	assert.Nil(t, err)
+	assert.Equal(t, "bot", response.User.UserName)
+	assert.Equal(t, map[string]interface{}{
+		"userId": "id",
+		"data":   map[string]interface{}{"active": false, "roles": []interface{}{"user", "bot"}, "name": ""},
+	}, received)
+}
@@ -0,0 +1,321 @@
+// Package roomsync keeps a local copy of the rooms and subscriptions of a user. After the first
+// sync only the changes since the last one are loaded, and the changes the server streams are
+// applied as they come.
+//
+//	cache := roomsync.NewCache(roomsync.RealtimeLoader{Client: client})
+//	cache.OnChange(func(change roomsync.Change) { ... })
+//	if err := cache.Sync(); err != nil { ... }
+//	if err := cache.Watch(client); err != nil { ... }
+package roomsync
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/yazver/Rocket.Chat.Go.SDK/models"
+	"github.com/yazver/Rocket.Chat.Go.SDK/realtime"
+)
+
+// The actions of changes, like the server sends them.
+const (
+	ActionInserted = "inserted"
+	ActionUpdated  = "updated"
+	ActionRemoved  = "removed"
+)
+
+// Loader loads the rooms and subscriptions changed or removed after a time, the zero time
+// loads all of them.
+type Loader interface {
+	Rooms(since time.Time) (updated, removed []models.Channel, err error)
+	Subscriptions(since time.Time) (updated, removed []models.Subscription, err error)
+}
+
+// Change is a change of the cache, either of a room or of a subscription.
+type Change struct {
+	Action string
+	// Room is set for changes of rooms.
+	Room *models.Channel
+	// Subscription is set for changes of subscriptions.
+	Subscription *models.Subscription
+}
+
+// Cache is a local copy of the rooms and subscriptions of a user. Its methods are safe for
+// concurrent use.
+type Cache struct {
+	loader Loader
+
+	// syncMu serializes the syncs, mu guards the data.
+	syncMu sync.Mutex
+
+	mu            sync.Mutex
+	rooms         map[string]models.Channel
+	subscriptions map[string]models.Subscription
+	// roomSubscriptions maps room IDs to the IDs of their subscriptions.
+	roomSubscriptions map[string]string
+	// roomsSince and subscriptionsSince are the newest changes loaded, the next sync loads
+	// what changed after them.
+	roomsSince         time.Time
+	subscriptionsSince time.Time
+	listeners          []func(Change)
+}
+
+// NewCache creates an empty cache loading with the loader, Sync fills it.
+func NewCache(loader Loader) *Cache {
+	c := &Cache{loader: loader}
+	c.reset()
+	return c
+}
+
+// OnChange adds a listener which is called for every room and subscription inserted, updated
+// or removed. Listeners of a watched cache are called by the message loop of the client as
+// well, so they must not block or call methods of the client.
+func (c *Cache) OnChange(listener func(Change)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.listeners = append(c.listeners, listener)
+}
+
+// Sync loads the rooms and subscriptions changed since the last sync, the first sync loads all
+// of them. Call it after reconnects to catch up with the changes missed meanwhile.
+func (c *Cache) Sync() error {
+	c.syncMu.Lock()
+	defer c.syncMu.Unlock()
+
+	c.mu.Lock()
+	roomsSince, subscriptionsSince := c.roomsSince, c.subscriptionsSince
+	c.mu.Unlock()
+
+	updatedRooms, removedRooms, err := c.loader.Rooms(roomsSince)
+	if err != nil {
+		return fmt.Errorf("loading rooms: %w", err)
+	}
+	updatedSubscriptions, removedSubscriptions, err := c.loader.Subscriptions(subscriptionsSince)
+	if err != nil {
+		return fmt.Errorf("loading subscriptions: %w", err)
+	}
+
+	c.mu.Lock()
+	var changes []Change
+	for _, room := range updatedRooms {
+		changes = c.updateRoom(changes, room)
+		c.roomsSince = latest(c.roomsSince, room.UpdatedAt)
+	}
+	for _, room := range removedRooms {
+		changes = c.removeRoom(changes, room)
+		c.roomsSince = latest(c.roomsSince, room.DeletedAt)
+	}
+	for _, subscription := range updatedSubscriptions {
+		changes = c.updateSubscription(changes, subscription)
+		c.subscriptionsSince = latest(c.subscriptionsSince, subscription.UpdatedAt)
+	}
+	for _, subscription := range removedSubscriptions {
+		changes = c.removeSubscription(changes, subscription)
+		c.subscriptionsSince = latest(c.subscriptionsSince, subscription.DeletedAt)
+	}
+	c.mu.Unlock()
+
+	c.notify(changes)
+	return nil
+}
+
+// Reset empties the cache, so the next sync loads everything again. Listeners aren't told
+// about the rooms and subscriptions dropped.
+func (c *Cache) Reset() {
+	c.syncMu.Lock()
+	defer c.syncMu.Unlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reset()
+}
+
+func (c *Cache) reset() {
+	c.rooms = map[string]models.Channel{}
+	c.subscriptions = map[string]models.Subscription{}
+	c.roomSubscriptions = map[string]string{}
+	c.roomsSince = time.Time{}
+	c.subscriptionsSince = time.Time{}
+}
+
+// Watch keeps the cache up to date with the room and subscription changes the server sends
+// to the logged in user. Streamed changes don't move the time of the next sync, so changes
+// missed while disconnected are still loaded by it.
+func (c *Cache) Watch(client *realtime.Client) error {
+	if err := client.SubscribeToRoomChanges(c.applyRoomChange); err != nil {
+		return fmt.Errorf("subscribing to room changes: %w", err)
+	}
+	if err := client.SubscribeToSubscriptionChanges(c.applySubscriptionChange); err != nil {
+		return fmt.Errorf("subscribing to subscription changes: %w", err)
+	}
+	return nil
+}
+
+func (c *Cache) applyRoomChange(change models.RoomChange) {
+	c.mu.Lock()
+	var changes []Change
+	if change.Action == ActionRemoved {
+		changes = c.removeRoom(changes, change.Room)
+	} else {
+		changes = c.updateRoom(changes, change.Room)
+	}
+	c.mu.Unlock()
+
+	c.notify(changes)
+}
+
+func (c *Cache) applySubscriptionChange(change models.SubscriptionChange) {
+	c.mu.Lock()
+	var changes []Change
+	if change.Action == ActionRemoved {
+		changes = c.removeSubscription(changes, change.Subscription)
+	} else {
+		changes = c.updateSubscription(changes, change.Subscription)
+	}
+	c.mu.Unlock()
+
+	c.notify(changes)
+}
+
+// updateRoom stores the room unless the cached one is newer, it has to be called with the
+// lock held.
+func (c *Cache) updateRoom(changes []Change, room models.Channel) []Change {
+	cached, ok := c.rooms[room.ID]
+	if ok && (older(room.UpdatedAt, cached.UpdatedAt) || reflect.DeepEqual(room, cached)) {
+		return changes
+	}
+
+	c.rooms[room.ID] = room
+	action := ActionUpdated
+	if !ok {
+		action = ActionInserted
+	}
+	return append(changes, Change{Action: action, Room: &room})
+}
+
+// removeRoom drops the room unless the cached one was updated after it was removed, it has to
+// be called with the lock held.
+func (c *Cache) removeRoom(changes []Change, room models.Channel) []Change {
+	cached, ok := c.rooms[room.ID]
+	if !ok || older(room.DeletedAt, cached.UpdatedAt) {
+		return changes
+	}
+
+	delete(c.rooms, room.ID)
+	return append(changes, Change{Action: ActionRemoved, Room: &cached})
+}
+
+// updateSubscription stores the subscription unless the cached one is newer, it has to be
+// called with the lock held.
+func (c *Cache) updateSubscription(changes []Change, subscription models.Subscription) []Change {
+	cached, ok := c.subscriptions[subscription.ID]
+	if ok && (older(subscription.UpdatedAt, cached.UpdatedAt) || reflect.DeepEqual(subscription, cached)) {
+		return changes
+	}
+
+	c.subscriptions[subscription.ID] = subscription
+	c.roomSubscriptions[subscription.RoomID] = subscription.ID
+	action := ActionUpdated
+	if !ok {
+		action = ActionInserted
+	}
+	return append(changes, Change{Action: action, Subscription: &subscription})
+}
+
+// removeSubscription drops the subscription unless the cached one was updated after it was
+// removed, it has to be called with the lock held. Removed subscriptions may have only an ID.
+func (c *Cache) removeSubscription(changes []Change, subscription models.Subscription) []Change {
+	cached, ok := c.subscriptions[subscription.ID]
+	if !ok || older(subscription.DeletedAt, cached.UpdatedAt) {
+		return changes
+	}
+
+	delete(c.subscriptions, cached.ID)
+	if c.roomSubscriptions[cached.RoomID] == cached.ID {
+		delete(c.roomSubscriptions, cached.RoomID)
+	}
+	return append(changes, Change{Action: ActionRemoved, Subscription: &cached})
+}
+
+func (c *Cache) notify(changes []Change) {
+	if len(changes) == 0 {
+		return
+	}
+
+	c.mu.Lock()
+	listeners := append([]func(Change){}, c.listeners...)
+	c.mu.Unlock()
+
+	for _, change := range changes {
+		for _, listener := range listeners {
+			listener(change)
+		}
+	}
+}
+
+// Room returns a room by its ID.
+func (c *Cache) Room(roomID string) (models.Channel, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	room, ok := c.rooms[roomID]
+	return room, ok
+}
+
+// Rooms returns all rooms ordered by their names.
+func (c *Cache) Rooms() []models.Channel {
+	c.mu.Lock()
+	rooms := make([]models.Channel, 0, len(c.rooms))
+	for _, room := range c.rooms {
+		rooms = append(rooms, room)
+	}
+	c.mu.Unlock()
+
+	sort.Slice(rooms, func(i, j int) bool {
+		if rooms[i].Name != rooms[j].Name {
+			return rooms[i].Name < rooms[j].Name
+		}
+		return rooms[i].ID < rooms[j].ID
+	})
+	return rooms
+}
+
+// Subscription returns the subscription of the user to a room.
+func (c *Cache) Subscription(roomID string) (models.Subscription, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	subscription, ok := c.subscriptions[c.roomSubscriptions[roomID]]
+	return subscription, ok
+}
+
+// Subscriptions returns all subscriptions ordered by the names of their rooms.
+func (c *Cache) Subscriptions() []models.Subscription {
+	c.mu.Lock()
+	subscriptions := make([]models.Subscription, 0, len(c.subscriptions))
+	for _, subscription := range c.subscriptions {
+		subscriptions = append(subscriptions, subscription)
+	}
+	c.mu.Unlock()
+
+	sort.Slice(subscriptions, func(i, j int) bool {
+		if subscriptions[i].Name != subscriptions[j].Name {
+			return subscriptions[i].Name < subscriptions[j].Name
+		}
+		return subscriptions[i].ID < subscriptions[j].ID
+	})
+	return subscriptions
+}
+
+// older tells if the time is before the other one, unknown times aren't older.
+func older(t, other *time.Time) bool {
+	return t != nil && other != nil && t.Before(*other)
+}
+
+func latest(current time.Time, t *time.Time) time.Time {
+	if t != nil && t.After(current) {
+		return *t
+	}
+	return current
+}
@@ -0,0 +1,129 @@
+package roomsync
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yazver/Rocket.Chat.Go.SDK/fakeserver"
+	"github.com/yazver/Rocket.Chat.Go.SDK/models"
+	"github.com/yazver/Rocket.Chat.Go.SDK/realtime"
+	"github.com/yazver/Rocket.Chat.Go.SDK/rest"
+)
+
+// countingLoader remembers the times it loaded since.
+type countingLoader struct {
+	Loader
+	since []time.Time
+}
+
+func (l *countingLoader) Rooms(since time.Time) ([]models.Channel, []models.Channel, error) {
+	l.since = append(l.since, since)
+	return l.Loader.Rooms(since)
+}
+
+func TestCache_Sync(t *testing.T) {
+	server := fakeserver.New()
+	defer server.Close()
+	server.AddUser("bot", "pass", "bot")
+	news := server.AddRoom("news", models.RoomTypeChannel, "bot")
+
+	client := rest.NewClient(server.URL(), false)
+	_, err := client.Login(&models.UserCredentials{Username: "bot", Password: "pass"})
+	require.NoError(t, err)
+
+	loader := &countingLoader{Loader: RESTLoader{Client: client}}
+	cache := NewCache(loader)
+	var changes []Change
+	cache.OnChange(func(change Change) { changes = append(changes, change) })
+
+	require.NoError(t, cache.Sync())
+	rooms := cache.Rooms()
+	require.Len(t, rooms, 2)
+	assert.Equal(t, "general", rooms[0].Name)
+	assert.Equal(t, "news", rooms[1].Name)
+	assert.Len(t, cache.Subscriptions(), 2)
+	assert.Len(t, changes, 4)
+	for _, change := range changes {
+		assert.Equal(t, ActionInserted, change.Action)
+	}
+
+	changes = nil
+	require.NoError(t, cache.Sync())
+	assert.Empty(t, changes, "nothing changed")
+
+	time.Sleep(time.Millisecond)
+	require.NoError(t, client.SetRoomTopic(&models.Channel{ID: news.ID, Type: news.Type}, "headlines"))
+	require.NoError(t, client.LeaveRoomByID(fakeserver.GeneralRoomID))
+
+	changes = nil
+	require.NoError(t, cache.Sync())
+	room, ok := cache.Room(news.ID)
+	require.True(t, ok)
+	assert.Equal(t, "headlines", room.Topic)
+	_, ok = cache.Subscription(fakeserver.GeneralRoomID)
+	assert.False(t, ok, "the subscription of the room left is removed")
+
+	actions := map[string]int{}
+	for _, change := range changes {
+		actions[change.Action]++
+	}
+	assert.Equal(t, map[string]int{ActionUpdated: 2, ActionRemoved: 1}, actions)
+
+	require.Len(t, loader.since, 3)
+	assert.True(t, loader.since[0].IsZero())
+	assert.False(t, loader.since[2].Before(loader.since[1]))
+}
+
+func TestCache_Watch(t *testing.T) {
+	server := fakeserver.New()
+	defer server.Close()
+	server.AddUser("bot", "pass", "bot")
+	server.AddUser("alice", "pass")
+
+	client, err := realtime.NewClient(server.URL(), false)
+	require.NoError(t, err)
+	defer client.Close()
+	_, err = client.Login(&models.UserCredentials{Username: "bot", Password: "pass"})
+	require.NoError(t, err)
+
+	cache := NewCache(RealtimeLoader{Client: client})
+	require.NoError(t, cache.Sync())
+	assert.Len(t, cache.Rooms(), 1)
+
+	changes := make(chan Change, 10)
+	cache.OnChange(func(change Change) {
+		if change.Room != nil {
+			changes <- change
+		}
+	})
+	require.NoError(t, cache.Watch(client))
+
+	server.AddMessage(fakeserver.GeneralRoomID, "alice", "hello")
+	change := receive(t, changes)
+	assert.Equal(t, ActionUpdated, change.Action)
+	require.NotNil(t, change.Room)
+	assert.Equal(t, 1, change.Room.Msgs)
+
+	team := server.AddRoom("team", models.RoomTypeGroup, "bot", "alice")
+	change = receive(t, changes)
+	assert.Equal(t, ActionInserted, change.Action)
+	require.NotNil(t, change.Room)
+	assert.Equal(t, team.ID, change.Room.ID)
+
+	_, ok := cache.Room(team.ID)
+	assert.True(t, ok)
+}
+
+func receive(t *testing.T, changes chan Change) Change {
+	t.Helper()
+	select {
+	case change := <-changes:
+		return change
+	case <-time.After(time.Second):
+		t.Fatal("no change received")
+		return Change{}
+	}
+}
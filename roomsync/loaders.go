@@ -0,0 +1,51 @@
+package roomsync
+
+import (
+	"time"
+
+	"github.com/yazver/Rocket.Chat.Go.SDK/models"
+	"github.com/yazver/Rocket.Chat.Go.SDK/realtime"
+	"github.com/yazver/Rocket.Chat.Go.SDK/rest"
+)
+
+// RealtimeLoader loads with the rooms/get and subscriptions/get methods.
+type RealtimeLoader struct {
+	Client *realtime.Client
+}
+
+func (l RealtimeLoader) Rooms(since time.Time) ([]models.Channel, []models.Channel, error) {
+	return l.Client.GetRoomsUpdatedSince(since)
+}
+
+func (l RealtimeLoader) Subscriptions(since time.Time) ([]models.Subscription, []models.Subscription, error) {
+	return l.Client.GetSubscriptionsUpdatedSince(since)
+}
+
+// RESTLoader loads with rooms.get and subscriptions.get.
+type RESTLoader struct {
+	Client *rest.Client
+}
+
+func (l RESTLoader) Rooms(since time.Time) ([]models.Channel, []models.Channel, error) {
+	response, err := l.Client.GetRooms(updatedSince(since))
+	if err != nil {
+		return nil, nil, err
+	}
+	return response.Update, response.Remove, nil
+}
+
+func (l RESTLoader) Subscriptions(since time.Time) ([]models.Subscription, []models.Subscription, error) {
+	response, err := l.Client.GetSubscriptions(updatedSince(since))
+	if err != nil {
+		return nil, nil, err
+	}
+	return response.Update, response.Remove, nil
+}
+
+// updatedSince is nil for the zero time, which loads everything.
+func updatedSince(since time.Time) *time.Time {
+	if since.IsZero() {
+		return nil
+	}
+	return &since
+}
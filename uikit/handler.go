@@ -0,0 +1,141 @@
+// Package uikit dispatches UIKit interactions like block actions and view submissions to handlers.
+package uikit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+
+	"github.com/yazver/Rocket.Chat.Go.SDK/models"
+)
+
+var (
+	ErrNoHandler = errors.New("no handler for interaction")
+)
+
+// HandlerFunc handles an interaction. The response may be nil if the client doesn't need to do anything.
+type HandlerFunc func(interaction *models.Interaction) (*models.InteractionResponse, error)
+
+// Handler routes interactions by their type and action ID or view ID.
+// A handler registered with an empty ID is used when no other handler matches.
+type Handler struct {
+	// Use this switch to log all received interactions.
+	Debug bool
+
+	mu           sync.RWMutex
+	blockActions map[string]HandlerFunc
+	viewSubmits  map[string]HandlerFunc
+	viewCloses   map[string]HandlerFunc
+}
+
+func NewHandler() *Handler {
+	return &Handler{
+		blockActions: make(map[string]HandlerFunc),
+		viewSubmits:  make(map[string]HandlerFunc),
+		viewCloses:   make(map[string]HandlerFunc),
+	}
+}
+
+// OnBlockAction registers a handler for clicks and selections of the element with the action ID.
+func (h *Handler) OnBlockAction(actionID string, f HandlerFunc) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.blockActions[actionID] = f
+}
+
+// OnViewSubmit registers a handler for submissions of the modal with the view ID.
+func (h *Handler) OnViewSubmit(viewID string, f HandlerFunc) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.viewSubmits[viewID] = f
+}
+
+// OnViewClosed registers a handler called when the modal with the view ID is closed.
+func (h *Handler) OnViewClosed(viewID string, f HandlerFunc) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.viewCloses[viewID] = f
+}
+
+// Handle dispatches an interaction to the matching handler.
+func (h *Handler) Handle(interaction *models.Interaction) (*models.InteractionResponse, error) {
+	var (
+		handlers map[string]HandlerFunc
+		key      string
+	)
+
+	switch interaction.Type {
+	case models.InteractionTypeBlockAction:
+		handlers, key = h.blockActions, interaction.ActionID
+	case models.InteractionTypeViewSubmit:
+		handlers = h.viewSubmits
+		if interaction.Payload.View != nil {
+			key = interaction.Payload.View.ID
+		}
+	case models.InteractionTypeViewClosed:
+		handlers, key = h.viewCloses, interaction.Payload.ViewID
+		if key == "" && interaction.Payload.View != nil {
+			key = interaction.Payload.View.ID
+		}
+	default:
+		return nil, fmt.Errorf("unknown interaction type %q", interaction.Type)
+	}
+
+	h.mu.RLock()
+	f, ok := handlers[key]
+	if !ok {
+		f, ok = handlers[""]
+	}
+	h.mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%w: %s %s", ErrNoHandler, interaction.Type, key)
+	}
+	return f(interaction)
+}
+
+// ServeHTTP decodes an interaction from the request body and writes the handler response as JSON.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	interaction := new(models.Interaction)
+	if err := json.NewDecoder(r.Body).Decode(interaction); err != nil {
+		http.Error(w, fmt.Sprintf("decoding interaction: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	if h.Debug {
+		log.Printf("interaction %s: %+v", interaction.Type, interaction)
+	}
+
+	response, err := h.Handle(interaction)
+	switch {
+	case errors.Is(err, ErrNoHandler):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	case response == nil:
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if response.AppID == "" {
+		response.AppID = interaction.AppID
+	}
+	if response.TriggerID == "" {
+		response.TriggerID = interaction.TriggerID
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("writing interaction response: %v", err)
+	}
+}
@@ -0,0 +1,111 @@
+package uikit
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yazver/Rocket.Chat.Go.SDK/models"
+)
+
+func TestHandler_BlockAction(t *testing.T) {
+	handler := NewHandler()
+	handler.OnBlockAction("approve", func(interaction *models.Interaction) (*models.InteractionResponse, error) {
+		assert.Equal(t, "GENERAL", interaction.RoomID)
+		assert.Equal(t, "yes", interaction.Payload.Value)
+		return &models.InteractionResponse{
+			Type: models.InteractionResponseModalOpen,
+			View: &models.View{
+				ID:     "confirm",
+				Type:   models.ViewTypeModal,
+				Title:  models.NewPlainText("Confirm"),
+				Blocks: models.Blocks{models.SectionBlock{Text: models.NewMarkdownText("*Sure?*")}},
+			},
+		}, nil
+	})
+
+	body := `{"type":"blockAction","appId":"app","actionId":"approve","triggerId":"trigger","rid":"GENERAL","payload":{"blockId":"b","value":"yes"}}`
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(body)))
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	response := new(models.InteractionResponse)
+	assert.Nil(t, json.Unmarshal(recorder.Body.Bytes(), response))
+	assert.Equal(t, models.InteractionResponseModalOpen, response.Type)
+	assert.Equal(t, "app", response.AppID)
+	assert.Equal(t, "trigger", response.TriggerID)
+	assert.Len(t, response.View.Blocks, 1)
+	assert.IsType(t, models.SectionBlock{}, response.View.Blocks[0])
+}
+
+func TestHandler_ViewSubmit(t *testing.T) {
+	handler := NewHandler()
+	handler.OnViewSubmit("", func(interaction *models.Interaction) (*models.InteractionResponse, error) {
+		assert.Equal(t, "Bob", interaction.Payload.View.State["name"]["input"])
+		return nil, nil
+	})
+
+	body := `{"type":"viewSubmit","appId":"app","payload":{"view":{"id":"form","blocks":[],"state":{"name":{"input":"Bob"}}}}}`
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(body)))
+
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+}
+
+func TestHandler_NoHandler(t *testing.T) {
+	_, err := NewHandler().Handle(&models.Interaction{Type: models.InteractionTypeViewClosed})
+	assert.True(t, errors.Is(err, ErrNoHandler))
+}
+
+func TestBlocks_RoundTrip(t *testing.T) {
+	blocks := models.Blocks{
+		models.SectionBlock{
+			Text:      models.NewMarkdownText("Pick one"),
+			Accessory: models.OverflowElement{ActionID: "more", Options: []models.OptionObject{{Text: models.NewPlainText("A"), Value: "a"}}},
+		},
+		models.DividerBlock{},
+		models.ActionsBlock{Elements: []models.BlockElement{
+			models.ButtonElement{ActionID: "ok", Text: models.NewPlainText("OK"), Style: models.ButtonStylePrimary},
+			models.StaticSelectElement{ActionID: "sel", Options: []models.OptionObject{{Text: models.NewPlainText("B"), Value: "b"}}},
+		}},
+		models.ContextBlock{Elements: []models.BlockElement{*models.NewPlainText("small"), models.ImageElement{ImageURL: "http://x/y.png", AltText: "y"}}},
+		models.ImageBlock{ImageURL: "http://x/z.png", AltText: "z"},
+		models.InputBlock{Label: models.NewPlainText("Name"), Element: models.PlainTextInputElement{ActionID: "input"}},
+	}
+
+	raw, err := json.Marshal(blocks)
+	assert.Nil(t, err)
+
+	var decoded models.Blocks
+	assert.Nil(t, json.Unmarshal(raw, &decoded))
+	assert.Equal(t, blocks, decoded)
+}
+
+func TestBlocks_Unknown(t *testing.T) {
+	raw := `{"_id":"m","rid":"r","msg":"hi","blocks":[` +
+		`{"type":"callout","title":{"type":"plain_text","text":"Note"}},` +
+		`{"type":"preview","title":[{"type":"plain_text","text":"a"}]},` +
+		`{"type":"section","text":"not an object"},` +
+		`{"type":"actions","elements":[{"type":"datepicker","actionId":"d"},{"type":"button","text":{"type":"plain_text","text":"OK"}}]}]}`
+
+	var message models.Message
+	assert.Nil(t, json.Unmarshal([]byte(raw), &message))
+	assert.Equal(t, "hi", message.Msg)
+	assert.Len(t, message.Blocks, 4)
+	assert.Equal(t, models.BlockType("callout"), message.Blocks[0].BlockType())
+	assert.IsType(t, models.UnknownBlock{}, message.Blocks[1])
+	assert.IsType(t, models.UnknownBlock{}, message.Blocks[2])
+
+	actions := message.Blocks[3].(models.ActionsBlock)
+	assert.Equal(t, models.UnknownElement{Type: "datepicker", Raw: json.RawMessage(`{"type":"datepicker","actionId":"d"}`)}, actions.Elements[0])
+	assert.IsType(t, models.ButtonElement{}, actions.Elements[1])
+
+	encoded, err := json.Marshal(message.Blocks[:2])
+	assert.Nil(t, err)
+	assert.JSONEq(t, `[{"type":"callout","title":{"type":"plain_text","text":"Note"}},{"type":"preview","title":[{"type":"plain_text","text":"a"}]}]`, string(encoded))
+}